@@ -253,7 +253,7 @@ func (xcc *XchainCryptoClient) PSIEncryptSampleIDSet(sampleID []string, privateK
 // PSIReEncryptIDSet 利用同态私钥二次加密样本ID列表
 // - encSet 一次加密后的ID列表
 // - privateKey 同态私钥
-func (xcc *XchainCryptoClient) PSIReEncryptIDSet(encSet *linear_vertical.EncSet, privateKey *ecdsa.PrivateKey) *linear_vertical.EncSet {
+func (xcc *XchainCryptoClient) PSIReEncryptIDSet(encSet *linear_vertical.EncSet, privateKey *ecdsa.PrivateKey) (*linear_vertical.EncSet, error) {
 	return linear_vertical.ReEncryptIDSet(encSet, privateKey)
 }
 
@@ -261,7 +261,8 @@ func (xcc *XchainCryptoClient) PSIReEncryptIDSet(encSet *linear_vertical.EncSet,
 // - sampleID 原始ID列表
 // - reEncSetLocal 己方二次加密后的ID列表
 // - reEncSetOthers 其他方二次加密后的ID列表
-func (xcc *XchainCryptoClient) PSIntersect(sampleID []string, reEncSetLocal *linear_vertical.EncSet, reEncSetOthers []*linear_vertical.EncSet) []string {
+// 所有集合都需要经过DLEQ证明的验证，否则返回错误
+func (xcc *XchainCryptoClient) PSIntersect(sampleID []string, reEncSetLocal *linear_vertical.EncSet, reEncSetOthers []*linear_vertical.EncSet) ([]string, error) {
 	return linear_vertical.Intersect(sampleID, reEncSetLocal, reEncSetOthers)
 }
 
@@ -16,18 +16,26 @@ package big_polynomial
 import (
 	"math/big"
 
+	"github.com/legendzhouwd/cu_crypto/common/math/polynomial"
 	"github.com/legendzhouwd/cu_crypto/common/math/rand"
 )
 
 type PolynomialClient struct {
 	// A big prime which is used for Galois Field computing
 	prime *big.Int
+	field *polynomial.Field
 }
 
-// New new PolynomialClient with a prime
+// New new PolynomialClient with a prime, returns nil if prime is not an odd prime
 func New(prime *big.Int) *PolynomialClient {
+	field, err := polynomial.NewField(prime)
+	if err != nil {
+		return nil
+	}
+
 	pc := new(PolynomialClient)
 	pc.prime = prime
+	pc.field = field
 
 	return pc
 }
@@ -96,87 +104,42 @@ func (pc *PolynomialClient) Evaluate(polynomialCoefficients []*big.Int, specifie
 
 // Add 对2个多项式进行加法操作
 func (pc *PolynomialClient) Add(a []*big.Int, b []*big.Int) []*big.Int {
-	degree := len(a)
-	c := make([]*big.Int, degree)
-
-	// 初始化big int数组
-	for i := range c {
-		c[i] = big.NewInt(0)
+	length := len(a)
+	if len(b) > length {
+		length = len(b)
 	}
 
-	for i := 0; i < degree; i++ {
-		c[i] = a[i].Add(a[i], b[i])
-		c[i] = big.NewInt(0).Mod(c[i], pc.prime)
-	}
-
-	return c
+	return padding(pc.field.Add(a, b), length)
 }
 
 // Multiply 对2个多项式进行乘法操作
 func (pc *PolynomialClient) Multiply(a []*big.Int, b []*big.Int) []*big.Int {
-	degA := len(a)
-	degB := len(b)
-	result := make([]*big.Int, degA+degB-1)
-
-	// 初始化big int数组
-	for i := range result {
-		result[i] = big.NewInt(0)
+	if len(a) == 0 || len(b) == 0 {
+		return []*big.Int{}
 	}
 
-	for i := 0; i < degA; i++ {
-		for j := 0; j < degB; j++ {
-			temp := a[i].Mul(a[i], b[j])
-			result[i+j] = result[i+j].Add(result[i+j], temp)
-		}
-	}
-
-	return result
+	return padding(pc.field.Mul(a, b), len(a)+len(b)-1)
 }
 
 // Scale 将1个多项式与指定系数k进行乘法操作
 func (pc *PolynomialClient) Scale(a []*big.Int, k *big.Int) []*big.Int {
-	b := make([]*big.Int, len(a))
-
-	for i := 0; i < len(a); i++ {
-		b[i] = a[i].Mul(a[i], k)
-		b[i] = big.NewInt(0).Mod(b[i], pc.prime)
-	}
-
-	return b
+	return padding(pc.field.Scale(a, k), len(a))
 }
 
 // GetLagrangeBasePolynomial 获取拉格朗日基本多项式（插值基函数）
+// 返回的系数按降序排列
 func (pc *PolynomialClient) GetLagrangeBasePolynomial(xs []*big.Int, xpos int) []*big.Int {
-	var poly []*big.Int
-	poly = append(poly, big.NewInt(1))
-
-	denominator := big.NewInt(1)
-
-	for i := 0; i < len(xs); i++ {
-		if i != xpos {
-			currentTerm := make([]*big.Int, 2)
-			currentTerm[0] = big.NewInt(1)
-			currentTerm[1] = big.NewInt(0).Sub(big.NewInt(0), xs[i])
-			denominator = denominator.Mul(denominator, big.NewInt(0).Sub(xs[xpos], xs[i]))
-			poly = pc.Multiply(poly, currentTerm)
-		}
+	basis, err := pc.field.LagrangeBasis(xs, xpos)
+	if err != nil {
+		return nil
 	}
 
-	inverser := big.NewInt(0).ModInverse(denominator, pc.prime)
-
-	return pc.Scale(poly, inverser)
+	return reverse(padding(basis, len(xs)))
 }
 
 // GetPolynomialByPoints 利用Lagrange Polynomial Interpolation Formula，通过给定坐标点集合来计算多项式
+// 返回的系数按降序排列，最后一项为常数项
 func (pc *PolynomialClient) GetPolynomialByPoints(points map[int]*big.Int) []*big.Int {
-	degree := len(points)
-	bases := make([][]*big.Int, degree)
-	result := make([]*big.Int, degree)
-
-	for i := range result {
-		result[i] = big.NewInt(0)
-	}
-
 	var xs []*big.Int
 	var ys []*big.Int
 
@@ -185,13 +148,31 @@ func (pc *PolynomialClient) GetPolynomialByPoints(points map[int]*big.Int) []*bi
 		ys = append(ys, v)
 	}
 
-	for i := 0; i < degree; i++ {
-		bases[i] = pc.GetLagrangeBasePolynomial(xs, i)
+	result, err := pc.field.Interpolate(xs, ys)
+	if err != nil {
+		return nil
 	}
 
-	for i := 0; i < degree; i++ {
-		result = pc.Add(result, pc.Scale(bases[i], ys[i]))
+	return reverse(padding(result, len(points)))
+}
+
+// padding 高次补0，保持与原有接口一致的长度
+func padding(a polynomial.Polynomial, length int) []*big.Int {
+	result := make([]*big.Int, length)
+	for i := range result {
+		if i < len(a) {
+			result[i] = a[i]
+		} else {
+			result[i] = big.NewInt(0)
+		}
 	}
+	return result
+}
 
+func reverse(a []*big.Int) []*big.Int {
+	result := make([]*big.Int, len(a))
+	for i, c := range a {
+		result[len(a)-1-i] = c
+	}
 	return result
 }
@@ -0,0 +1,119 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// Pedersen承诺：C = v*G + r*H
+// H由HashToCurve得到，任何人都不知道log_G(H)，所以承诺是计算绑定、完美隐藏的
+// 承诺满足加法同态：C(v1, r1) + C(v2, r2) = C(v1+v2, r1+r2)
+
+const pedersenTag = "cu_crypto/pedersen/"
+
+var (
+	ErrVectorLengthMismatch = errors.New("the length of the vector does not match the generators")
+)
+
+// PedersenParams Pedersen承诺的公开参数
+type PedersenParams struct {
+	Curve elliptic.Curve
+	// 标量承诺使用曲线基点G
+	G *Point
+	// 盲化因子的生成元
+	H *Point
+	// 向量承诺的生成元
+	Gs []*Point
+}
+
+// NewPedersenParams 使用应用标签派生生成元，不同的标签得到互相独立的参数
+// vectorLength为0时只支持标量承诺
+func NewPedersenParams(curve elliptic.Curve, tag []byte, vectorLength int) (*PedersenParams, error) {
+	prefix := pedersenTag + curve.Params().Name + "/" + string(tag) + "/"
+
+	h, err := HashToCurve([]byte(prefix+"H"), curve)
+	if err != nil {
+		return nil, err
+	}
+
+	gs := make([]*Point, vectorLength)
+	for i := range gs {
+		gs[i], err = HashToCurve([]byte(prefix+"G"+strconv.Itoa(i)), curve)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := &PedersenParams{
+		Curve: curve,
+		G:     BasePoint(curve),
+		H:     h,
+		Gs:    gs,
+	}
+	return params, nil
+}
+
+// Commit 使用随机盲化因子对value做承诺，返回承诺和盲化因子
+func (pp *PedersenParams) Commit(value *big.Int) (*Point, *big.Int, error) {
+	blinding, err := RandomScalar(pp.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pp.CommitWithBlinding(value, blinding), blinding, nil
+}
+
+// CommitWithBlinding 计算C = value*G + blinding*H
+func (pp *PedersenParams) CommitWithBlinding(value, blinding *big.Int) *Point {
+	return AddPoints(mulBase(pp.Curve, value), mulPoint(pp.H, blinding))
+}
+
+// VerifyCommitment 打开承诺并校验
+func (pp *PedersenParams) VerifyCommitment(commitment *Point, value, blinding *big.Int) bool {
+	if commitment == nil || value == nil || blinding == nil {
+		return false
+	}
+	return pp.CommitWithBlinding(value, blinding).Equals(commitment)
+}
+
+// CommitVector 向量承诺：C = Σ v_i*G_i + blinding*H
+func (pp *PedersenParams) CommitVector(values []*big.Int, blinding *big.Int) (*Point, error) {
+	if len(values) > len(pp.Gs) {
+		return nil, ErrVectorLengthMismatch
+	}
+
+	scalars := append(append([]*big.Int{}, values...), blinding)
+	points := append(append([]*Point{}, pp.Gs[:len(values)]...), pp.H)
+	return MultiScalarMult(pp.Curve, scalars, points), nil
+}
+
+// VerifyVectorCommitment 打开向量承诺并校验
+func (pp *PedersenParams) VerifyVectorCommitment(commitment *Point, values []*big.Int, blinding *big.Int) bool {
+	if commitment == nil || blinding == nil {
+		return false
+	}
+	expected, err := pp.CommitVector(values, blinding)
+	if err != nil {
+		return false
+	}
+	return expected.Equals(commitment)
+}
+
+// AddCommitments 承诺的同态加法，结果可能是无穷远点
+func (pp *PedersenParams) AddCommitments(c1, c2 *Point) *Point {
+	return AddPoints(c1, c2)
+}
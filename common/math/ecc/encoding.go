@@ -0,0 +1,207 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+// SEC1 point formats
+// 点的编码格式，零值为非压缩格式，兼容elliptic.Marshal的输出
+const (
+	// 0x04 || X || Y
+	PointFormatUncompressed = iota
+	// 0x02/0x03 || X，前缀由Y的奇偶性决定
+	PointFormatCompressed
+)
+
+const (
+	prefixCompressedEven = 0x02
+	prefixCompressedOdd  = 0x03
+	prefixUncompressed   = 0x04
+)
+
+var (
+	ErrInvalidPointEncoding = errors.New("invalid SEC1 point encoding")
+	ErrPointNotOnCurve      = errors.New("the given point is not on the elliptic curve")
+	ErrPointNotInSubgroup   = errors.New("the given point is not in the prime order subgroup")
+	ErrInvalidPointFormat   = errors.New("invalid point format")
+)
+
+// CurveByName 根据曲线名称获取支持SEC1编码的曲线：P-256、P-384、SM2-P-256
+func CurveByName(name string) (elliptic.Curve, error) {
+	switch name {
+	case elliptic.P256().Params().Name:
+		return elliptic.P256(), nil
+	case elliptic.P384().Params().Name:
+		return elliptic.P384(), nil
+	case sm2.P256Sm2().Params().Name:
+		return sm2.P256Sm2(), nil
+	default:
+		return nil, fmt.Errorf("curve [%v] is not supported yet.", name)
+	}
+}
+
+// MarshalPoint 按SEC1格式编码曲线上的点
+func MarshalPoint(curve elliptic.Curve, x, y *big.Int, format int) ([]byte, error) {
+	if _, err := CurveByName(curve.Params().Name); err != nil {
+		return nil, err
+	}
+	if err := validatePoint(curve, x, y); err != nil {
+		return nil, err
+	}
+
+	byteLen := coordinateLength(curve)
+	switch format {
+	case PointFormatUncompressed:
+		data := make([]byte, 1+2*byteLen)
+		data[0] = prefixUncompressed
+		x.FillBytes(data[1 : 1+byteLen])
+		y.FillBytes(data[1+byteLen:])
+		return data, nil
+	case PointFormatCompressed:
+		data := make([]byte, 1+byteLen)
+		data[0] = byte(prefixCompressedEven + y.Bit(0))
+		x.FillBytes(data[1:])
+		return data, nil
+	default:
+		return nil, ErrInvalidPointFormat
+	}
+}
+
+// UnmarshalPoint 解析SEC1格式的点，自动识别压缩与非压缩格式，并校验点在曲线及素数阶子群上
+func UnmarshalPoint(curve elliptic.Curve, data []byte) (*Point, error) {
+	if _, err := CurveByName(curve.Params().Name); err != nil {
+		return nil, err
+	}
+
+	byteLen := coordinateLength(curve)
+	p := curve.Params().P
+	if len(data) == 0 {
+		return nil, ErrInvalidPointEncoding
+	}
+
+	var x, y *big.Int
+	switch data[0] {
+	case prefixUncompressed:
+		if len(data) != 1+2*byteLen {
+			return nil, ErrInvalidPointEncoding
+		}
+		x = new(big.Int).SetBytes(data[1 : 1+byteLen])
+		y = new(big.Int).SetBytes(data[1+byteLen:])
+	case prefixCompressedEven, prefixCompressedOdd:
+		if len(data) != 1+byteLen {
+			return nil, ErrInvalidPointEncoding
+		}
+		x = new(big.Int).SetBytes(data[1:])
+		if x.Cmp(p) >= 0 {
+			return nil, ErrInvalidPointEncoding
+		}
+		y = decompressY(curve, x, uint(data[0]-prefixCompressedEven))
+		if y == nil {
+			return nil, ErrPointNotOnCurve
+		}
+	default:
+		return nil, ErrInvalidPointEncoding
+	}
+
+	if err := validatePoint(curve, x, y); err != nil {
+		return nil, err
+	}
+	return newPoint(curve, x, y), nil
+}
+
+// CompressPoint 将非压缩格式的点转换为压缩格式，无需知道曲线参数
+func CompressPoint(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrInvalidPointEncoding
+	}
+	if data[0] == prefixCompressedEven || data[0] == prefixCompressedOdd {
+		return append([]byte{}, data...), nil
+	}
+	if data[0] != prefixUncompressed || len(data)%2 != 1 || len(data) < 3 {
+		return nil, ErrInvalidPointEncoding
+	}
+
+	byteLen := (len(data) - 1) / 2
+	compressed := make([]byte, 1+byteLen)
+	compressed[0] = prefixCompressedEven + data[len(data)-1]&1
+	copy(compressed[1:], data[1:1+byteLen])
+	return compressed, nil
+}
+
+// Marshal 将Point编码为SEC1非压缩格式
+func (p *Point) Marshal() ([]byte, error) {
+	return MarshalPoint(p.Curve, p.X, p.Y, PointFormatUncompressed)
+}
+
+// MarshalCompressed 将Point编码为SEC1压缩格式
+func (p *Point) MarshalCompressed() ([]byte, error) {
+	return MarshalPoint(p.Curve, p.X, p.Y, PointFormatCompressed)
+}
+
+// validatePoint 校验坐标范围以及点是否在曲线上
+// P-256、P-384和SM2-P-256的余因子均为1，曲线上除无穷远点外的所有点都在素数阶子群中
+func validatePoint(curve elliptic.Curve, x, y *big.Int) error {
+	if x == nil || y == nil {
+		return ErrPointNotOnCurve
+	}
+	p := curve.Params().P
+	if x.Sign() < 0 || y.Sign() < 0 || x.Cmp(p) >= 0 || y.Cmp(p) >= 0 {
+		return ErrPointNotOnCurve
+	}
+	// 无穷远点没有SEC1的坐标编码
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return ErrPointNotInSubgroup
+	}
+	if !curve.IsOnCurve(x, y) {
+		return ErrPointNotOnCurve
+	}
+	return nil
+}
+
+// decompressY 根据y^2 = x^3 - 3x + b计算y，三条曲线的参数a均为-3 mod p
+func decompressY(curve elliptic.Curve, x *big.Int, yBit uint) *big.Int {
+	params := curve.Params()
+	p := params.P
+
+	ySquared := new(big.Int).Exp(x, big.NewInt(3), p)
+	threeX := new(big.Int).Lsh(x, 1)
+	threeX.Add(threeX, x)
+	ySquared.Sub(ySquared, threeX)
+	ySquared.Add(ySquared, params.B)
+	ySquared.Mod(ySquared, p)
+
+	y := new(big.Int).ModSqrt(ySquared, p)
+	if y == nil {
+		return nil
+	}
+	if y.Bit(0) != yBit {
+		y.Sub(p, y)
+	}
+	// y为0时不存在奇数的解
+	if y.Bit(0) != yBit {
+		return nil
+	}
+	return y
+}
+
+func coordinateLength(curve elliptic.Curve) int {
+	return (curve.Params().BitSize + 7) / 8
+}
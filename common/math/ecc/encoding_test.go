@@ -0,0 +1,87 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointEncoding(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), sm2.P256Sm2()} {
+		byteLen := (curve.Params().BitSize + 7) / 8
+
+		for i := 0; i < 16; i++ {
+			k, err := rand.Int(rand.Reader, curve.Params().N)
+			require.Nil(t, err)
+			p := ScalarBaseMult(curve, k)
+
+			uncompressed, err := p.Marshal()
+			require.Nil(t, err)
+			require.Equal(t, 1+2*byteLen, len(uncompressed))
+			require.True(t, bytes.Equal(elliptic.Marshal(curve, p.X, p.Y), uncompressed))
+
+			compressed, err := p.MarshalCompressed()
+			require.Nil(t, err)
+			require.Equal(t, 1+byteLen, len(compressed))
+
+			converted, err := CompressPoint(uncompressed)
+			require.Nil(t, err)
+			require.True(t, bytes.Equal(compressed, converted))
+
+			for _, data := range [][]byte{uncompressed, compressed} {
+				q, err := UnmarshalPoint(curve, data)
+				require.Nil(t, err)
+				require.True(t, p.Equals(q))
+			}
+		}
+	}
+}
+
+func TestPointEncodingValidation(t *testing.T) {
+	curve := sm2.P256Sm2()
+	p := ScalarBaseMult(curve, big.NewInt(1))
+	compressed, err := p.MarshalCompressed()
+	require.Nil(t, err)
+
+	// 长度错误
+	_, err = UnmarshalPoint(curve, compressed[:32])
+	require.Equal(t, ErrInvalidPointEncoding, err)
+
+	// 未知前缀
+	invalid := append([]byte{0x05}, compressed[1:]...)
+	_, err = UnmarshalPoint(curve, invalid)
+	require.Equal(t, ErrInvalidPointEncoding, err)
+
+	// 不在曲线上的点
+	uncompressed, err := p.Marshal()
+	require.Nil(t, err)
+	uncompressed[len(uncompressed)-1] ^= 1
+	_, err = UnmarshalPoint(curve, uncompressed)
+	require.Equal(t, ErrPointNotOnCurve, err)
+
+	// 无穷远点
+	_, err = MarshalPoint(curve, big.NewInt(0), big.NewInt(0), PointFormatCompressed)
+	require.Equal(t, ErrPointNotInSubgroup, err)
+
+	// 不支持的曲线
+	_, err = UnmarshalPoint(elliptic.P224(), compressed)
+	require.NotNil(t, err)
+}
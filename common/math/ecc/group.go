@@ -0,0 +1,225 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math/big"
+	"math/bits"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 与elliptic包的约定一致，无穷远点使用(0, 0)表示
+
+// Infinity 返回曲线的无穷远点
+func Infinity(curve elliptic.Curve) *Point {
+	return newPoint(curve, new(big.Int), new(big.Int))
+}
+
+// IsInfinity 判断是否为无穷远点
+func (p *Point) IsInfinity() bool {
+	return p.X.Sign() == 0 && p.Y.Sign() == 0
+}
+
+// Neg 计算-P = (x, -y mod p)
+func (p *Point) Neg() *Point {
+	if p.IsInfinity() {
+		return Infinity(p.Curve)
+	}
+	y := new(big.Int).Sub(p.Curve.Params().P, p.Y)
+	return newPoint(p.Curve, new(big.Int).Set(p.X), y)
+}
+
+// MultiScalarMult 计算Σ k_i*P_i，相同的点会先合并标量，只做一次数乘
+func MultiScalarMult(curve elliptic.Curve, scalars []*big.Int, points []*Point) *Point {
+	n := curve.Params().N
+
+	merged := make(map[string]*big.Int)
+	order := []*Point{}
+	for i, p := range points {
+		if p.IsInfinity() {
+			continue
+		}
+		key := string(p.X.Bytes()) + "|" + string(p.Y.Bytes())
+		if k, exist := merged[key]; exist {
+			k.Add(k, scalars[i])
+			k.Mod(k, n)
+			continue
+		}
+		merged[key] = new(big.Int).Mod(scalars[i], n)
+		order = append(order, p)
+	}
+
+	ks := make([]*big.Int, len(order))
+	for i, p := range order {
+		ks[i] = merged[string(p.X.Bytes())+"|"+string(p.Y.Bytes())]
+	}
+	if len(order) > pippengerThreshold {
+		return pippenger(curve, ks, order)
+	}
+
+	result := Infinity(curve)
+	for i, p := range order {
+		result = AddPoints(result, mulPoint(p, ks[i]))
+	}
+	return result
+}
+
+// pippengerThreshold 点的数量超过该值时使用Pippenger算法，用于批量验证等大规模的多标量乘法
+const pippengerThreshold = 16
+
+// pippenger 分桶计算Σ k_i*P_i：标量按c比特分窗，每个窗口内将点加到对应的桶中，
+// 再通过累加和计算 Σ j*B_j，点加次数约为 (n + 2^c) * 256/c，远少于逐个数乘
+func pippenger(curve elliptic.Curve, scalars []*big.Int, points []*Point) *Point {
+	c := bits.Len(uint(len(points))) - 2
+	if c < 2 {
+		c = 2
+	}
+	if c > 12 {
+		c = 12
+	}
+	windows := (curve.Params().N.BitLen() + c - 1) / c
+
+	result := Infinity(curve)
+	buckets := make([]*Point, 1<<uint(c))
+	for w := windows - 1; w >= 0; w-- {
+		for i := 0; i < c && !result.IsInfinity(); i++ {
+			x, y := curve.Double(result.X, result.Y)
+			result = newPoint(curve, x, y)
+		}
+
+		for j := range buckets {
+			buckets[j] = Infinity(curve)
+		}
+		for i, k := range scalars {
+			index := 0
+			for b := c - 1; b >= 0; b-- {
+				index = index<<1 | int(k.Bit(w*c+b))
+			}
+			if index != 0 {
+				buckets[index] = AddPoints(buckets[index], points[i])
+			}
+		}
+
+		// Σ j*B_j = B_max + (B_max + B_max-1) + ...
+		running, sum := Infinity(curve), Infinity(curve)
+		for j := len(buckets) - 1; j >= 1; j-- {
+			running = AddPoints(running, buckets[j])
+			sum = AddPoints(sum, running)
+		}
+		result = AddPoints(result, sum)
+	}
+	return result
+}
+
+// AddPoints 点加，允许无穷远点参与运算
+func AddPoints(p, q *Point) *Point {
+	x, y := p.Curve.Add(p.X, p.Y, q.X, q.Y)
+	return newPoint(p.Curve, x, y)
+}
+
+func subPoints(p, q *Point) *Point {
+	return AddPoints(p, q.Neg())
+}
+
+// mulPoint 数乘，标量先模N，支持负数与0
+func mulPoint(p *Point, k *big.Int) *Point {
+	scalar := new(big.Int).Mod(k, p.Curve.Params().N)
+	if scalar.Sign() == 0 || p.IsInfinity() {
+		return Infinity(p.Curve)
+	}
+	x, y := p.Curve.ScalarMult(p.X, p.Y, scalar.Bytes())
+	return newPoint(p.Curve, x, y)
+}
+
+func mulBase(curve elliptic.Curve, k *big.Int) *Point {
+	scalar := new(big.Int).Mod(k, curve.Params().N)
+	if scalar.Sign() == 0 {
+		return Infinity(curve)
+	}
+	x, y := curve.ScalarBaseMult(scalar.Bytes())
+	return newPoint(curve, x, y)
+}
+
+// BasePoint 返回曲线的基点G
+func BasePoint(curve elliptic.Curve) *Point {
+	params := curve.Params()
+	return newPoint(curve, new(big.Int).Set(params.Gx), new(big.Int).Set(params.Gy))
+}
+
+// RandomScalar 生成[1, N)内的随机数
+func RandomScalar(curve elliptic.Curve) (*big.Int, error) {
+	n := curve.Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// ScalarBytes 将标量模N后编码为与N等长的大端字节数组
+func ScalarBytes(curve elliptic.Curve, k *big.Int) []byte {
+	n := curve.Params().N
+	return new(big.Int).Mod(k, n).FillBytes(make([]byte, (n.BitLen()+7)/8))
+}
+
+// newHash 与HashToCurve一致：SM2曲线使用SM3，其它曲线使用SHA-256
+func newHash(curve elliptic.Curve) hash.Hash {
+	if curve.Params().Name == sm2.P256Sm2().Params().Name {
+		return sm3.New()
+	}
+	return sha256.New()
+}
+
+// hashToScalar Fiat-Shamir变换，将带长度前缀的transcript哈希为模N的标量
+func hashToScalar(curve elliptic.Curve, tag string, elements ...[]byte) *big.Int {
+	h := newHash(curve)
+	writeElement(h, []byte(tag))
+	writeElement(h, []byte(curve.Params().Name))
+	for _, e := range elements {
+		writeElement(h, e)
+	}
+
+	e := new(big.Int).SetBytes(h.Sum(nil))
+	return e.Mod(e, curve.Params().N)
+}
+
+func writeElement(h hash.Hash, e []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(e)))
+	h.Write(length[:])
+	h.Write(e)
+}
+
+// pointBytes transcript中点的编码，无穷远点编码为单个0字节
+func pointBytes(p *Point) []byte {
+	if p.IsInfinity() {
+		return []byte{0}
+	}
+	data, err := MarshalPoint(p.Curve, p.X, p.Y, PointFormatCompressed)
+	if err != nil {
+		return elliptic.Marshal(p.Curve, p.X, p.Y)
+	}
+	return data
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiScalarMultPippenger(t *testing.T) {
+	for _, curve := range testCurves {
+		for _, size := range []int{pippengerThreshold + 1, 100} {
+			scalars := make([]*big.Int, size)
+			points := make([]*Point, size)
+			expected := Infinity(curve)
+			for i := range points {
+				k, err := RandomScalar(curve)
+				require.Nil(t, err)
+				x, err := RandomScalar(curve)
+				require.Nil(t, err)
+				scalars[i] = k
+				points[i] = mulBase(curve, x)
+				expected = AddPoints(expected, mulPoint(points[i], k))
+			}
+			require.True(t, expected.Equals(MultiScalarMult(curve, scalars, points)))
+
+			// 追加 k*(-P) 使结果与去掉P时相同，覆盖桶内点相消的情况
+			negScalars := append(scalars, scalars[0])
+			negPoints := append(points, points[0].Neg())
+			partial := MultiScalarMult(curve, scalars[1:], points[1:])
+			require.True(t, partial.Equals(MultiScalarMult(curve, negScalars, negPoints)))
+
+			// Σ k_i*P_i - Σ k_i*P_i == O
+			all := append(append([]*big.Int{}, scalars...), scalars...)
+			allPoints := append([]*Point{}, points...)
+			for _, p := range points {
+				allPoints = append(allPoints, p.Neg())
+			}
+			require.True(t, MultiScalarMult(curve, all, allPoints).IsInfinity())
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+// 非交互零知识证明，使用Fiat-Shamir变换：
+//
+// Schnorr证明：证明知道x，使得Y = x*B
+//	Prover:   k随机，T = k*B，c = H(B, Y, T, context)，s = k + c*x
+//	Verifier: s*B == T + c*Y
+//
+// Chaum-Pedersen DLEQ证明：证明log_G1(H1) == log_G2(H2)
+//	Prover:   k随机，A1 = k*G1，A2 = k*G2，c = H(G1, H1, G2, H2, A1, A2, context)，s = k + c*x
+//	Verifier: s*G1 == A1 + c*H1 且 s*G2 == A2 + c*H2
+//
+// 证明中保留承诺点而不是挑战值，这样多个证明可以通过随机线性组合一起验证。
+// context用于绑定会话信息（例如协议名、参与方身份），防止证明被挪用到其它场景。
+
+const (
+	schnorrProofTag = "cu_crypto/schnorr-pok"
+	dleqProofTag    = "cu_crypto/dleq"
+	dleqBatchTag    = "cu_crypto/dleq-batch"
+)
+
+// 批量验证时随机系数的比特数
+const batchWeightBits = 128
+
+var (
+	ErrInvalidProof         = errors.New("invalid proof encoding")
+	ErrInvalidProofParams   = errors.New("invalid params for proof")
+	ErrProofLengthsMismatch = errors.New("the number of statements and proofs do not match")
+)
+
+// SchnorrProof 离散对数知识证明
+type SchnorrProof struct {
+	Commitment *Point
+	Response   *big.Int
+}
+
+// DLEQProof 离散对数相等证明
+type DLEQProof struct {
+	A1       *Point
+	A2       *Point
+	Response *big.Int
+}
+
+// DLEQStatement DLEQ证明的陈述：log_G1(H1) == log_G2(H2)
+type DLEQStatement struct {
+	G1, H1, G2, H2 *Point
+}
+
+// ProveDiscreteLog 证明知道x使得Y = x*base，base为nil时使用曲线基点
+func ProveDiscreteLog(curve elliptic.Curve, x *big.Int, base *Point, context []byte) (*SchnorrProof, error) {
+	if x == nil {
+		return nil, ErrInvalidProofParams
+	}
+	if base == nil {
+		base = BasePoint(curve)
+	}
+	y := mulPoint(base, x)
+
+	k, err := RandomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	t := mulPoint(base, k)
+	c := schnorrChallenge(base, y, t, context)
+
+	// s = k + c*x mod N
+	s := new(big.Int).Mul(c, x)
+	s.Add(s, k)
+	s.Mod(s, curve.Params().N)
+
+	return &SchnorrProof{Commitment: t, Response: s}, nil
+}
+
+// VerifyDiscreteLog 验证Schnorr证明，base为nil时使用曲线基点
+func VerifyDiscreteLog(y *Point, base *Point, proof *SchnorrProof, context []byte) bool {
+	if !validProofPoints(y, proof) {
+		return false
+	}
+	if base == nil {
+		base = BasePoint(y.Curve)
+	}
+
+	c := schnorrChallenge(base, y, proof.Commitment, context)
+	lhs := mulPoint(base, proof.Response)
+	rhs := AddPoints(proof.Commitment, mulPoint(y, c))
+	return lhs.Equals(rhs)
+}
+
+// BatchVerifyDiscreteLog 批量验证Schnorr证明
+// 检查 Σρ_i*(s_i*B_i - T_i - c_i*Y_i) == O，其中ρ_i为随机系数；相同的B_i会合并为一次数乘
+func BatchVerifyDiscreteLog(ys []*Point, bases []*Point, proofs []*SchnorrProof, context []byte) bool {
+	if len(ys) == 0 || len(ys) != len(proofs) || (bases != nil && len(bases) != len(ys)) {
+		return false
+	}
+	curve := ys[0].Curve
+	n := curve.Params().N
+
+	scalars := []*big.Int{}
+	points := []*Point{}
+	for i, y := range ys {
+		if !validProofPoints(y, proofs[i]) || y.Curve.Params().Name != curve.Params().Name {
+			return false
+		}
+		base := BasePoint(curve)
+		if bases != nil && bases[i] != nil {
+			base = bases[i]
+		}
+
+		rho, err := randomWeight()
+		if err != nil {
+			return false
+		}
+		c := schnorrChallenge(base, y, proofs[i].Commitment, context)
+
+		scalars = append(scalars,
+			new(big.Int).Mul(rho, proofs[i].Response),
+			new(big.Int).Sub(n, rho),
+			new(big.Int).Neg(new(big.Int).Mul(rho, c)))
+		points = append(points, base, proofs[i].Commitment, y)
+	}
+
+	return MultiScalarMult(curve, scalars, points).IsInfinity()
+}
+
+// ProveDLEQ 证明H1 = x*G1与H2 = x*G2使用了相同的x
+func ProveDLEQ(curve elliptic.Curve, x *big.Int, g1, g2 *Point, context []byte) (*DLEQProof, error) {
+	if x == nil || g1 == nil || g2 == nil {
+		return nil, ErrInvalidProofParams
+	}
+	h1 := mulPoint(g1, x)
+	h2 := mulPoint(g2, x)
+
+	k, err := RandomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	a1 := mulPoint(g1, k)
+	a2 := mulPoint(g2, k)
+	c := dleqChallenge(&DLEQStatement{G1: g1, H1: h1, G2: g2, H2: h2}, a1, a2, context)
+
+	s := new(big.Int).Mul(c, x)
+	s.Add(s, k)
+	s.Mod(s, curve.Params().N)
+
+	return &DLEQProof{A1: a1, A2: a2, Response: s}, nil
+}
+
+// VerifyDLEQ 验证DLEQ证明
+func VerifyDLEQ(statement *DLEQStatement, proof *DLEQProof, context []byte) bool {
+	if !validDLEQ(statement, proof) {
+		return false
+	}
+
+	c := dleqChallenge(statement, proof.A1, proof.A2, context)
+	if !mulPoint(statement.G1, proof.Response).Equals(AddPoints(proof.A1, mulPoint(statement.H1, c))) {
+		return false
+	}
+	return mulPoint(statement.G2, proof.Response).Equals(AddPoints(proof.A2, mulPoint(statement.H2, c)))
+}
+
+// BatchVerifyDLEQ 批量验证多个DLEQ证明，两个验证等式分别使用独立的随机系数合并为一个等式
+func BatchVerifyDLEQ(statements []*DLEQStatement, proofs []*DLEQProof, context []byte) bool {
+	if len(statements) == 0 || len(statements) != len(proofs) {
+		return false
+	}
+	curve := statements[0].G1.Curve
+	n := curve.Params().N
+
+	scalars := []*big.Int{}
+	points := []*Point{}
+	for i, st := range statements {
+		if !validDLEQ(st, proofs[i]) || st.G1.Curve.Params().Name != curve.Params().Name {
+			return false
+		}
+		c := dleqChallenge(st, proofs[i].A1, proofs[i].A2, context)
+
+		for _, eq := range [][3]*Point{{st.G1, proofs[i].A1, st.H1}, {st.G2, proofs[i].A2, st.H2}} {
+			rho, err := randomWeight()
+			if err != nil {
+				return false
+			}
+			// ρ*(s*G - A - c*H)
+			scalars = append(scalars,
+				new(big.Int).Mul(rho, proofs[i].Response),
+				new(big.Int).Sub(n, rho),
+				new(big.Int).Neg(new(big.Int).Mul(rho, c)))
+			points = append(points, eq[0], eq[1], eq[2])
+		}
+	}
+
+	return MultiScalarMult(curve, scalars, points).IsInfinity()
+}
+
+// ProveDLEQBatch 用一个证明说明所有的outputs[i] = x*inputs[i]，且Y = x*base
+// 先用由全部输入输出派生的系数w_i计算M = Σw_i*inputs[i]、Z = Σw_i*outputs[i]，再证明log_base(Y) == log_M(Z)
+func ProveDLEQBatch(curve elliptic.Curve, x *big.Int, base *Point, inputs []*Point, context []byte) (*DLEQProof, error) {
+	if x == nil || len(inputs) == 0 {
+		return nil, ErrInvalidProofParams
+	}
+	if base == nil {
+		base = BasePoint(curve)
+	}
+
+	outputs := make([]*Point, len(inputs))
+	for i, in := range inputs {
+		outputs[i] = mulPoint(in, x)
+	}
+	m, _ := combineDLEQBatch(base, mulPoint(base, x), inputs, outputs, context)
+
+	return ProveDLEQ(curve, x, base, m, context)
+}
+
+// VerifyDLEQBatch 验证ProveDLEQBatch生成的证明
+func VerifyDLEQBatch(y *Point, base *Point, inputs, outputs []*Point, proof *DLEQProof, context []byte) bool {
+	if y == nil || len(inputs) == 0 || len(inputs) != len(outputs) {
+		return false
+	}
+	if base == nil {
+		base = BasePoint(y.Curve)
+	}
+	for i := range inputs {
+		if inputs[i] == nil || outputs[i] == nil || inputs[i].IsInfinity() || outputs[i].IsInfinity() {
+			return false
+		}
+	}
+
+	m, z := combineDLEQBatch(base, y, inputs, outputs, context)
+	return VerifyDLEQ(&DLEQStatement{G1: base, H1: y, G2: m, H2: z}, proof, context)
+}
+
+// combineDLEQBatch 派生系数并计算M与Z
+func combineDLEQBatch(base, y *Point, inputs, outputs []*Point, context []byte) (*Point, *Point) {
+	curve := base.Curve
+
+	h := newHash(curve)
+	writeElement(h, []byte(dleqBatchTag))
+	writeElement(h, pointBytes(base))
+	writeElement(h, pointBytes(y))
+	writeElement(h, context)
+	for i := range inputs {
+		writeElement(h, pointBytes(inputs[i]))
+		writeElement(h, pointBytes(outputs[i]))
+	}
+	seed := h.Sum(nil)
+
+	weights := make([]*big.Int, len(inputs))
+	for i := range weights {
+		weights[i] = hashToScalar(curve, dleqBatchTag, seed, []byte(strconv.Itoa(i)))
+	}
+
+	return MultiScalarMult(curve, weights, inputs), MultiScalarMult(curve, weights, outputs)
+}
+
+// Marshal 编码为 A1 || A2 || s，点使用压缩格式
+func (proof *DLEQProof) Marshal() ([]byte, error) {
+	a1, err := proof.A1.MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+	a2, err := proof.A2.MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+	s := make([]byte, coordinateLength(proof.A1.Curve))
+	proof.Response.FillBytes(s)
+
+	return append(append(a1, a2...), s...), nil
+}
+
+// UnmarshalDLEQProof 解析DLEQ证明
+func UnmarshalDLEQProof(curve elliptic.Curve, data []byte) (*DLEQProof, error) {
+	byteLen := coordinateLength(curve)
+	if len(data) != 3*byteLen+2 {
+		return nil, ErrInvalidProof
+	}
+	a1, err := UnmarshalPoint(curve, data[:byteLen+1])
+	if err != nil {
+		return nil, err
+	}
+	a2, err := UnmarshalPoint(curve, data[byteLen+1:2*byteLen+2])
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).SetBytes(data[2*byteLen+2:])
+	if s.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidProof
+	}
+
+	return &DLEQProof{A1: a1, A2: a2, Response: s}, nil
+}
+
+// Marshal 编码为 T || s，点使用压缩格式
+func (proof *SchnorrProof) Marshal() ([]byte, error) {
+	t, err := proof.Commitment.MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+	s := make([]byte, coordinateLength(proof.Commitment.Curve))
+	proof.Response.FillBytes(s)
+
+	return append(t, s...), nil
+}
+
+// UnmarshalSchnorrProof 解析Schnorr证明
+func UnmarshalSchnorrProof(curve elliptic.Curve, data []byte) (*SchnorrProof, error) {
+	byteLen := coordinateLength(curve)
+	if len(data) != 2*byteLen+1 {
+		return nil, ErrInvalidProof
+	}
+	t, err := UnmarshalPoint(curve, data[:byteLen+1])
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).SetBytes(data[byteLen+1:])
+	if s.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidProof
+	}
+
+	return &SchnorrProof{Commitment: t, Response: s}, nil
+}
+
+func schnorrChallenge(base, y, t *Point, context []byte) *big.Int {
+	return hashToScalar(base.Curve, schnorrProofTag, pointBytes(base), pointBytes(y), pointBytes(t), context)
+}
+
+func dleqChallenge(st *DLEQStatement, a1, a2 *Point, context []byte) *big.Int {
+	return hashToScalar(st.G1.Curve, dleqProofTag,
+		pointBytes(st.G1), pointBytes(st.H1), pointBytes(st.G2), pointBytes(st.H2),
+		pointBytes(a1), pointBytes(a2), context)
+}
+
+func validProofPoints(y *Point, proof *SchnorrProof) bool {
+	if y == nil || proof == nil || proof.Commitment == nil || proof.Response == nil {
+		return false
+	}
+	return !y.IsInfinity() && proof.Response.Sign() >= 0 && proof.Response.Cmp(y.Curve.Params().N) < 0
+}
+
+func validDLEQ(st *DLEQStatement, proof *DLEQProof) bool {
+	if st == nil || proof == nil || st.G1 == nil || st.H1 == nil || st.G2 == nil || st.H2 == nil {
+		return false
+	}
+	if proof.A1 == nil || proof.A2 == nil || proof.Response == nil {
+		return false
+	}
+	if st.G1.IsInfinity() || st.G2.IsInfinity() {
+		return false
+	}
+	return proof.Response.Sign() >= 0 && proof.Response.Cmp(st.G1.Curve.Params().N) < 0
+}
+
+// randomWeight 批量验证使用的128位随机系数
+func randomWeight() (*big.Int, error) {
+	for {
+		rho, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), batchWeightBits))
+		if err != nil {
+			return nil, err
+		}
+		if rho.Sign() != 0 {
+			return rho, nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecc
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/stretchr/testify/require"
+)
+
+var testCurves = []elliptic.Curve{elliptic.P256(), sm2.P256Sm2()}
+
+func TestPedersenCommitment(t *testing.T) {
+	for _, curve := range testCurves {
+		pp, err := NewPedersenParams(curve, []byte("test"), 3)
+		require.Nil(t, err)
+
+		v1, v2 := big.NewInt(100), big.NewInt(23)
+		c1, r1, err := pp.Commit(v1)
+		require.Nil(t, err)
+		c2, r2, err := pp.Commit(v2)
+		require.Nil(t, err)
+
+		require.True(t, pp.VerifyCommitment(c1, v1, r1))
+		require.False(t, pp.VerifyCommitment(c1, v2, r1))
+
+		// 加法同态
+		sum := pp.AddCommitments(c1, c2)
+		require.True(t, pp.VerifyCommitment(sum, big.NewInt(123), new(big.Int).Add(r1, r2)))
+
+		values := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+		cv, err := pp.CommitVector(values, r1)
+		require.Nil(t, err)
+		require.True(t, pp.VerifyVectorCommitment(cv, values, r1))
+		values[2] = big.NewInt(4)
+		require.False(t, pp.VerifyVectorCommitment(cv, values, r1))
+
+		_, err = pp.CommitVector(make([]*big.Int, 4), r1)
+		require.Equal(t, ErrVectorLengthMismatch, err)
+
+		// 不同标签得到不同的生成元
+		other, err := NewPedersenParams(curve, []byte("other"), 0)
+		require.Nil(t, err)
+		require.False(t, other.H.Equals(pp.H))
+	}
+}
+
+func TestSchnorrProof(t *testing.T) {
+	for _, curve := range testCurves {
+		ys := []*Point{}
+		proofs := []*SchnorrProof{}
+		for i := 0; i < 4; i++ {
+			x, err := RandomScalar(curve)
+			require.Nil(t, err)
+			proof, err := ProveDiscreteLog(curve, x, nil, []byte("ctx"))
+			require.Nil(t, err)
+
+			y := mulBase(curve, x)
+			require.True(t, VerifyDiscreteLog(y, nil, proof, []byte("ctx")))
+			require.False(t, VerifyDiscreteLog(y, nil, proof, []byte("other ctx")))
+
+			data, err := proof.Marshal()
+			require.Nil(t, err)
+			decoded, err := UnmarshalSchnorrProof(curve, data)
+			require.Nil(t, err)
+			require.True(t, VerifyDiscreteLog(y, nil, decoded, []byte("ctx")))
+
+			ys = append(ys, y)
+			proofs = append(proofs, proof)
+		}
+		require.True(t, BatchVerifyDiscreteLog(ys, nil, proofs, []byte("ctx")))
+
+		ys[1], ys[2] = ys[2], ys[1]
+		require.False(t, BatchVerifyDiscreteLog(ys, nil, proofs, []byte("ctx")))
+	}
+}
+
+func TestDLEQProof(t *testing.T) {
+	for _, curve := range testCurves {
+		statements := []*DLEQStatement{}
+		proofs := []*DLEQProof{}
+		for i := 0; i < 3; i++ {
+			x, err := RandomScalar(curve)
+			require.Nil(t, err)
+			g2, err := HashToCurve([]byte{byte(i)}, curve)
+			require.Nil(t, err)
+
+			proof, err := ProveDLEQ(curve, x, BasePoint(curve), g2, nil)
+			require.Nil(t, err)
+			st := &DLEQStatement{G1: BasePoint(curve), H1: mulBase(curve, x), G2: g2, H2: mulPoint(g2, x)}
+			require.True(t, VerifyDLEQ(st, proof, nil))
+
+			data, err := proof.Marshal()
+			require.Nil(t, err)
+			decoded, err := UnmarshalDLEQProof(curve, data)
+			require.Nil(t, err)
+			require.True(t, VerifyDLEQ(st, decoded, nil))
+
+			statements = append(statements, st)
+			proofs = append(proofs, proof)
+		}
+		require.True(t, BatchVerifyDLEQ(statements, proofs, nil))
+
+		// 第二个等式使用了不同的私钥
+		statements[1].H2 = mulPoint(statements[1].G2, big.NewInt(7))
+		require.False(t, VerifyDLEQ(statements[1], proofs[1], nil))
+		require.False(t, BatchVerifyDLEQ(statements, proofs, nil))
+	}
+}
+
+func TestDLEQBatchProof(t *testing.T) {
+	for _, curve := range testCurves {
+		x, err := RandomScalar(curve)
+		require.Nil(t, err)
+		y := mulBase(curve, x)
+
+		inputs := make([]*Point, 8)
+		outputs := make([]*Point, 8)
+		for i := range inputs {
+			inputs[i], err = HashToCurve([]byte{byte(i)}, curve)
+			require.Nil(t, err)
+			outputs[i] = mulPoint(inputs[i], x)
+		}
+
+		proof, err := ProveDLEQBatch(curve, x, nil, inputs, []byte("psi"))
+		require.Nil(t, err)
+		require.True(t, VerifyDLEQBatch(y, nil, inputs, outputs, proof, []byte("psi")))
+
+		// 任意一个输出使用其它私钥都会导致验证失败
+		outputs[5] = mulPoint(inputs[5], new(big.Int).Add(x, big.NewInt(1)))
+		require.False(t, VerifyDLEQBatch(y, nil, inputs, outputs, proof, []byte("psi")))
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package polynomial
+
+import (
+	"math/big"
+)
+
+// Domain multiplicative subgroup {1, w, w^2, ..., w^(n-1)} used by NTT
+// 由n次本原单位根生成的乘法子群，n是2的幂
+type Domain struct {
+	field        *Field
+	size         int
+	generator    *big.Int
+	generatorInv *big.Int
+	sizeInv      *big.Int
+}
+
+// NewDomain 构造不小于size的最小2的幂长度的求值域
+func (f *Field) NewDomain(size int) (*Domain, error) {
+	if size <= 0 {
+		return nil, ErrInvalidDomainSize
+	}
+	n := nextPowerOfTwo(size)
+
+	generator, err := f.RootOfUnity(n)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Domain{
+		field:        f,
+		size:         n,
+		generator:    generator,
+		generatorInv: new(big.Int).ModInverse(generator, f.modulus),
+		sizeInv:      new(big.Int).ModInverse(big.NewInt(int64(n)), f.modulus),
+	}
+	return d, nil
+}
+
+// Size 返回求值域的大小
+func (d *Domain) Size() int {
+	return d.size
+}
+
+// Generator 返回求值域的生成元w
+func (d *Domain) Generator() *big.Int {
+	return new(big.Int).Set(d.generator)
+}
+
+// Element 返回w^i
+func (d *Domain) Element(i int) *big.Int {
+	return new(big.Int).Exp(d.generator, big.NewInt(int64(i)), d.field.modulus)
+}
+
+// FFT evaluate the polynomial on every element of the domain
+// 计算多项式在求值域上所有点的值，结果的第i项为f(w^i)
+func (d *Domain) FFT(a Polynomial) ([]*big.Int, error) {
+	if len(a) > d.size {
+		return nil, ErrTooManyCoefficients
+	}
+	values := d.padded(a)
+	d.ntt(values, d.generator)
+	return values, nil
+}
+
+// InverseFFT interpolate the polynomial from its values on the domain
+// 通过求值域上所有点的值恢复多项式系数
+func (d *Domain) InverseFFT(values []*big.Int) (Polynomial, error) {
+	if len(values) > d.size {
+		return nil, ErrTooManyCoefficients
+	}
+	coefficients := d.padded(values)
+	d.ntt(coefficients, d.generatorInv)
+	for i := range coefficients {
+		coefficients[i] = d.field.mul(coefficients[i], d.sizeInv)
+	}
+	return Polynomial(coefficients).trim(), nil
+}
+
+// padded 拷贝并约减输入，不足的部分补0
+func (d *Domain) padded(a []*big.Int) []*big.Int {
+	values := make([]*big.Int, d.size)
+	for i := range values {
+		if i < len(a) {
+			values[i] = d.field.Reduce(a[i])
+		} else {
+			values[i] = big.NewInt(0)
+		}
+	}
+	return values
+}
+
+// ntt in-place iterative Cooley-Tukey transform
+// 原地迭代的Cooley-Tukey蝶形变换
+func (d *Domain) ntt(values []*big.Int, root *big.Int) {
+	n := len(values)
+	p := d.field.modulus
+
+	// 位逆序置换
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	t := new(big.Int)
+	for length := 2; length <= n; length <<= 1 {
+		// 当前层的单位根 root^(n/length)
+		step := new(big.Int).Exp(root, big.NewInt(int64(n/length)), p)
+		twiddles := make([]*big.Int, length/2)
+		twiddles[0] = big.NewInt(1)
+		for k := 1; k < length/2; k++ {
+			twiddles[k] = new(big.Int).Mul(twiddles[k-1], step)
+			twiddles[k].Mod(twiddles[k], p)
+		}
+
+		for start := 0; start < n; start += length {
+			for k := 0; k < length/2; k++ {
+				u := values[start+k]
+				v := values[start+k+length/2]
+				t.Mul(v, twiddles[k])
+				t.Mod(t, p)
+
+				values[start+k+length/2] = new(big.Int).Sub(u, t)
+				if values[start+k+length/2].Sign() < 0 {
+					values[start+k+length/2].Add(values[start+k+length/2], p)
+				}
+				values[start+k] = new(big.Int).Add(u, t)
+				if values[start+k].Cmp(p) >= 0 {
+					values[start+k].Sub(values[start+k], p)
+				}
+			}
+		}
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	return size
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package polynomial
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+var (
+	ErrInvalidModulus      = errors.New("modulus must be an odd prime")
+	ErrInvalidDomainSize   = errors.New("domain size is not supported by the field")
+	ErrDivisionByZero      = errors.New("division by zero polynomial")
+	ErrNotInvertible       = errors.New("element is not invertible")
+	ErrInvalidPoints       = errors.New("points are empty or mismatched")
+	ErrDuplicatePoints     = errors.New("x coordinates of the points must be distinct")
+	ErrInvalidDegree       = errors.New("degree must be non-negative")
+	ErrTooManyCoefficients = errors.New("number of coefficients exceeds the domain size")
+)
+
+// Field Galois Field GF(p)
+// 素数域，记录模数以及用于NTT的2-adicity和本原单位根
+type Field struct {
+	modulus *big.Int
+	// modulus-1 = 2^twoAdicity * oddFactor
+	twoAdicity uint
+	// 2^twoAdicity次本原单位根
+	rootOfUnity *big.Int
+}
+
+// NewField new a field with a prime modulus
+// 使用素数模数构造有限域，并计算NTT所需的本原单位根
+func NewField(modulus *big.Int) (*Field, error) {
+	if modulus == nil || modulus.Cmp(big.NewInt(2)) <= 0 || modulus.Bit(0) == 0 || !modulus.ProbablyPrime(20) {
+		return nil, ErrInvalidModulus
+	}
+
+	f := &Field{modulus: new(big.Int).Set(modulus)}
+
+	oddFactor := new(big.Int).Sub(modulus, big.NewInt(1))
+	for oddFactor.Bit(0) == 0 {
+		oddFactor.Rsh(oddFactor, 1)
+		f.twoAdicity++
+	}
+
+	// 二次非剩余g满足g^((p-1)/2) = -1，所以g^oddFactor的阶恰好是2^twoAdicity
+	g := big.NewInt(2)
+	for big.Jacobi(g, modulus) != -1 {
+		g.Add(g, big.NewInt(1))
+	}
+	f.rootOfUnity = new(big.Int).Exp(g, oddFactor, modulus)
+
+	return f, nil
+}
+
+// BLS12381Fr the scalar field of BLS12-381
+// BLS12-381的标量域，2-adicity为32，适合NTT
+func BLS12381Fr() *Field {
+	f, _ := NewField(fr.Modulus())
+	return f
+}
+
+// Modulus 返回模数的拷贝
+func (f *Field) Modulus() *big.Int {
+	return new(big.Int).Set(f.modulus)
+}
+
+// TwoAdicity 返回模数减一中因子2的个数，NTT支持的最大长度为2^TwoAdicity
+func (f *Field) TwoAdicity() uint {
+	return f.twoAdicity
+}
+
+// RootOfUnity 返回n次本原单位根，n必须是2的幂且不超过2^TwoAdicity
+func (f *Field) RootOfUnity(n int) (*big.Int, error) {
+	if n <= 0 || n&(n-1) != 0 {
+		return nil, ErrInvalidDomainSize
+	}
+	logN := uint(0)
+	for 1<<logN < n {
+		logN++
+	}
+	if logN > f.twoAdicity {
+		return nil, ErrInvalidDomainSize
+	}
+
+	root := new(big.Int).Set(f.rootOfUnity)
+	for i := logN; i < f.twoAdicity; i++ {
+		root.Mul(root, root)
+		root.Mod(root, f.modulus)
+	}
+	return root, nil
+}
+
+// Reduce 将整数约减到[0, p)
+func (f *Field) Reduce(x *big.Int) *big.Int {
+	return new(big.Int).Mod(x, f.modulus)
+}
+
+// RandomElement 生成[0, p)内均匀分布的随机数
+func (f *Field) RandomElement() (*big.Int, error) {
+	return rand.Int(rand.Reader, f.modulus)
+}
+
+// Inverse 计算模逆
+func (f *Field) Inverse(x *big.Int) (*big.Int, error) {
+	r := new(big.Int).Mod(x, f.modulus)
+	if r.Sign() == 0 {
+		return nil, ErrNotInvertible
+	}
+	return r.ModInverse(r, f.modulus), nil
+}
+
+// batchInverse Montgomery's trick, only one modular inversion for n elements
+// 批量求逆，只需要一次模逆运算
+func (f *Field) batchInverse(xs []*big.Int) ([]*big.Int, error) {
+	n := len(xs)
+	if n == 0 {
+		return []*big.Int{}, nil
+	}
+
+	prefix := make([]*big.Int, n)
+	acc := big.NewInt(1)
+	for i, x := range xs {
+		if new(big.Int).Mod(x, f.modulus).Sign() == 0 {
+			return nil, ErrNotInvertible
+		}
+		prefix[i] = new(big.Int).Set(acc)
+		acc.Mul(acc, x)
+		acc.Mod(acc, f.modulus)
+	}
+
+	inv := new(big.Int).ModInverse(acc, f.modulus)
+	result := make([]*big.Int, n)
+	for i := n - 1; i >= 0; i-- {
+		result[i] = new(big.Int).Mul(inv, prefix[i])
+		result[i].Mod(result[i], f.modulus)
+		inv.Mul(inv, xs[i])
+		inv.Mod(inv, f.modulus)
+	}
+	return result, nil
+}
+
+func (f *Field) add(a, b *big.Int) *big.Int {
+	r := new(big.Int).Add(a, b)
+	return r.Mod(r, f.modulus)
+}
+
+func (f *Field) sub(a, b *big.Int) *big.Int {
+	r := new(big.Int).Sub(a, b)
+	return r.Mod(r, f.modulus)
+}
+
+func (f *Field) mul(a, b *big.Int) *big.Int {
+	r := new(big.Int).Mul(a, b)
+	return r.Mod(r, f.modulus)
+}
+
+func (f *Field) neg(a *big.Int) *big.Int {
+	r := new(big.Int).Neg(a)
+	return r.Mod(r, f.modulus)
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package polynomial
+
+import (
+	"math/big"
+)
+
+// 点数少于该值时，批量求值直接使用Horner法则
+const batchEvaluateThreshold = 16
+
+// subproductTree tree[0] are the leaves (x - x_i), the root is Π(x - x_i)
+// 子积树：叶子为(x - x_i)，每个节点是两个孩子的乘积
+type subproductTree [][]Polynomial
+
+func (f *Field) newSubproductTree(xs []*big.Int) subproductTree {
+	level := make([]Polynomial, len(xs))
+	for i, x := range xs {
+		level[i] = Polynomial{f.neg(x), big.NewInt(1)}
+	}
+
+	tree := subproductTree{level}
+	for len(level) > 1 {
+		next := make([]Polynomial, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = f.Mul(level[2*i], level[2*i+1])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	return tree
+}
+
+func (t subproductTree) root() Polynomial {
+	return t[len(t)-1][0]
+}
+
+// evaluateOnTree 自顶向下逐层取余，叶子上的余数就是多项式在x_i处的值
+func (f *Field) evaluateOnTree(a Polynomial, tree subproductTree) []*big.Int {
+	remainders := []Polynomial{a}
+	for level := len(tree) - 1; level >= 0; level-- {
+		nodes := tree[level]
+		next := make([]Polynomial, len(nodes))
+		for i, node := range nodes {
+			// 子积树节点首项系数为1，取余不会出错
+			_, r, _ := f.DivMod(remainders[i/2], node)
+			next[i] = r
+		}
+		remainders = next
+	}
+
+	values := make([]*big.Int, len(remainders))
+	for i, r := range remainders {
+		if len(r) == 0 {
+			values[i] = big.NewInt(0)
+		} else {
+			values[i] = r[0]
+		}
+	}
+	return values
+}
+
+// BatchEvaluate evaluate the polynomial on many points using a subproduct tree
+// 利用子积树对多个点批量求值，复杂度为O(M(n)log n)
+func (f *Field) BatchEvaluate(a Polynomial, xs []*big.Int) []*big.Int {
+	if len(xs) < batchEvaluateThreshold {
+		values := make([]*big.Int, len(xs))
+		for i, x := range xs {
+			values[i] = f.Evaluate(a, x)
+		}
+		return values
+	}
+
+	tree := f.newSubproductTree(xs)
+	return f.evaluateOnTree(f.NewPolynomial(a), tree)
+}
+
+// Interpolate get the unique polynomial of degree < n through the n points
+// 快速插值：通过n个坐标点计算次数小于n的多项式，复杂度为O(M(n)log n)
+func (f *Field) Interpolate(xs, ys []*big.Int) (Polynomial, error) {
+	if len(xs) == 0 || len(xs) != len(ys) {
+		return nil, ErrInvalidPoints
+	}
+
+	tree := f.newSubproductTree(xs)
+
+	// 权重 w_i = y_i / M'(x_i)，M'(x_i)为0说明存在重复的横坐标
+	derivatives := f.evaluateOnTree(f.Derivative(tree.root()), tree)
+	inverses, err := f.batchInverse(derivatives)
+	if err != nil {
+		return nil, ErrDuplicatePoints
+	}
+
+	level := make([]Polynomial, len(xs))
+	for i := range xs {
+		level[i] = Polynomial{f.mul(ys[i], inverses[i])}.trim()
+	}
+
+	// 自底向上合并：node = left * M_right + right * M_left
+	for depth := 0; depth < len(tree)-1; depth++ {
+		nodes := tree[depth]
+		next := make([]Polynomial, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = f.Add(f.Mul(level[2*i], nodes[2*i+1]), f.Mul(level[2*i+1], nodes[2*i]))
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		level = next
+	}
+	return level[0], nil
+}
+
+// LagrangeBasis 获取拉格朗日基本多项式 L_i(x) = Π_{j!=i}(x - x_j)/(x_i - x_j)
+func (f *Field) LagrangeBasis(xs []*big.Int, i int) (Polynomial, error) {
+	if i < 0 || i >= len(xs) {
+		return nil, ErrInvalidPoints
+	}
+
+	others := make([]*big.Int, 0, len(xs)-1)
+	denominator := big.NewInt(1)
+	for j, x := range xs {
+		if j == i {
+			continue
+		}
+		others = append(others, x)
+		denominator = f.mul(denominator, f.sub(xs[i], x))
+	}
+	inverse, err := f.Inverse(denominator)
+	if err != nil {
+		return nil, ErrDuplicatePoints
+	}
+	if len(others) == 0 {
+		return Polynomial{inverse}, nil
+	}
+
+	tree := f.newSubproductTree(others)
+	return f.Scale(tree.root(), inverse), nil
+}
+
+// LagrangeCoefficients 计算所有的L_i(x)，常用于门限方案中在x=0处恢复秘密
+func (f *Field) LagrangeCoefficients(xs []*big.Int, x *big.Int) ([]*big.Int, error) {
+	n := len(xs)
+	if n == 0 {
+		return nil, ErrInvalidPoints
+	}
+
+	// 分子使用前缀积与后缀积，避免除法
+	prefix := make([]*big.Int, n+1)
+	suffix := make([]*big.Int, n+1)
+	prefix[0], suffix[n] = big.NewInt(1), big.NewInt(1)
+	for i := 0; i < n; i++ {
+		prefix[i+1] = f.mul(prefix[i], f.sub(x, xs[i]))
+	}
+	for i := n - 1; i >= 0; i-- {
+		suffix[i] = f.mul(suffix[i+1], f.sub(x, xs[i]))
+	}
+
+	denominators := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		denominators[i] = big.NewInt(1)
+		for j := 0; j < n; j++ {
+			if j != i {
+				denominators[i] = f.mul(denominators[i], f.sub(xs[i], xs[j]))
+			}
+		}
+	}
+	inverses, err := f.batchInverse(denominators)
+	if err != nil {
+		return nil, ErrDuplicatePoints
+	}
+
+	result := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		result[i] = f.mul(f.mul(prefix[i], suffix[i+1]), inverses[i])
+	}
+	return result, nil
+}
+
+// InterpolateAt 不恢复整个多项式，直接计算插值多项式在x处的值
+func (f *Field) InterpolateAt(xs, ys []*big.Int, x *big.Int) (*big.Int, error) {
+	if len(xs) != len(ys) {
+		return nil, ErrInvalidPoints
+	}
+	coefficients, err := f.LagrangeCoefficients(xs, x)
+	if err != nil {
+		return nil, err
+	}
+
+	result := big.NewInt(0)
+	for i, c := range coefficients {
+		result = f.add(result, f.mul(c, ys[i]))
+	}
+	return result, nil
+}
@@ -0,0 +1,341 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package polynomial
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// 短于该长度的多项式乘法直接使用朴素算法
+const schoolbookThreshold = 32
+
+// Polynomial coefficients in ascending order, a[i] is the coefficient of x^i
+// 多项式系数按升序排列，a[i]是x^i的系数
+type Polynomial []*big.Int
+
+// Degree 返回多项式的次数，零多项式返回-1
+func (a Polynomial) Degree() int {
+	for i := len(a) - 1; i >= 0; i-- {
+		if a[i].Sign() != 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// trim 去掉高次的零系数
+func (a Polynomial) trim() Polynomial {
+	return a[:a.Degree()+1]
+}
+
+// NewPolynomial 拷贝并约减系数，构造域上的多项式
+func (f *Field) NewPolynomial(coefficients []*big.Int) Polynomial {
+	result := make(Polynomial, len(coefficients))
+	for i, c := range coefficients {
+		result[i] = f.Reduce(c)
+	}
+	return result.trim()
+}
+
+// Random make a random polynomial of the degree, the constant term is [constant] if not nil
+// 生成指定次数的随机多项式，最高次系数非0；constant不为nil时作为常数项
+func (f *Field) Random(degree int, constant *big.Int) (Polynomial, error) {
+	if degree < 0 {
+		return nil, ErrInvalidDegree
+	}
+
+	result := make(Polynomial, degree+1)
+	for i := range result {
+		c, err := f.RandomElement()
+		if err != nil {
+			return nil, err
+		}
+		result[i] = c
+	}
+	if constant != nil {
+		result[0] = f.Reduce(constant)
+	}
+
+	// 最高次系数不能为0，否则次数会退化
+	for degree > 0 && result[degree].Sign() == 0 {
+		c, err := f.RandomElement()
+		if err != nil {
+			return nil, err
+		}
+		result[degree] = c
+	}
+	return result, nil
+}
+
+// Evaluate 使用Horner法则计算f(x) mod p
+func (f *Field) Evaluate(a Polynomial, x *big.Int) *big.Int {
+	result := big.NewInt(0)
+	for i := len(a) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, a[i])
+		result.Mod(result, f.modulus)
+	}
+	return result
+}
+
+// Add 多项式加法
+func (f *Field) Add(a, b Polynomial) Polynomial {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	result := make(Polynomial, len(a))
+	for i := range a {
+		if i < len(b) {
+			result[i] = f.add(a[i], b[i])
+		} else {
+			result[i] = f.Reduce(a[i])
+		}
+	}
+	return result.trim()
+}
+
+// Sub 多项式减法
+func (f *Field) Sub(a, b Polynomial) Polynomial {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	result := make(Polynomial, n)
+	zero := big.NewInt(0)
+	for i := range result {
+		ai, bi := zero, zero
+		if i < len(a) {
+			ai = a[i]
+		}
+		if i < len(b) {
+			bi = b[i]
+		}
+		result[i] = f.sub(ai, bi)
+	}
+	return result.trim()
+}
+
+// Scale 多项式与常数k相乘
+func (f *Field) Scale(a Polynomial, k *big.Int) Polynomial {
+	result := make(Polynomial, len(a))
+	for i := range a {
+		result[i] = f.mul(a[i], k)
+	}
+	return result.trim()
+}
+
+// Mul multiply two polynomials
+// 多项式乘法：短多项式使用朴素算法；域的2-adicity足够时使用NTT，否则使用Kronecker代换
+func (f *Field) Mul(a, b Polynomial) Polynomial {
+	a, b = a.trim(), b.trim()
+	if len(a) == 0 || len(b) == 0 {
+		return Polynomial{}
+	}
+
+	shorter := len(a)
+	if len(b) < shorter {
+		shorter = len(b)
+	}
+	if shorter < schoolbookThreshold {
+		return f.mulSchoolbook(a, b)
+	}
+
+	size := nextPowerOfTwo(len(a) + len(b) - 1)
+	if domain, err := f.NewDomain(size); err == nil {
+		return f.mulNTT(domain, a, b)
+	}
+	return f.mulKronecker(a, b)
+}
+
+// mulSchoolbook O(n*m)的朴素乘法
+func (f *Field) mulSchoolbook(a, b Polynomial) Polynomial {
+	result := make([]*big.Int, len(a)+len(b)-1)
+	for i := range result {
+		result[i] = big.NewInt(0)
+	}
+
+	t := new(big.Int)
+	for i := range a {
+		for j := range b {
+			t.Mul(a[i], b[j])
+			result[i+j].Add(result[i+j], t)
+		}
+	}
+	for i := range result {
+		result[i].Mod(result[i], f.modulus)
+	}
+	return Polynomial(result).trim()
+}
+
+// mulNTT 在求值域上逐点相乘
+func (f *Field) mulNTT(domain *Domain, a, b Polynomial) Polynomial {
+	va, _ := domain.FFT(a)
+	vb, _ := domain.FFT(b)
+	for i := range va {
+		va[i] = f.mul(va[i], vb[i])
+	}
+	result, _ := domain.InverseFFT(va)
+	return result
+}
+
+// mulKronecker packs the coefficients into one big integer, so the multiplication
+// is done by the sub-quadratic integer multiplication of math/big
+// Kronecker代换：把系数打包成一个大整数，借助math/big的Karatsuba乘法完成多项式乘法
+func (f *Field) mulKronecker(a, b Polynomial) Polynomial {
+	shorter := len(a)
+	if len(b) < shorter {
+		shorter = len(b)
+	}
+	// 每个乘积系数小于 shorter * p^2，按字节对齐槽位
+	slotBits := 2*f.modulus.BitLen() + bits.Len(uint(shorter)) + 1
+	slotBytes := (slotBits + 7) / 8
+
+	pack := func(p Polynomial) *big.Int {
+		buf := make([]byte, len(p)*slotBytes)
+		for i, c := range p {
+			// 低次系数放在低位，即缓冲区的末尾
+			end := len(buf) - i*slotBytes
+			f.Reduce(c).FillBytes(buf[end-slotBytes : end])
+		}
+		return new(big.Int).SetBytes(buf)
+	}
+
+	product := new(big.Int).Mul(pack(a), pack(b))
+
+	n := len(a) + len(b) - 1
+	buf := make([]byte, n*slotBytes)
+	product.FillBytes(buf)
+
+	result := make(Polynomial, n)
+	for i := range result {
+		end := len(buf) - i*slotBytes
+		result[i] = new(big.Int).SetBytes(buf[end-slotBytes : end])
+		result[i].Mod(result[i], f.modulus)
+	}
+	return result.trim()
+}
+
+// DivMod 多项式带余除法，返回商和余数
+func (f *Field) DivMod(a, b Polynomial) (Polynomial, Polynomial, error) {
+	a, b = f.NewPolynomial(a), f.NewPolynomial(b)
+	if len(b) == 0 {
+		return nil, nil, ErrDivisionByZero
+	}
+	if len(a) < len(b) {
+		return Polynomial{}, a, nil
+	}
+	if len(b) < schoolbookThreshold || len(a)-len(b) < schoolbookThreshold {
+		return f.divModSchoolbook(a, b)
+	}
+
+	// 快速除法：rev(q) = rev(a) * rev(b)^-1 mod x^(m+1)
+	m := len(a) - len(b)
+	inv, err := f.inverseSeries(reverse(b), m+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	qRev := f.Mul(reverse(a)[:m+1], inv)
+	if len(qRev) > m+1 {
+		qRev = qRev[:m+1]
+	}
+	q := make(Polynomial, m+1)
+	for i := range q {
+		q[i] = big.NewInt(0)
+	}
+	for i, c := range qRev {
+		q[m-i] = c
+	}
+	q = q.trim()
+
+	r := f.Sub(a, f.Mul(q, b))
+	return q, r, nil
+}
+
+// divModSchoolbook 长除法
+func (f *Field) divModSchoolbook(a, b Polynomial) (Polynomial, Polynomial, error) {
+	leadInv, err := f.Inverse(b[len(b)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := make(Polynomial, len(a))
+	for i := range a {
+		r[i] = new(big.Int).Set(a[i])
+	}
+	q := make(Polynomial, len(a)-len(b)+1)
+	t := new(big.Int)
+	for i := len(q) - 1; i >= 0; i-- {
+		c := f.mul(r[i+len(b)-1], leadInv)
+		q[i] = c
+		if c.Sign() == 0 {
+			continue
+		}
+		for j := range b {
+			t.Mul(c, b[j])
+			r[i+j].Sub(r[i+j], t)
+			r[i+j].Mod(r[i+j], f.modulus)
+		}
+	}
+	return q.trim(), r.trim(), nil
+}
+
+// inverseSeries Newton iteration, returns g such that a*g = 1 mod x^n
+// 牛顿迭代求幂级数的逆
+func (f *Field) inverseSeries(a Polynomial, n int) (Polynomial, error) {
+	c0, err := f.Inverse(a[0])
+	if err != nil {
+		return nil, err
+	}
+	g := Polynomial{c0}
+	for k := 1; k < n; {
+		k *= 2
+		if k > n {
+			k = n
+		}
+		// g = g * (2 - a*g) mod x^k
+		ag := f.Mul(truncate(a, k), g)
+		ag = truncate(ag, k)
+		two := f.Sub(Polynomial{big.NewInt(2)}, ag)
+		g = truncate(f.Mul(g, two), k)
+	}
+	return g, nil
+}
+
+// Derivative 形式导数
+func (f *Field) Derivative(a Polynomial) Polynomial {
+	if len(a) <= 1 {
+		return Polynomial{}
+	}
+	result := make(Polynomial, len(a)-1)
+	for i := 1; i < len(a); i++ {
+		result[i-1] = f.mul(a[i], big.NewInt(int64(i)))
+	}
+	return result.trim()
+}
+
+func reverse(a Polynomial) Polynomial {
+	result := make(Polynomial, len(a))
+	for i, c := range a {
+		result[len(a)-1-i] = c
+	}
+	return result
+}
+
+func truncate(a Polynomial, n int) Polynomial {
+	if len(a) > n {
+		return a[:n].trim()
+	}
+	return a
+}
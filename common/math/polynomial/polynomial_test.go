@@ -0,0 +1,148 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package polynomial
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 2-adicity很小的素数，乘法会走Kronecker代换
+const smallTwoAdicityPrime = "24815323469403931728221172233738523533528335161133543380459461440894543366372904768334987263999999999999999999663"
+
+func testFields(t *testing.T) []*Field {
+	prime, _ := new(big.Int).SetString(smallTwoAdicityPrime, 10)
+	field, err := NewField(prime)
+	require.Nil(t, err)
+	return []*Field{BLS12381Fr(), field}
+}
+
+func randomPolynomial(t *testing.T, f *Field, degree int) Polynomial {
+	p, err := f.Random(degree, nil)
+	require.Nil(t, err)
+	return p
+}
+
+func requireEqualPolynomial(t *testing.T, expected, actual Polynomial) {
+	require.Equal(t, len(expected.trim()), len(actual.trim()))
+	for i := range actual.trim() {
+		require.Equal(t, 0, expected[i].Cmp(actual[i]), "coefficient %d", i)
+	}
+}
+
+func TestNewField(t *testing.T) {
+	_, err := NewField(big.NewInt(15))
+	require.Equal(t, ErrInvalidModulus, err)
+
+	f := BLS12381Fr()
+	require.Equal(t, uint(32), f.TwoAdicity())
+
+	root, err := f.RootOfUnity(1 << 10)
+	require.Nil(t, err)
+	// 本原性：w^(n/2) = -1
+	half := new(big.Int).Exp(root, big.NewInt(1<<9), f.Modulus())
+	require.Equal(t, 0, half.Cmp(new(big.Int).Sub(f.Modulus(), big.NewInt(1))))
+}
+
+func TestFFT(t *testing.T) {
+	f := BLS12381Fr()
+	domain, err := f.NewDomain(60)
+	require.Nil(t, err)
+	require.Equal(t, 64, domain.Size())
+
+	a := randomPolynomial(t, f, 50)
+	values, err := domain.FFT(a)
+	require.Nil(t, err)
+	for _, i := range []int{0, 1, 17, 63} {
+		require.Equal(t, 0, values[i].Cmp(f.Evaluate(a, domain.Element(i))))
+	}
+
+	b, err := domain.InverseFFT(values)
+	require.Nil(t, err)
+	requireEqualPolynomial(t, a, b)
+}
+
+func TestMul(t *testing.T) {
+	for _, f := range testFields(t) {
+		for _, degrees := range [][2]int{{3, 5}, {100, 70}, {257, 300}} {
+			a := randomPolynomial(t, f, degrees[0])
+			b := randomPolynomial(t, f, degrees[1])
+
+			c := f.Mul(a, b)
+			require.Equal(t, degrees[0]+degrees[1], c.Degree())
+			requireEqualPolynomial(t, f.mulSchoolbook(a, b), c)
+		}
+	}
+}
+
+func TestDivMod(t *testing.T) {
+	for _, f := range testFields(t) {
+		a := randomPolynomial(t, f, 300)
+		b := randomPolynomial(t, f, 120)
+
+		q, r, err := f.DivMod(a, b)
+		require.Nil(t, err)
+		require.True(t, r.Degree() < b.Degree())
+		requireEqualPolynomial(t, a, f.Add(f.Mul(q, b), r))
+
+		_, _, err = f.DivMod(a, Polynomial{})
+		require.Equal(t, ErrDivisionByZero, err)
+	}
+}
+
+func TestBatchEvaluateAndInterpolate(t *testing.T) {
+	for _, f := range testFields(t) {
+		a := randomPolynomial(t, f, 99)
+		xs := make([]*big.Int, 100)
+		for i := range xs {
+			xs[i] = big.NewInt(int64(i + 1))
+		}
+
+		ys := f.BatchEvaluate(a, xs)
+		for i, x := range xs {
+			require.Equal(t, 0, ys[i].Cmp(f.Evaluate(a, x)))
+		}
+
+		b, err := f.Interpolate(xs, ys)
+		require.Nil(t, err)
+		requireEqualPolynomial(t, a, b)
+
+		constant, err := f.InterpolateAt(xs, ys, big.NewInt(0))
+		require.Nil(t, err)
+		require.Equal(t, 0, constant.Cmp(a[0]))
+
+		xs[1] = xs[0]
+		_, err = f.Interpolate(xs, ys)
+		require.Equal(t, ErrDuplicatePoints, err)
+	}
+}
+
+func TestLagrangeBasis(t *testing.T) {
+	f := BLS12381Fr()
+	xs := []*big.Int{big.NewInt(2), big.NewInt(5), big.NewInt(9)}
+
+	for i := range xs {
+		basis, err := f.LagrangeBasis(xs, i)
+		require.Nil(t, err)
+		for j, x := range xs {
+			expected := int64(0)
+			if i == j {
+				expected = 1
+			}
+			require.Equal(t, 0, f.Evaluate(basis, x).Cmp(big.NewInt(expected)))
+		}
+	}
+}
@@ -15,16 +15,13 @@ package ecdsa
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
 	"encoding/hex"
-	"errors"
 	"fmt"
-	"math/big"
 )
 
 /*
 	Generates the commonly used ecdsa account, with which to identify users and nodes.
+	The package level functions use P-256, see Scheme for secp256k1 and SM2.
 */
 
 const (
@@ -33,20 +30,12 @@ const (
 	SignatureLength  = 64
 )
 
-var (
-	defaultCurve elliptic.Curve
-)
-
 type PublicKey [PublicKeyLength]byte
 
 type PrivateKey [PrivateKeyLength]byte
 
 type Signature [SignatureLength]byte
 
-func init() {
-	defaultCurve = elliptic.P256()
-}
-
 func (pk PublicKey) String() string {
 	return hex.EncodeToString(pk[:])
 }
@@ -61,47 +50,17 @@ func (s Signature) String() string {
 
 // GenerateKeyPair generate a key pair
 func GenerateKeyPair() (privkey PrivateKey, pubkey PublicKey, err error) {
-	privateKey, err := ecdsa.GenerateKey(defaultCurve, rand.Reader)
-	if err != nil {
-		// will never happen
-		return
-	}
-
-	privkey = MarshalPrivateKey(privateKey)
-	pubkey = MarshalPublicKey(&privateKey.PublicKey)
-	return
+	return P256.GenerateKeyPair()
 }
 
 // ParsePrivateKey parse from local type to EC private key
 func ParsePrivateKey(privkey PrivateKey) ecdsa.PrivateKey {
-	D := new(big.Int).SetBytes(privkey[:])
-
-	x, y := defaultCurve.ScalarBaseMult(D.Bytes())
-
-	return ecdsa.PrivateKey{
-		PublicKey: ecdsa.PublicKey{
-			Curve: defaultCurve,
-			X:     x,
-			Y:     y,
-		},
-		D: D,
-	}
+	return P256.ParsePrivateKey(privkey)
 }
 
 // ParsePublicKey parse from local type to EC public key
 func ParsePublicKey(pubkey PublicKey) (ecdsa.PublicKey, error) {
-	x := new(big.Int).SetBytes(pubkey[:32])
-	y := new(big.Int).SetBytes(pubkey[32:])
-
-	if !defaultCurve.IsOnCurve(x, y) {
-		return ecdsa.PublicKey{}, errors.New("public key not on curve")
-	}
-
-	return ecdsa.PublicKey{
-		Curve: defaultCurve,
-		X:     x,
-		Y:     y,
-	}, nil
+	return P256.ParsePublicKey(pubkey)
 }
 
 // MarshalPrivateKey marshal private key to local types
@@ -124,41 +83,27 @@ func MarshalPublicKey(pubkey *ecdsa.PublicKey) PublicKey {
 
 // PublicKeyFromPrivateKey
 func PublicKeyFromPrivateKey(privkey PrivateKey) PublicKey {
-	ecPrivkey := ParsePrivateKey(privkey)
-	return MarshalPublicKey(&ecPrivkey.PublicKey)
+	return P256.PublicKeyFromPrivateKey(privkey)
 }
 
 // Sign sign a digest
 func Sign(privkey PrivateKey, digest []byte) (Signature, error) {
-	privateKey := ParsePrivateKey(privkey)
-
-	r, s, err := ecdsa.Sign(rand.Reader, &privateKey, digest)
-	if err != nil {
-		return Signature{}, fmt.Errorf("failed to sign digest %w", err)
-	}
-
-	rr, ss := padStart(r.Bytes(), 32), padStart(s.Bytes(), 32)
-	var sig Signature
-	copy(sig[:], rr)
-	copy(sig[32:], ss)
-	return sig, nil
+	return P256.Sign(privkey, digest)
 }
 
 // Verify verify a signature
 func Verify(pubkey PublicKey, digest []byte, signature Signature) error {
-	publicKey, err := ParsePublicKey(pubkey)
-	if err != nil {
-		return err
-	}
-
-	rr, ss := signature[:32], signature[32:]
-	r, s := new(big.Int).SetBytes(rr), new(big.Int).SetBytes(ss)
+	return P256.Verify(pubkey, digest, signature)
+}
 
-	if !ecdsa.Verify(&publicKey, digest, r, s) {
-		return errors.New("failed to verify")
-	}
+// SignRecoverable sign a digest and return the 65 bytes r || s || v
+func SignRecoverable(privkey PrivateKey, digest []byte) (RecoverableSignature, error) {
+	return P256.SignRecoverable(privkey, digest)
+}
 
-	return nil
+// RecoverPublicKey recover the public key from a digest and its recoverable signature
+func RecoverPublicKey(digest []byte, sig RecoverableSignature) (PublicKey, error) {
+	return P256.RecoverPublicKey(digest, sig)
 }
 
 // DecodePrivateKeyFromString decode ec private key from string
@@ -209,3 +154,18 @@ func DecodeSignatureFromString(s string) (Signature, error) {
 	copy(sig[:], bs)
 	return sig, nil
 }
+
+// DecodeRecoverableSignatureFromString decode recoverable signature from string
+func DecodeRecoverableSignatureFromString(s string) (RecoverableSignature, error) {
+	var sig RecoverableSignature
+
+	bs, err := hex.DecodeString(s)
+	if err != nil {
+		return RecoverableSignature{}, err
+	}
+	if len(bs) != RecoverableSignatureLength {
+		return RecoverableSignature{}, fmt.Errorf("invalid signature length")
+	}
+	copy(sig[:], bs)
+	return sig, nil
+}
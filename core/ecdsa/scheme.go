@@ -0,0 +1,469 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
+)
+
+/*
+	A Scheme binds the fixed-size PublicKey, PrivateKey and Signature types to
+	one curve and signature algorithm. P256 is the default used by the package
+	level functions, Secp256k1 interoperates with Ethereum-style chains and SM2
+	uses the SM2 signature algorithm, where the digest is e = SM3(Z || M).
+
+	P256 signs and verifies with crypto/ecdsa and randomized nonces, and
+	computes the recovery id of a signature by trying each candidate. Secp256k1
+	and SM2 signatures use RFC 6979 deterministic nonces. A RecoverableSignature is
+	r || s || v, where v in [0, 3] is the recovery id: bit 0 is the parity of
+	the y coordinate of the nonce point R and bit 1 is set when the x coordinate
+	of R is not smaller than the group order. Secp256k1 signatures are normalized
+	to s <= N/2 as required by Ethereum.
+*/
+
+const RecoverableSignatureLength = SignatureLength + 1
+
+type RecoverableSignature [RecoverableSignatureLength]byte
+
+func (s RecoverableSignature) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// Signature returns the 64 bytes r || s without the recovery id
+func (s RecoverableSignature) Signature() Signature {
+	var sig Signature
+	copy(sig[:], s[:SignatureLength])
+	return sig
+}
+
+// RecoveryID returns v
+func (s RecoverableSignature) RecoveryID() byte {
+	return s[SignatureLength]
+}
+
+type algorithm int
+
+const (
+	algorithmECDSA algorithm = iota
+	algorithmSM2
+)
+
+type Scheme struct {
+	curve     elliptic.Curve
+	algorithm algorithm
+	// coefficient a of y^2 = x^3 + ax + b
+	a    *big.Int
+	lowS bool
+	// sign and verify with crypto/ecdsa
+	stdlib bool
+}
+
+var (
+	// P256 ECDSA on NIST P-256
+	P256 = newScheme(elliptic.P256(), algorithmECDSA, false)
+	// Secp256k1 ECDSA on secp256k1 with low-s signatures
+	Secp256k1 = newScheme(S256(), algorithmECDSA, true)
+	// SM2 the SM2 signature algorithm on the SM2 curve
+	SM2 = newScheme(sm2.P256Sm2(), algorithmSM2, false)
+)
+
+var (
+	ErrInvalidPrivateKey   = errors.New("invalid private key")
+	ErrInvalidSignature    = errors.New("invalid signature")
+	ErrInvalidRecoveryID   = errors.New("invalid recovery id")
+	ErrRecoverPublicKey    = errors.New("failed to recover public key")
+	ErrPublicKeyNotOnCurve = errors.New("public key not on curve")
+)
+
+func newScheme(curve elliptic.Curve, alg algorithm, lowS bool) *Scheme {
+	a := big.NewInt(0)
+	if curve.Params().Name != "secp256k1" {
+		a.Sub(curve.Params().P, big.NewInt(3))
+	}
+	stdlib := alg == algorithmECDSA && curve == elliptic.P256()
+	return &Scheme{curve: curve, algorithm: alg, a: a, lowS: lowS, stdlib: stdlib}
+}
+
+// Curve returns the curve of the scheme
+func (sc *Scheme) Curve() elliptic.Curve {
+	return sc.curve
+}
+
+// GenerateKeyPair generate a key pair
+func (sc *Scheme) GenerateKeyPair() (privkey PrivateKey, pubkey PublicKey, err error) {
+	n := sc.curve.Params().N
+	d, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(1)))
+	if err != nil {
+		return
+	}
+	d.Add(d, big.NewInt(1))
+
+	copy(privkey[:], padStart(d.Bytes(), PrivateKeyLength))
+	pubkey = sc.PublicKeyFromPrivateKey(privkey)
+	return
+}
+
+// ParsePrivateKey parse from local type to EC private key
+func (sc *Scheme) ParsePrivateKey(privkey PrivateKey) ecdsa.PrivateKey {
+	D := new(big.Int).SetBytes(privkey[:])
+
+	x, y := sc.curve.ScalarBaseMult(D.Bytes())
+
+	return ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: sc.curve,
+			X:     x,
+			Y:     y,
+		},
+		D: D,
+	}
+}
+
+// ParsePublicKey parse from local type to EC public key
+func (sc *Scheme) ParsePublicKey(pubkey PublicKey) (ecdsa.PublicKey, error) {
+	x := new(big.Int).SetBytes(pubkey[:32])
+	y := new(big.Int).SetBytes(pubkey[32:])
+
+	if !sc.curve.IsOnCurve(x, y) {
+		return ecdsa.PublicKey{}, ErrPublicKeyNotOnCurve
+	}
+
+	return ecdsa.PublicKey{
+		Curve: sc.curve,
+		X:     x,
+		Y:     y,
+	}, nil
+}
+
+// PublicKeyFromPrivateKey
+func (sc *Scheme) PublicKeyFromPrivateKey(privkey PrivateKey) PublicKey {
+	ecPrivkey := sc.ParsePrivateKey(privkey)
+	return MarshalPublicKey(&ecPrivkey.PublicKey)
+}
+
+// Sign sign a digest
+func (sc *Scheme) Sign(privkey PrivateKey, digest []byte) (Signature, error) {
+	if sc.stdlib {
+		privateKey := sc.ParsePrivateKey(privkey)
+
+		r, s, err := ecdsa.Sign(rand.Reader, &privateKey, digest)
+		if err != nil {
+			return Signature{}, fmt.Errorf("failed to sign digest %w", err)
+		}
+
+		var sig Signature
+		copy(sig[:32], padStart(r.Bytes(), 32))
+		copy(sig[32:], padStart(s.Bytes(), 32))
+		return sig, nil
+	}
+
+	sig, err := sc.SignRecoverable(privkey, digest)
+	if err != nil {
+		return Signature{}, err
+	}
+	return sig.Signature(), nil
+}
+
+// Verify verify a signature
+func (sc *Scheme) Verify(pubkey PublicKey, digest []byte, signature Signature) error {
+	publicKey, err := sc.ParsePublicKey(pubkey)
+	if err != nil {
+		return err
+	}
+
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if sc.stdlib {
+		if !ecdsa.Verify(&publicKey, digest, r, s) {
+			return errors.New("failed to verify")
+		}
+		return nil
+	}
+	if !sc.verify(&publicKey, digest, r, s) {
+		return errors.New("failed to verify")
+	}
+
+	return nil
+}
+
+// SignRecoverable sign a digest and return r || s || v
+func (sc *Scheme) SignRecoverable(privkey PrivateKey, digest []byte) (RecoverableSignature, error) {
+	if sc.stdlib {
+		sig, err := sc.Sign(privkey, digest)
+		if err != nil {
+			return RecoverableSignature{}, err
+		}
+		return sc.withRecoveryID(sc.PublicKeyFromPrivateKey(privkey), digest, sig)
+	}
+
+	params := sc.curve.Params()
+	n := params.N
+	d := new(big.Int).SetBytes(privkey[:])
+	if d.Sign() == 0 || d.Cmp(n) >= 0 {
+		return RecoverableSignature{}, ErrInvalidPrivateKey
+	}
+	e := sc.hashToInt(digest)
+
+	g, err := nonce.NewGenerator(sc.curve, d, digest, nonce.ModeDeterministic)
+	if err != nil {
+		return RecoverableSignature{}, fmt.Errorf("failed to sign digest %w", err)
+	}
+	for {
+		k, err := g.Next()
+		if err != nil {
+			return RecoverableSignature{}, fmt.Errorf("failed to sign digest %w", err)
+		}
+		x1, y1 := sc.curve.ScalarBaseMult(k.Bytes())
+
+		var r, s *big.Int
+		switch sc.algorithm {
+		case algorithmSM2:
+			// r = (e + x1) mod n, s = (1 + d)^-1 * (k - r*d) mod n
+			r = new(big.Int).Add(e, x1)
+			r.Mod(r, n)
+			if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(n) == 0 {
+				continue
+			}
+			s = new(big.Int).Mul(r, d)
+			s.Sub(k, s)
+			dInv := new(big.Int).Add(d, big.NewInt(1))
+			dInv.ModInverse(dInv, n)
+			s.Mul(s, dInv)
+			s.Mod(s, n)
+		default:
+			// r = x1 mod n, s = k^-1 * (e + r*d) mod n
+			r = new(big.Int).Mod(x1, n)
+			if r.Sign() == 0 {
+				continue
+			}
+			s = new(big.Int).Mul(r, d)
+			s.Add(s, e)
+			s.Mul(s, new(big.Int).ModInverse(k, n))
+			s.Mod(s, n)
+		}
+		if s.Sign() == 0 {
+			continue
+		}
+
+		v := byte(y1.Bit(0))
+		if x1.Cmp(n) >= 0 {
+			v |= 2
+		}
+		// (r, n - s) is the signature of -R
+		if sc.lowS && s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+			s.Sub(n, s)
+			v ^= 1
+		}
+
+		var sig RecoverableSignature
+		copy(sig[:32], padStart(r.Bytes(), 32))
+		copy(sig[32:64], padStart(s.Bytes(), 32))
+		sig[64] = v
+		return sig, nil
+	}
+}
+
+// RecoverPublicKey recover the public key from a digest and its recoverable signature
+func (sc *Scheme) RecoverPublicKey(digest []byte, sig RecoverableSignature) (PublicKey, error) {
+	params := sc.curve.Params()
+	n := params.N
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	v := sig[64]
+	if r.Sign() == 0 || r.Cmp(n) >= 0 || s.Sign() == 0 || s.Cmp(n) >= 0 {
+		return PublicKey{}, ErrInvalidSignature
+	}
+	if v > 3 {
+		return PublicKey{}, ErrInvalidRecoveryID
+	}
+	e := sc.hashToInt(digest)
+
+	// x coordinate of R
+	x := new(big.Int)
+	switch sc.algorithm {
+	case algorithmSM2:
+		x.Sub(r, e)
+		x.Mod(x, n)
+	default:
+		x.Set(r)
+	}
+	if v&2 != 0 {
+		x.Add(x, n)
+	}
+	y := sc.decompress(x, uint(v&1))
+	if y == nil {
+		return PublicKey{}, ErrRecoverPublicKey
+	}
+
+	// ECDSA: Q = r^-1 * (s*R - e*G)
+	// SM2:   Q = (r + s)^-1 * (R - s*G)
+	var u1, u2 *big.Int
+	switch sc.algorithm {
+	case algorithmSM2:
+		t := new(big.Int).Add(r, s)
+		t.Mod(t, n)
+		if t.Sign() == 0 {
+			return PublicKey{}, ErrInvalidSignature
+		}
+		tInv := new(big.Int).ModInverse(t, n)
+		u1 = new(big.Int).Mul(s, tInv)
+		u2 = tInv
+	default:
+		rInv := new(big.Int).ModInverse(r, n)
+		u1 = new(big.Int).Mul(e, rInv)
+		u2 = new(big.Int).Mul(s, rInv)
+	}
+	u1.Neg(u1)
+	u1.Mod(u1, n)
+	u2.Mod(u2, n)
+
+	x1, y1 := sc.curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := sc.curve.ScalarMult(x, y, u2.Bytes())
+	qx, qy := addPoints(sc.curve, x1, y1, x2, y2)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return PublicKey{}, ErrRecoverPublicKey
+	}
+
+	return MarshalPublicKey(&ecdsa.PublicKey{Curve: sc.curve, X: qx, Y: qy}), nil
+}
+
+// withRecoveryID finds the recovery id of a signature made without access
+// to the nonce point, by recovering the public key for each candidate
+func (sc *Scheme) withRecoveryID(pubkey PublicKey, digest []byte, sig Signature) (RecoverableSignature, error) {
+	var rsig RecoverableSignature
+	copy(rsig[:], sig[:])
+	for v := byte(0); v < 4; v++ {
+		rsig[SignatureLength] = v
+		recovered, err := sc.RecoverPublicKey(digest, rsig)
+		if err == nil && recovered == pubkey {
+			return rsig, nil
+		}
+	}
+	return RecoverableSignature{}, ErrRecoverPublicKey
+}
+
+func (sc *Scheme) verify(pub *ecdsa.PublicKey, digest []byte, r, s *big.Int) bool {
+	n := sc.curve.Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	e := sc.hashToInt(digest)
+
+	// ECDSA: R = (e*w)*G + (r*w)*Q, w = s^-1, check R.x = r mod n
+	// SM2:   R = s*G + t*Q, t = r + s, check (e + R.x) mod n = r
+	var u1, u2 *big.Int
+	switch sc.algorithm {
+	case algorithmSM2:
+		u1 = s
+		u2 = new(big.Int).Add(r, s)
+		u2.Mod(u2, n)
+		if u2.Sign() == 0 {
+			return false
+		}
+	default:
+		w := new(big.Int).ModInverse(s, n)
+		u1 = new(big.Int).Mul(e, w)
+		u1.Mod(u1, n)
+		u2 = new(big.Int).Mul(r, w)
+		u2.Mod(u2, n)
+	}
+
+	x1, y1 := sc.curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := sc.curve.ScalarMult(pub.X, pub.Y, u2.Bytes())
+	x, y := addPoints(sc.curve, x1, y1, x2, y2)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return false
+	}
+
+	if sc.algorithm == algorithmSM2 {
+		x.Add(x, e)
+	}
+	x.Mod(x, n)
+	return x.Cmp(r) == 0
+}
+
+// hashToInt converts a digest to an integer. ECDSA uses the leftmost bits
+// of the digest as in FIPS 186-4, SM2 uses the whole digest
+func (sc *Scheme) hashToInt(digest []byte) *big.Int {
+	if sc.algorithm == algorithmSM2 {
+		return new(big.Int).SetBytes(digest)
+	}
+
+	orderBits := sc.curve.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+	ret := new(big.Int).SetBytes(digest)
+	excess := len(digest)*8 - orderBits
+	if excess > 0 {
+		ret.Rsh(ret, uint(excess))
+	}
+	return ret
+}
+
+// decompress returns y with the given parity such that (x, y) is on the curve
+func (sc *Scheme) decompress(x *big.Int, odd uint) *big.Int {
+	params := sc.curve.Params()
+	p := params.P
+	if x.Cmp(p) >= 0 {
+		return nil
+	}
+
+	// y^2 = x^3 + ax + b
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, new(big.Int).Mul(sc.a, x))
+	y2.Add(y2, params.B)
+	y2.Mod(y2, p)
+
+	y := new(big.Int).ModSqrt(y2, p)
+	if y == nil {
+		return nil
+	}
+	if y.Bit(0) != odd {
+		y.Sub(p, y)
+	}
+	if !sc.curve.IsOnCurve(x, y) {
+		return nil
+	}
+	return y
+}
+
+// addPoints adds two points where either may be the point at infinity (0, 0)
+func addPoints(curve elliptic.Curve, x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return x2, y2
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return x1, y1
+	}
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) == 0 {
+			return curve.Double(x1, y1)
+		}
+		// P + (-P)
+		return new(big.Int), new(big.Int)
+	}
+	return curve.Add(x1, y1, x2, y2)
+}
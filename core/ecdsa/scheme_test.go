@@ -0,0 +1,138 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func TestSchemes(t *testing.T) {
+	schemes := map[string]*Scheme{
+		"P256":      P256,
+		"Secp256k1": Secp256k1,
+		"SM2":       SM2,
+	}
+	for name, sc := range schemes {
+		t.Run(name, func(t *testing.T) {
+			privkey, pubkey, err := sc.GenerateKeyPair()
+			require.NoError(t, err)
+			require.Equal(t, pubkey, sc.PublicKeyFromPrivateKey(privkey))
+
+			digest := sha256.Sum256([]byte("test"))
+			sig, err := sc.Sign(privkey, digest[:])
+			require.NoError(t, err)
+			require.NoError(t, sc.Verify(pubkey, digest[:], sig))
+
+			other := sha256.Sum256([]byte("other"))
+			require.Error(t, sc.Verify(pubkey, other[:], sig))
+
+			rsig, err := sc.SignRecoverable(privkey, digest[:])
+			require.NoError(t, err)
+			require.NoError(t, sc.Verify(pubkey, digest[:], rsig.Signature()))
+			// P256 nonces are random, the other schemes use RFC 6979
+			if sc != P256 {
+				require.Equal(t, sig, rsig.Signature())
+			}
+			recovered, err := sc.RecoverPublicKey(digest[:], rsig)
+			require.NoError(t, err)
+			require.Equal(t, pubkey, recovered)
+
+			recovered, err = sc.RecoverPublicKey(other[:], rsig)
+			if err == nil {
+				require.NotEqual(t, pubkey, recovered)
+			}
+
+			rsig[64] = 4
+			_, err = sc.RecoverPublicKey(digest[:], rsig)
+			require.Equal(t, ErrInvalidRecoveryID, err)
+		})
+	}
+}
+
+func TestP256Interop(t *testing.T) {
+	privkey, pubkey, err := P256.GenerateKeyPair()
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("test"))
+
+	// interoperates with crypto/ecdsa, and the nonce differs on every call
+	sig, err := P256.Sign(privkey, digest[:])
+	require.NoError(t, err)
+	again, err := P256.Sign(privkey, digest[:])
+	require.NoError(t, err)
+	require.NotEqual(t, sig, again)
+
+	ecPrivkey := P256.ParsePrivateKey(privkey)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	require.True(t, ecdsa.Verify(&ecPrivkey.PublicKey, digest[:], r, s))
+
+	r, s, err = ecdsa.Sign(rand.Reader, &ecPrivkey, digest[:])
+	require.NoError(t, err)
+	var stdSig Signature
+	copy(stdSig[:32], padStart(r.Bytes(), 32))
+	copy(stdSig[32:], padStart(s.Bytes(), 32))
+	require.NoError(t, P256.Verify(pubkey, digest[:], stdSig))
+}
+
+func TestSecp256k1RFC6979(t *testing.T) {
+	require.True(t, S256().IsOnCurve(S256().Params().Gx, S256().Params().Gy))
+
+	var privkey PrivateKey
+	privkey[31] = 1
+	digest := sha256.Sum256([]byte("Satoshi Nakamoto"))
+	sig, err := Secp256k1.SignRecoverable(privkey, digest[:])
+	require.NoError(t, err)
+	require.Equal(t, "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8", hex.EncodeToString(sig[:32]))
+	require.Equal(t, "2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5", hex.EncodeToString(sig[32:64]))
+
+	pubkey, err := Secp256k1.RecoverPublicKey(digest[:], sig)
+	require.NoError(t, err)
+	require.Equal(t, Secp256k1.PublicKeyFromPrivateKey(privkey), pubkey)
+}
+
+func TestSecp256k1LowS(t *testing.T) {
+	halfN := new(big.Int).Rsh(S256().Params().N, 1)
+	privkey, _, err := Secp256k1.GenerateKeyPair()
+	require.NoError(t, err)
+	for i := 0; i < 16; i++ {
+		digest := sha256.Sum256([]byte{byte(i)})
+		sig, err := Secp256k1.SignRecoverable(privkey, digest[:])
+		require.NoError(t, err)
+		require.True(t, new(big.Int).SetBytes(sig[32:64]).Cmp(halfN) <= 0)
+	}
+}
+
+func TestSM2Interop(t *testing.T) {
+	privkey, pubkey, err := SM2.GenerateKeyPair()
+	require.NoError(t, err)
+	digest := sha256.Sum256([]byte("test"))
+	sig, err := SM2.Sign(privkey, digest[:])
+	require.NoError(t, err)
+
+	ecPubkey, err := SM2.ParsePublicKey(pubkey)
+	require.NoError(t, err)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	pub := &sm2.PublicKey{Curve: ecPubkey.Curve, X: ecPubkey.X, Y: ecPubkey.Y}
+	require.True(t, sm2.Verify(pub, digest[:], r, s))
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/secp256k1"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fp"
+	"github.com/consensys/gnark-crypto/ecc/secp256k1/fr"
+)
+
+/*
+	secp256k1 (y^2 = x^3 + 7) as an elliptic.Curve.
+	The generic elliptic.CurveParams arithmetic assumes a = -3, so the group
+	operations are delegated to gnark-crypto. The point at infinity is (0, 0).
+*/
+
+type secp256k1Curve struct {
+	params *elliptic.CurveParams
+}
+
+var secp256k1Instance = newSecp256k1Curve()
+
+func newSecp256k1Curve() *secp256k1Curve {
+	_, g := secp256k1.Generators()
+	params := &elliptic.CurveParams{
+		P:       fp.Modulus(),
+		N:       fr.Modulus(),
+		B:       big.NewInt(7),
+		Gx:      g.X.BigInt(new(big.Int)),
+		Gy:      g.Y.BigInt(new(big.Int)),
+		BitSize: 256,
+		Name:    "secp256k1",
+	}
+	return &secp256k1Curve{params: params}
+}
+
+// S256 returns the secp256k1 curve used by Bitcoin and Ethereum
+func S256() elliptic.Curve {
+	return secp256k1Instance
+}
+
+func (c *secp256k1Curve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+func (c *secp256k1Curve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+	// y^2 = x^3 + 7
+	lhs := new(big.Int).Mul(y, y)
+	lhs.Mod(lhs, p)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.params.B)
+	rhs.Mod(rhs, p)
+	return lhs.Cmp(rhs) == 0
+}
+
+func (c *secp256k1Curve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p1, p2 := toAffine(x1, y1), toAffine(x2, y2)
+	var sum secp256k1.G1Affine
+	sum.Add(&p1, &p2)
+	return fromAffine(&sum)
+}
+
+func (c *secp256k1Curve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	return c.Add(x1, y1, x1, y1)
+}
+
+func (c *secp256k1Curve) ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	p := toAffine(x1, y1)
+	var res secp256k1.G1Affine
+	res.ScalarMultiplication(&p, new(big.Int).SetBytes(k))
+	return fromAffine(&res)
+}
+
+func (c *secp256k1Curve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	var res secp256k1.G1Affine
+	res.ScalarMultiplicationBase(new(big.Int).SetBytes(k))
+	return fromAffine(&res)
+}
+
+func toAffine(x, y *big.Int) secp256k1.G1Affine {
+	var p secp256k1.G1Affine
+	p.X.SetBigInt(x)
+	p.Y.SetBigInt(y)
+	return p
+}
+
+func fromAffine(p *secp256k1.G1Affine) (x, y *big.Int) {
+	return p.X.BigInt(new(big.Int)), p.Y.BigInt(new(big.Int))
+}
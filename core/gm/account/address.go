@@ -2,6 +2,7 @@ package account
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"encoding/json"
 	"errors"
@@ -19,6 +20,8 @@ var (
 	CurveParamNilError             = errors.New("curve input param is nil")
 	NotExactTheSameCurveInputError = errors.New("the curve is not same as curve of members")
 	KeyParamNotMatchError          = errors.New("key param not match")
+	InvalidEd25519PublicKeyError   = errors.New("The Ed25519 public key is invalid")
+	InvalidEd25519PrivateKeyError  = errors.New("The Ed25519 private key is invalid")
 )
 
 // generate address for multi-signature / ring-signature algorithm
@@ -43,7 +46,7 @@ func GetAddressFromPublicKeys(keys []*ecdsa.PublicKey) (string, error) {
 
 	address, err := getAddressFromKeyData(keys[0], data)
 
-	return address, nil
+	return address, err
 }
 
 // 验证钱包地址是否和指定的公钥数组match
@@ -87,13 +90,9 @@ func checkCurveForPublicKeys(keys []*ecdsa.PublicKey) bool {
 }
 
 func getAddressFromKeyData(pub *ecdsa.PublicKey, data []byte) (string, error) {
-	// 替换国密
-	outputSM3 := gmHash.HashUsingSM3(data)
-	OutputRipemd160 := gmHash.HashUsingRipemd160(outputSM3)
-
 	// 暂时只支持一个字节长度，也就是uint8的密码学标志位
 	// 判断是否是nist标准的私钥
-	nVersion := config.Nist
+	var nVersion uint8 = config.Nist
 
 	switch pub.Params().Name {
 	case config.CurveNist: // NIST
@@ -103,6 +102,25 @@ func getAddressFromKeyData(pub *ecdsa.PublicKey, data []byte) (string, error) {
 		return "", fmt.Errorf("This cryptography[%v] has not been supported yet.", pub.Params().Name)
 	}
 
+	// 替换国密
+	return encodeAddress(nVersion, gmHash.HashUsingSM3(data)), nil
+}
+
+// xuperchain格式的NIST地址使用SHA256 + Ripemd160，校验码为两次SHA256，密码学标记位与NIST地址相同
+func getXchainAddressFromKeyData(data []byte) string {
+	return encodeAddressWithCheckCode(config.Nist, gmHash.HashUsingSha256(data), gmHash.DoubleSha256)
+}
+
+// encodeAddress 地址为 base58(密码学标记位 || Ripemd160(outputHash) || 4字节校验码)
+func encodeAddress(nVersion uint8, outputHash []byte) string {
+	return encodeAddressWithCheckCode(nVersion, outputHash, func(data []byte) []byte {
+		return addressCheckCode(nVersion, data)
+	})
+}
+
+func encodeAddressWithCheckCode(nVersion uint8, outputHash []byte, checkCodeFunc func([]byte) []byte) string {
+	OutputRipemd160 := gmHash.HashUsingRipemd160(outputHash)
+
 	bufVersion := []byte{byte(nVersion)}
 
 	strSlice := make([]byte, len(bufVersion)+len(OutputRipemd160))
@@ -110,8 +128,7 @@ func getAddressFromKeyData(pub *ecdsa.PublicKey, data []byte) (string, error) {
 	copy(strSlice[len(bufVersion):], OutputRipemd160)
 
 	// 计算校验码，防止地址抄写/拼写错误
-	// 替换国密
-	checkCode := gmHash.HashUsingSM3(strSlice)
+	checkCode := checkCodeFunc(strSlice)
 	simpleCheckCode := checkCode[:4]
 
 	slice := make([]byte, len(strSlice)+len(simpleCheckCode))
@@ -120,9 +137,7 @@ func getAddressFromKeyData(pub *ecdsa.PublicKey, data []byte) (string, error) {
 
 	// 使用base58编码，手写不容易出错。
 	// 相比Base64，Base58不使用数字"0"，字母大写"O"，字母大写"I"，和字母小写"l"，以及"+"和"/"符号。
-	strEnc := base58.Encode(slice)
-
-	return strEnc, nil
+	return base58.Encode(slice)
 }
 
 // 返回33位长度的地址
@@ -135,7 +150,16 @@ func GetAddressFromPublicKey(pub *ecdsa.PublicKey) (string, error) {
 	return address, err
 }
 
-// 验证钱包地址是否和指定的公钥match
+// GetXchainAddressFromPublicKey 为NIST公钥生成与xuperchain一致的地址，只支持NIST曲线
+func GetXchainAddressFromPublicKey(pub *ecdsa.PublicKey) (string, error) {
+	if pub.Params().Name != config.CurveNist {
+		return "", fmt.Errorf("This cryptography[%v] has not been supported yet.", pub.Params().Name)
+	}
+
+	return getXchainAddressFromKeyData(elliptic.Marshal(pub.Curve, pub.X, pub.Y)), nil
+}
+
+// 验证钱包地址是否和指定的公钥match，NIST公钥同时接受xuperchain格式的地址
 // 如果成功，返回true和对应的密码学标记位；如果失败，返回false和默认的密码学标记位0
 func VerifyAddressUsingPublicKey(address string, pub *ecdsa.PublicKey) (bool, uint8) {
 	// base58反解回byte[]数组
@@ -158,6 +182,35 @@ func VerifyAddressUsingPublicKey(address string, pub *ecdsa.PublicKey) (bool, ui
 		return true, nVersion
 	}
 
+	// 同时接受xuperchain格式的NIST地址
+	if nVersion == config.Nist && getXchainAddressFromKeyData(elliptic.Marshal(pub.Curve, pub.X, pub.Y)) == address {
+		return true, nVersion
+	}
+
+	return false, 0
+}
+
+// GetAddressFromEd25519PublicKey 为Ed25519公钥生成地址，使用SHA256 + Ripemd160，密码学标记位为config.Ed25519
+func GetAddressFromEd25519PublicKey(pub ed25519.PublicKey) (string, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return "", InvalidEd25519PublicKeyError
+	}
+
+	return encodeAddress(config.Ed25519, gmHash.HashUsingSha256(pub)), nil
+}
+
+// 验证钱包地址是否和指定的Ed25519公钥match
+// 如果成功，返回true和对应的密码学标记位；如果失败，返回false和默认的密码学标记位0
+func VerifyAddressUsingEd25519PublicKey(address string, pub ed25519.PublicKey) (bool, uint8) {
+	realAddress, err := GetAddressFromEd25519PublicKey(pub)
+	if err != nil {
+		return false, 0
+	}
+
+	if realAddress == address {
+		return true, config.Ed25519
+	}
+
 	return false, 0
 }
 
@@ -167,24 +220,37 @@ func CheckAddressFormat(address string) (bool, uint8) {
 	// base58反解回byte[]数组
 	slice := base58.Decode(address)
 
-	// 检查是否是合法的base58编码
-	if len(slice) < 1 {
+	// 检查是否是合法的base58编码，至少包含标记位与4字节的校验码
+	if len(slice) < 5 {
 		return false, 0
 	}
 	// 拿到简单校验码
 	simpleCheckCode := slice[len(slice)-4:]
 
 	checkContent := slice[:len(slice)-4]
-	// 替换国密
-	checkCode := gmHash.HashUsingSM3(checkContent)
-	realSimpleCheckCode := checkCode[:4]
 
 	byteVersion := slice[:1]
 	nVersion := uint8(byteVersion[0])
 
+	checkCode := addressCheckCode(nVersion, checkContent)
+	realSimpleCheckCode := checkCode[:4]
+
 	if utils.BytesCompare(realSimpleCheckCode, simpleCheckCode) {
 		return true, nVersion
 	}
 
+	// xuperchain格式的NIST地址使用两次SHA256作为校验码
+	if nVersion == config.Nist && utils.BytesCompare(gmHash.DoubleSha256(checkContent)[:4], simpleCheckCode) {
+		return true, nVersion
+	}
+
 	return false, 0
 }
+
+// addressCheckCode Ed25519地址的校验码使用两次SHA256，其余地址使用SM3
+func addressCheckCode(nVersion uint8, data []byte) []byte {
+	if nVersion == config.Ed25519 {
+		return gmHash.DoubleSha256(data)
+	}
+	return gmHash.HashUsingSM3(data)
+}
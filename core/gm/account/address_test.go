@@ -0,0 +1,124 @@
+package account
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	xuperAccount "github.com/xuperchain/crypto/core/account"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func TestAddressNist(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	address, err := GetAddressFromPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	ok, nVersion := CheckAddressFormat(address)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+	ok, nVersion = VerifyAddressUsingPublicKey(address, &key.PublicKey)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+
+	// xuperchain格式的地址与xuperchain生成的地址一致
+	xchainAddress, err := GetXchainAddressFromPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	expected, err := xuperAccount.GetAddressFromPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, expected, xchainAddress)
+	require.NotEqual(t, address, xchainAddress)
+
+	ok, nVersion = CheckAddressFormat(xchainAddress)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+	ok, nVersion = VerifyAddressUsingPublicKey(xchainAddress, &key.PublicKey)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+
+	gmKey, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	_, err = GetXchainAddressFromPublicKey(&gmKey.PublicKey)
+	require.Error(t, err)
+}
+
+// NIST地址的生成方式与之前的版本一致：SM3 + Ripemd160，校验码为SM3
+func TestAddressNistFixed(t *testing.T) {
+	curve := elliptic.P256()
+	var keys []*ecdsa.PublicKey
+	for _, k := range []int64{7, 11} {
+		x, y := curve.ScalarBaseMult(big.NewInt(k).Bytes())
+		keys = append(keys, &ecdsa.PublicKey{Curve: curve, X: x, Y: y})
+	}
+
+	address, err := GetAddressFromPublicKey(keys[0])
+	require.NoError(t, err)
+	require.Equal(t, "dncTiB41LUp4f6psxYojzMAAA51TKTPPm", address)
+	multiAddress, err := GetAddressFromPublicKeys(keys)
+	require.NoError(t, err)
+	require.Equal(t, "kwvpJGzSY7ANukRz1Lv3pgBXrNYYB45hb", multiAddress)
+
+	ok, nVersion := CheckAddressFormat(multiAddress)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+	ok, _ = VerifyAddressUsingPublicKey(address, keys[1])
+	require.False(t, ok)
+	ok, nVersion = VerifyAddressUsingPublicKeys(multiAddress, keys)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Nist), nVersion)
+	ok, _ = VerifyAddressUsingPublicKeys(multiAddress, keys[:1])
+	require.False(t, ok)
+}
+
+func TestAddressGm(t *testing.T) {
+	key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+
+	address, err := GetAddressFromPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	ok, nVersion := CheckAddressFormat(address)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Gm), nVersion)
+	ok, _ = VerifyAddressUsingPublicKey(address, &key.PublicKey)
+	require.True(t, ok)
+
+	other, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	ok, _ = VerifyAddressUsingPublicKey(address, &other.PublicKey)
+	require.False(t, ok)
+
+	ok, _ = CheckAddressFormat(address[:len(address)-1])
+	require.False(t, ok)
+	ok, _ = CheckAddressFormat("")
+	require.False(t, ok)
+}
+
+func TestAddressEd25519(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	address, err := GetAddressFromEd25519PublicKey(publicKey)
+	require.NoError(t, err)
+	ok, nVersion := CheckAddressFormat(address)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Ed25519), nVersion)
+	ok, nVersion = VerifyAddressUsingEd25519PublicKey(address, publicKey)
+	require.True(t, ok)
+	require.Equal(t, uint8(config.Ed25519), nVersion)
+
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ok, _ = VerifyAddressUsingEd25519PublicKey(address, other)
+	require.False(t, ok)
+
+	_, err = GetAddressFromEd25519PublicKey(publicKey[:31])
+	require.Equal(t, InvalidEd25519PublicKeyError, err)
+}
@@ -2,6 +2,7 @@ package account
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	//	"crypto/elliptic"
 	"encoding/json"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/ed25519_sign"
 	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
 	walletRand "github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
 	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
@@ -114,11 +116,10 @@ func GenerateAccountByMnemonic(mnemonic string, language int) (*account.ECDSAAcc
 		return nil, err
 	}
 
-	if cryptography != config.Gm {
-		err = fmt.Errorf("Only cryptoGraphy Gm[%d] is supported in this version, this cryptoGraphy is [%v].", config.Gm, cryptography)
+	if cryptography != config.Gm && cryptography != config.Ed25519 {
+		err = fmt.Errorf("Only cryptoGraphy Gm[%d] and Ed25519[%d] are supported in this version, this cryptoGraphy is [%v].", config.Gm, config.Ed25519, cryptography)
 		return nil, err
 	}
-	curve := sm2.P256Sm2()
 
 	// 将助记词转为随机数种子，在此过程中，校验助记词是否合法
 	password := "jingbo is handsome!"
@@ -127,6 +128,11 @@ func GenerateAccountByMnemonic(mnemonic string, language int) (*account.ECDSAAcc
 		return nil, err
 	}
 
+	if cryptography == config.Ed25519 {
+		return generateEd25519AccountBySeed(mnemonic, seed)
+	}
+	curve := sm2.P256Sm2()
+
 	// 通过随机数种子来生成椭圆曲线加密的私钥
 	privateKey, err := sign.GenerateKeyBySeed(curve, seed)
 	if err != nil {
@@ -199,6 +205,8 @@ func CreateNewAccountWithMnemonic(language int, strength uint8, cryptography uin
 		//		log.Printf("Only cryptoGraphy [NIST] is supported in this version.")
 		//		return nil, ErrCryptographyNotSupported
 		cryptographyBit = []byte{config.Gm}
+	case config.Ed25519: // Ed25519
+		cryptographyBit = []byte{config.Ed25519}
 	default: // 不支持的密码学类型
 		return nil, ErrCryptographyNotSupported
 	}
@@ -246,6 +254,38 @@ func CreateNewAccountWithMnemonic(language int, strength uint8, cryptography uin
 	return ecdsaAccount, nil
 }
 
+// 通过随机数种子的前32字节来生成Ed25519账户
+func generateEd25519AccountBySeed(mnemonic string, seed []byte) (*account.ECDSAAccount, error) {
+	privateKey, err := ed25519_sign.GenerateKeyFromSeed(seed[:ed25519.SeedSize])
+	if err != nil {
+		return nil, err
+	}
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	jsonPrivateKey, err := GetEd25519PrivateKeyJsonFormat(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	jsonPublicKey, err := GetEd25519PublicKeyJsonFormat(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	address, err := GetAddressFromEd25519PublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	// 返回的字段：助记词、私钥的json、公钥的json、钱包地址、错误信息
+	account := &account.ECDSAAccount{
+		EntropyByte:    seed,
+		Mnemonic:       mnemonic,
+		JsonPrivateKey: jsonPrivateKey,
+		JsonPublicKey:  jsonPublicKey,
+		Address:        address,
+	}
+
+	return account, nil
+}
+
 func ExportNewAccountWithMnemonic(path string, language int, strength uint8, cryptography uint8) error {
 	// 先获得返回值
 	ecdsaAccount, err := CreateNewAccountWithMnemonic(language, strength, cryptography)
@@ -323,6 +363,45 @@ func ExportNewAccount(path string, privateKey *ecdsa.PrivateKey) error {
 
 	return err
 }
+func ExportNewEd25519Account(path string, privateKey ed25519.PrivateKey) error {
+	jsonPrivateKey, err := GetEd25519PrivateKeyJsonFormat(privateKey)
+	if err != nil {
+		return err
+	}
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	jsonPublicKey, err := GetEd25519PublicKeyJsonFormat(publicKey)
+	if err != nil {
+		return err
+	}
+	address, err := GetAddressFromEd25519PublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+	//如果path不是以/结尾的，自动拼上
+	if strings.LastIndex(path, "/") != len([]rune(path))-1 {
+		path = path + "/"
+	}
+	err = writeFileUsingFilename(path+"private.key", []byte(jsonPrivateKey))
+	if err != nil {
+		log.Printf("Export private key file failed, the err is %v", err)
+		return err
+	}
+
+	err = writeFileUsingFilename(path+"public.key", []byte(jsonPublicKey))
+	if err != nil {
+		log.Printf("Export public key file failed, the err is %v", err)
+		return err
+	}
+
+	err = writeFileUsingFilename(path+"address", []byte(address))
+	if err != nil {
+		log.Printf("Export address file failed, the err is %v", err)
+		return err
+	}
+
+	return err
+}
+
 func GetEcdsaPrivateKeyFromJson(jsonContent []byte) (*ecdsa.PrivateKey, error) {
 	privateKey := new(ECDSAPrivateKey)
 	err := json.Unmarshal(jsonContent, privateKey)
@@ -381,6 +460,62 @@ func GetEcdsaPublicKeyFromFile(filename string) (*ecdsa.PublicKey, error) {
 	return GetEcdsaPublicKeyFromJson(content)
 }
 
+func GetEd25519PrivateKeyFromJson(jsonContent []byte) (ed25519.PrivateKey, error) {
+	privateKey := new(Ed25519PrivateKey)
+	err := json.Unmarshal(jsonContent, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if privateKey.Curvname != config.CurveEd25519 {
+		err = fmt.Errorf("curve [%v] is not supported yet.", privateKey.Curvname)
+		return nil, err
+	}
+	ed25519PrivateKey, err := ed25519_sign.GenerateKeyFromSeed(privateKey.Seed)
+	if err != nil {
+		return nil, err
+	}
+	// 公钥必须与种子派生出的公钥一致
+	if !utils.BytesCompare(ed25519PrivateKey.Public().(ed25519.PublicKey), privateKey.PublicKey) {
+		return nil, KeyParamNotMatchError
+	}
+
+	return ed25519PrivateKey, nil
+}
+func GetEd25519PrivateKeyFromFile(filename string) (ed25519.PrivateKey, error) {
+	content, err := readFileUsingFilename(filename)
+	if err != nil {
+		log.Printf("readFileUsingFilename failed, the err is %v", err)
+		return nil, err
+	}
+
+	return GetEd25519PrivateKeyFromJson(content)
+}
+func GetEd25519PublicKeyFromJson(jsonContent []byte) (ed25519.PublicKey, error) {
+	publicKey := new(Ed25519PublicKey)
+	err := json.Unmarshal(jsonContent, publicKey)
+	if err != nil {
+		return nil, err //json有问题
+	}
+	if publicKey.Curvname != config.CurveEd25519 {
+		err = fmt.Errorf("curve [%v] is not supported yet.", publicKey.Curvname)
+		return nil, err
+	}
+	if len(publicKey.PublicKey) != ed25519.PublicKeySize {
+		return nil, InvalidEd25519PublicKeyError
+	}
+
+	return ed25519.PublicKey(publicKey.PublicKey), nil
+}
+func GetEd25519PublicKeyFromFile(filename string) (ed25519.PublicKey, error) {
+	content, err := readFileUsingFilename(filename)
+	if err != nil {
+		log.Printf("readFileUsingFilename failed, the err is %v", err)
+		return nil, err
+	}
+
+	return GetEd25519PublicKeyFromJson(content)
+}
+
 func GetCryptoByteFromMnemonic(mnemonic string, language int) (uint8, error) {
 	entropy, err := walletRand.GetEntropyFromMnemonic(mnemonic, language)
 	if err != nil {
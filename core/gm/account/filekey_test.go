@@ -0,0 +1,72 @@
+package account
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/ed25519_sign"
+	walletRand "github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
+)
+
+func TestEd25519AccountWithMnemonic(t *testing.T) {
+	account, err := CreateNewAccountWithMnemonic(walletRand.English, StrengthMiddle, config.Ed25519)
+	require.NoError(t, err)
+
+	cryptography, err := GetCryptoByteFromMnemonic(account.Mnemonic, walletRand.English)
+	require.NoError(t, err)
+	require.Equal(t, uint8(config.Ed25519), cryptography)
+
+	privateKey, err := GetEd25519PrivateKeyFromJson([]byte(account.JsonPrivateKey))
+	require.NoError(t, err)
+	publicKey, err := GetEd25519PublicKeyFromJson([]byte(account.JsonPublicKey))
+	require.NoError(t, err)
+	require.Equal(t, privateKey.Public(), publicKey)
+	ok, _ := VerifyAddressUsingEd25519PublicKey(account.Address, publicKey)
+	require.True(t, ok)
+
+	// 助记词可以恢复出相同的账户
+	restored, err := GenerateAccountByMnemonic(account.Mnemonic, walletRand.English)
+	require.NoError(t, err)
+	require.Equal(t, account.JsonPrivateKey, restored.JsonPrivateKey)
+	require.Equal(t, account.Address, restored.Address)
+}
+
+func TestExportEd25519Account(t *testing.T) {
+	privateKey, err := ed25519_sign.GenerateKey()
+	require.NoError(t, err)
+	path := t.TempDir()
+	require.NoError(t, ExportNewEd25519Account(path, privateKey))
+
+	loaded, err := GetEd25519PrivateKeyFromFile(path + "/private.key")
+	require.NoError(t, err)
+	require.Equal(t, privateKey, loaded)
+	publicKey, err := GetEd25519PublicKeyFromFile(path + "/public.key")
+	require.NoError(t, err)
+	require.Equal(t, privateKey.Public(), publicKey)
+	address, err := readFileUsingFilename(path + "/address")
+	require.NoError(t, err)
+	ok, _ := VerifyAddressUsingEd25519PublicKey(string(address), publicKey)
+	require.True(t, ok)
+
+	// ECDSA格式的私钥无法按Ed25519私钥读取，公钥与种子不一致时报错
+	_, err = GetEd25519PrivateKeyFromJson([]byte(`{"Curvname":"SM2-P-256","X":1,"Y":2,"D":3}`))
+	require.Error(t, err)
+	other, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	jsonPrivateKey, err := GetEd25519PrivateKeyJsonFormat(privateKey)
+	require.NoError(t, err)
+	key := new(Ed25519PrivateKey)
+	require.NoError(t, json.Unmarshal([]byte(jsonPrivateKey), key))
+	key.PublicKey = other
+	mixed, err := json.Marshal(key)
+	require.NoError(t, err)
+	_, err = GetEd25519PrivateKeyFromJson(mixed)
+	require.Equal(t, KeyParamNotMatchError, err)
+
+	_, err = GetEd25519PublicKeyFromJson([]byte(`{"Curvname":"Ed25519","PublicKey":"AQID"}`))
+	require.Equal(t, InvalidEd25519PublicKeyError, err)
+}
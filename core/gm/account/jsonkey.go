@@ -2,8 +2,11 @@ package account
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/json"
 	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
 )
 
 // 通过这个数据结构来生成私钥的json
@@ -18,6 +21,19 @@ type ECDSAPublicKey struct {
 	X, Y     *big.Int
 }
 
+// 通过这个数据结构来生成Ed25519私钥的json，Seed为32字节的私钥种子
+type Ed25519PrivateKey struct {
+	Curvname  string
+	Seed      []byte
+	PublicKey []byte
+}
+
+// 通过这个数据结构来生成Ed25519公钥的json
+type Ed25519PublicKey struct {
+	Curvname  string
+	PublicKey []byte
+}
+
 func getNewEcdsaPrivateKey(k *ecdsa.PrivateKey) *ECDSAPrivateKey {
 	key := new(ECDSAPrivateKey)
 	key.Curvname = k.Params().Name
@@ -78,3 +94,36 @@ func GetEcdsaPublicKeyJsonFormatFromPublicKey(k *ecdsa.PublicKey) (string, error
 
 	return string(data), err
 }
+
+// 获得Ed25519私钥所对应的的json
+func GetEd25519PrivateKeyJsonFormat(k ed25519.PrivateKey) (string, error) {
+	if len(k) != ed25519.PrivateKeySize {
+		return "", InvalidEd25519PrivateKeyError
+	}
+	key := &Ed25519PrivateKey{
+		Curvname:  config.CurveEd25519,
+		Seed:      k.Seed(),
+		PublicKey: k.Public().(ed25519.PublicKey),
+	}
+
+	// 转换json
+	data, err := json.Marshal(key)
+
+	return string(data), err
+}
+
+// 获得Ed25519公钥所对应的的json
+func GetEd25519PublicKeyJsonFormat(k ed25519.PublicKey) (string, error) {
+	if len(k) != ed25519.PublicKeySize {
+		return "", InvalidEd25519PublicKeyError
+	}
+	key := &Ed25519PublicKey{
+		Curvname:  config.CurveEd25519,
+		PublicKey: k,
+	}
+
+	// 转换json
+	data, err := json.Marshal(key)
+
+	return string(data), err
+}
@@ -0,0 +1,249 @@
+package adaptor_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+// Schnorr适配器签名，用于原子跨链交换与条件支付
+//
+// 签名与schnorr_sign相同：R = s*G + e*P，e = H(m || R)。适配器签名锁定在点T = t*G上：
+// 1. 预签名：随机选择k，计算 e = H(m || k*G + T)，s' = k - e*x mod n，预签名为(T, e, s')
+// 2. 验证预签名：检查 e = H(m || s'*G + e*P + T)，验证者确信知道t的一方可以得到有效的签名
+// 3. 完成签名：s = s' + t mod n，此时 s*G + e*P = k*G + T，(e, s)可以通过schnorr_sign.Verify验证
+// 4. 提取秘密：已知预签名与完成的签名时 t = s - s' mod n
+//
+// 原子交换：A在链1上对交易m1做锁定在T上的预签名，B在链2上对交易m2做锁定在同一个T上的预签名，
+// 持有t的一方在链上公开完成的签名后，另一方从链上的签名中提取t，并完成对方的预签名。
+//
+// 预签名的k由RFC 6979的混合模式生成，摘要覆盖消息与T，对同一消息的不同T不会重复使用k。
+
+var (
+	InvalidInputParamsError   = errors.New("Invalid input params")
+	EmptyMessageError         = errors.New("Message to be sign should not be nil")
+	UnsupportedCurveError     = errors.New("The curve is not supported")
+	InvalidAdaptorPointError  = errors.New("The adaptor point is invalid")
+	InvalidAdaptorSecretError = errors.New("The adaptor secret does not match the adaptor point")
+	InvalidPreSignatureError  = errors.New("The pre-signature is invalid")
+	InvalidSignatureError     = errors.New("The completed signature does not match the pre-signature")
+	GenerateSignatureError    = errors.New("Failed to generate the adaptor signature")
+)
+
+// GenerateAdaptorSecret 随机生成适配器秘密t，返回t与压缩格式的T = t*G
+func GenerateAdaptorSecret(curve elliptic.Curve) (*big.Int, []byte, error) {
+	if err := checkCurve(curve); err != nil {
+		return nil, nil, err
+	}
+	t, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	point, err := ecc.ScalarBaseMult(curve, t).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, point, nil
+}
+
+// PreSign 生成锁定在adaptorPoint（T = t*G的编码）上的预签名
+func PreSign(privateKey *ecdsa.PrivateKey, message, adaptorPoint []byte) ([]byte, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	curve := privateKey.Curve
+	if err := checkCurve(curve); err != nil {
+		return nil, err
+	}
+	t, err := ecc.UnmarshalPoint(curve, adaptorPoint)
+	if err != nil {
+		return nil, InvalidAdaptorPointError
+	}
+	n := curve.Params().N
+
+	digest := nonce.Digest(curve, message, elliptic.Marshal(curve, t.X, t.Y))
+	g, err := nonce.NewGenerator(curve, privateKey.D, digest, nonce.ModeHedged)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		k, err := g.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		// e = H(m || k*G + T)
+		r := ecc.AddPoints(ecc.ScalarBaseMult(curve, k), t)
+		if r.IsInfinity() {
+			continue
+		}
+		e := challenge(curve, message, r)
+
+		// s' = k - e*x mod n
+		s := new(big.Int).Mul(e, privateKey.D)
+		s.Sub(k, s)
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		return json.Marshal(&common.AdaptorSignature{
+			CurveName: curve.Params().Name,
+			T:         &common.PublicKeyFactor{X: t.X, Y: t.Y},
+			E:         e,
+			S:         s,
+		})
+	}
+}
+
+// PreVerify 验证预签名，并检查预签名锁定在adaptorPoint上
+func PreVerify(publicKey *ecdsa.PublicKey, preSignature, message, adaptorPoint []byte) (bool, error) {
+	if publicKey == nil {
+		return false, InvalidInputParamsError
+	}
+	sig, t, err := parsePreSignature(preSignature)
+	if err != nil {
+		return false, err
+	}
+	curve := publicKey.Curve
+	if curve.Params().Name != sig.CurveName {
+		return false, nil
+	}
+	expected, err := ecc.UnmarshalPoint(curve, adaptorPoint)
+	if err != nil {
+		return false, InvalidAdaptorPointError
+	}
+	if !expected.Equals(t) {
+		return false, nil
+	}
+
+	// e = H(m || s'*G + e*P + T)
+	p := &ecc.Point{Curve: curve, X: publicKey.X, Y: publicKey.Y}
+	params := curve.Params()
+	r := ecc.MultiScalarMult(curve,
+		[]*big.Int{sig.S, new(big.Int).Mod(sig.E, params.N), big.NewInt(1)},
+		[]*ecc.Point{ecc.BasePoint(curve), p, t})
+	if r.IsInfinity() {
+		return false, nil
+	}
+	return challenge(curve, message, r).Cmp(sig.E) == 0, nil
+}
+
+// Adapt 使用秘密t完成预签名，返回schnorr_sign.Sign格式的超级签名
+func Adapt(preSignature []byte, secret *big.Int) ([]byte, error) {
+	if secret == nil {
+		return nil, InvalidInputParamsError
+	}
+	sig, t, err := parsePreSignature(preSignature)
+	if err != nil {
+		return nil, err
+	}
+	curve := t.Curve
+	n := curve.Params().N
+	if !ecc.ScalarBaseMult(curve, new(big.Int).Mod(secret, n)).Equals(t) {
+		return nil, InvalidAdaptorSecretError
+	}
+
+	// s = s' + t mod n
+	s := new(big.Int).Add(sig.S, secret)
+	s.Mod(s, n)
+	if s.Sign() == 0 {
+		return nil, GenerateSignatureError
+	}
+
+	sigContent, err := json.Marshal(&common.SchnorrSignature{E: sig.E, S: s})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&common.XuperSignature{
+		SigType:    common.Schnorr,
+		SigContent: sigContent,
+	})
+}
+
+// Extract 从预签名与完成的签名（Adapt返回的超级签名）中提取秘密 t = s - s' mod n
+func Extract(preSignature, signature []byte) (*big.Int, error) {
+	sig, t, err := parsePreSignature(preSignature)
+	if err != nil {
+		return nil, err
+	}
+	xuperSig := new(common.XuperSignature)
+	if err := json.Unmarshal(signature, xuperSig); err != nil || xuperSig.SigType != common.Schnorr {
+		return nil, InvalidSignatureError
+	}
+	schnorrSig := new(common.SchnorrSignature)
+	if err := json.Unmarshal(xuperSig.SigContent, schnorrSig); err != nil || schnorrSig.E == nil || schnorrSig.S == nil {
+		return nil, InvalidSignatureError
+	}
+	if schnorrSig.E.Cmp(sig.E) != 0 {
+		return nil, InvalidSignatureError
+	}
+
+	curve := t.Curve
+	n := curve.Params().N
+	secret := new(big.Int).Sub(schnorrSig.S, sig.S)
+	secret.Mod(secret, n)
+	if !ecc.ScalarBaseMult(curve, secret).Equals(t) {
+		return nil, InvalidSignatureError
+	}
+	return secret, nil
+}
+
+// Verify 验证完成的签名，与schnorr_sign.Verify相同，sig为超级签名中的SigContent
+func Verify(publicKey *ecdsa.PublicKey, sig, message []byte) (bool, error) {
+	return schnorr_sign.Verify(publicKey, sig, message)
+}
+
+func parsePreSignature(preSignature []byte) (*common.AdaptorSignature, *ecc.Point, error) {
+	sig := new(common.AdaptorSignature)
+	if err := json.Unmarshal(preSignature, sig); err != nil {
+		return nil, nil, InvalidPreSignatureError
+	}
+	if sig.T == nil || sig.E == nil || sig.S == nil {
+		return nil, nil, InvalidPreSignatureError
+	}
+	curve, err := ecc.CurveByName(sig.CurveName)
+	if err != nil {
+		return nil, nil, UnsupportedCurveError
+	}
+	if err := checkCurve(curve); err != nil {
+		return nil, nil, err
+	}
+	if sig.S.Sign() <= 0 || sig.S.Cmp(curve.Params().N) >= 0 {
+		return nil, nil, InvalidPreSignatureError
+	}
+	t, err := ecc.NewPoint(curve, sig.T.X, sig.T.Y)
+	if err != nil {
+		return nil, nil, InvalidPreSignatureError
+	}
+	return sig, t, nil
+}
+
+// challenge e = H(m || R)，与schnorr_sign一致
+func challenge(curve elliptic.Curve, message []byte, r *ecc.Point) *big.Int {
+	return new(big.Int).SetBytes(hash.HashUsingSM3(utils.BytesCombine(message, elliptic.Marshal(curve, r.X, r.Y))))
+}
+
+func checkCurve(curve elliptic.Curve) error {
+	if curve == nil {
+		return InvalidInputParamsError
+	}
+	name := curve.Params().Name
+	if name != config.CurveGm && name != config.CurveNist {
+		return UnsupportedCurveError
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package adaptor_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func sigContent(t *testing.T, sig []byte) []byte {
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	require.Equal(t, common.Schnorr, xuperSig.SigType)
+	return xuperSig.SigContent
+}
+
+func TestAdaptorSignature(t *testing.T) {
+	message := []byte("transfer 10 to bob")
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		secret, point, err := GenerateAdaptorSecret(curve)
+		require.NoError(t, err)
+
+		preSig, err := PreSign(key, message, point)
+		require.NoError(t, err)
+		ok, err := PreVerify(&key.PublicKey, preSig, message, point)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		// 预签名本身不是有效的签名
+		ok, err = Verify(&key.PublicKey, preSig, message)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// 锁定在其它点上或消息不同时预签名无效
+		_, otherPoint, err := GenerateAdaptorSecret(curve)
+		require.NoError(t, err)
+		ok, err = PreVerify(&key.PublicKey, preSig, message, otherPoint)
+		require.NoError(t, err)
+		require.False(t, ok)
+		ok, err = PreVerify(&key.PublicKey, preSig, []byte("transfer 100 to bob"), point)
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		sig, err := Adapt(preSig, secret)
+		require.NoError(t, err)
+		ok, err = Verify(&key.PublicKey, sigContent(t, sig), message)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		extracted, err := Extract(preSig, sig)
+		require.NoError(t, err)
+		require.Equal(t, secret, extracted)
+
+		_, err = Adapt(preSig, new(big.Int).Add(secret, big.NewInt(1)))
+		require.Equal(t, InvalidAdaptorSecretError, err)
+	}
+}
+
+// 原子交换：A与B的两笔交易锁定在同一个T上，B从A公开的签名中提取t后完成自己的交易
+func TestAdaptorAtomicSwap(t *testing.T) {
+	curve := sm2.P256Sm2()
+	alice, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	bob, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	tx1 := []byte("chain1: bob pays alice")
+	tx2 := []byte("chain2: alice pays bob")
+
+	// A选择秘密t，双方各自对支付给对方的交易做锁定在T上的预签名
+	secret, point, err := GenerateAdaptorSecret(curve)
+	require.NoError(t, err)
+	preSig1, err := PreSign(bob, tx1, point)
+	require.NoError(t, err)
+	preSig2, err := PreSign(alice, tx2, point)
+	require.NoError(t, err)
+	ok, err := PreVerify(&bob.PublicKey, preSig1, tx1, point)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = PreVerify(&alice.PublicKey, preSig2, tx2, point)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A完成B的预签名并在链1上公开，B从中提取t并完成A的预签名
+	sig1, err := Adapt(preSig1, secret)
+	require.NoError(t, err)
+	extracted, err := Extract(preSig1, sig1)
+	require.NoError(t, err)
+	sig2, err := Adapt(preSig2, extracted)
+	require.NoError(t, err)
+	ok, err = Verify(&alice.PublicKey, sigContent(t, sig2), tx2)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// 完成的签名与预签名不对应
+	_, err = Extract(preSig1, sig2)
+	require.Equal(t, InvalidSignatureError, err)
+}
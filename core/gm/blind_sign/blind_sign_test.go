@@ -0,0 +1,119 @@
+package blind_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+)
+
+func TestBlindSchnorr(t *testing.T) {
+	message := []byte("coupon #1024")
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		signer, commitment, err := NewSchnorrSignerSession(key)
+		require.NoError(t, err)
+		user, challenge, err := NewSchnorrUserSession(&key.PublicKey, commitment, message)
+		require.NoError(t, err)
+		blindSig, err := signer.Sign(challenge)
+		require.NoError(t, err)
+		sig, err := user.Unblind(blindSig)
+		require.NoError(t, err)
+
+		xuperSig := new(common.XuperSignature)
+		require.NoError(t, json.Unmarshal(sig, xuperSig))
+		require.Equal(t, common.Schnorr, xuperSig.SigType)
+		ok, err := schnorr_sign.Verify(&key.PublicKey, xuperSig.SigContent, message)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = schnorr_sign.Verify(&key.PublicKey, xuperSig.SigContent, []byte("coupon #1025"))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// 签名者看到的挑战与s都不出现在最终的签名中
+		schnorrSig := new(common.SchnorrSignature)
+		require.NoError(t, json.Unmarshal(xuperSig.SigContent, schnorrSig))
+		require.NotEqual(t, new(big.Int).SetBytes(challenge), schnorrSig.E)
+		require.NotEqual(t, new(big.Int).SetBytes(blindSig), schnorrSig.S)
+
+		// 每个会话只能使用一次
+		_, err = signer.Sign(challenge)
+		require.Equal(t, SessionAlreadyUsedError, err)
+		_, err = user.Unblind(blindSig)
+		require.Equal(t, SessionAlreadyUsedError, err)
+	}
+}
+
+func TestBlindSchnorrInvalidResponse(t *testing.T) {
+	key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, commitment, err := NewSchnorrSignerSession(key)
+	require.NoError(t, err)
+	user, challenge, err := NewSchnorrUserSession(&key.PublicKey, commitment, []byte("coupon"))
+	require.NoError(t, err)
+	blindSig, err := signer.Sign(challenge)
+	require.NoError(t, err)
+
+	blindSig[len(blindSig)-1] ^= 1
+	_, err = user.Unblind(blindSig)
+	require.Equal(t, InvalidBlindSignatureError, err)
+
+	_, _, err = NewSchnorrUserSession(&key.PublicKey, []byte{0x02, 0x01}, []byte("coupon"))
+	require.Equal(t, InvalidCommitmentError, err)
+	_, _, err = NewSchnorrUserSession(&key.PublicKey, commitment, nil)
+	require.Equal(t, EmptyMessageError, err)
+}
+
+func TestBlindSM2(t *testing.T) {
+	key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	pub := &sm2.PublicKey{Curve: key.Curve, X: key.X, Y: key.Y}
+	message := []byte("anonymous credential")
+	uid := []byte("1234567812345678")
+
+	signer, commitment, err := NewSM2SignerSession(key)
+	require.NoError(t, err)
+	user, blindedR, err := NewSM2UserSession(&key.PublicKey, commitment, message, uid)
+	require.NoError(t, err)
+	blindSig, err := signer.Sign(blindedR)
+	require.NoError(t, err)
+	sig, err := user.Unblind(blindSig)
+	require.NoError(t, err)
+
+	r, s, err := sm2.SignDataToSignDigit(sig)
+	require.NoError(t, err)
+	require.True(t, sm2.Sm2Verify(pub, message, uid, r, s))
+	require.False(t, sm2.Sm2Verify(pub, []byte("tampered"), uid, r, s))
+	require.NotEqual(t, new(big.Int).SetBytes(blindedR), r)
+	require.NotEqual(t, new(big.Int).SetBytes(blindSig), s)
+
+	_, err = signer.Sign(blindedR)
+	require.Equal(t, SessionAlreadyUsedError, err)
+
+	// 签名者返回错误的s
+	signer, commitment, err = NewSM2SignerSession(key)
+	require.NoError(t, err)
+	user, blindedR, err = NewSM2UserSession(&key.PublicKey, commitment, message, uid)
+	require.NoError(t, err)
+	blindSig, err = signer.Sign(blindedR)
+	require.NoError(t, err)
+	blindSig[0] ^= 1
+	_, err = user.Unblind(blindSig)
+	require.Equal(t, InvalidBlindSignatureError, err)
+
+	nistKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, _, err = NewSM2SignerSession(nistKey)
+	require.Equal(t, UnsupportedCurveError, err)
+}
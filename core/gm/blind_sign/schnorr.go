@@ -0,0 +1,193 @@
+package blind_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+// 盲签名：签名者对用户盲化后的数据签名，无法把签发过程与最终的签名关联起来，适用于匿名凭证、优惠券等场景。
+//
+// 盲Schnorr签名，最终的签名与schnorr_sign.Sign的格式一致，可以直接使用schnorr_sign.Verify验证：
+// 签名者持有私钥x，公钥P = x*G，用户持有消息m
+// 1. 签名者随机选择k，发送承诺 R = k*G
+// 2. 用户随机选择盲化因子α、β，计算 R' = R + α*G + β*P，e' = H(m || R')，发送盲化的挑战 e = e' - β mod n
+// 3. 签名者计算 s = k - e*x mod n
+// 4. 用户验证 s*G + e*P = R，计算 s' = s + α mod n，签名为(e', s')
+// 此时 s'*G + e'*P = R - e*P + α*G + e'*P = R + α*G + β*P = R'。
+// 对任意的(R, e, s)与(e', s')都存在一组α、β使等式成立，因此签名者无法关联签发与使用。
+//
+// 注意：签名者同时打开大量会话时，用户可以利用ROS攻击多得到一个签名，
+// 签名者应当限制并发会话的数量或逐个完成会话。每个会话的k只能使用一次。
+
+var (
+	InvalidInputParamsError    = errors.New("Invalid input params")
+	EmptyMessageError          = errors.New("Message to be sign should not be nil")
+	UnsupportedCurveError      = errors.New("The curve is not supported")
+	InvalidCommitmentError     = errors.New("The commitment of the signer is invalid")
+	InvalidChallengeError      = errors.New("The blinded challenge is invalid")
+	InvalidBlindSignatureError = errors.New("The blind signature returned by the signer is invalid")
+	SessionAlreadyUsedError    = errors.New("The blind signing session has already been used")
+)
+
+// SchnorrSignerSession 签名者一次盲Schnorr签名的状态
+type SchnorrSignerSession struct {
+	privateKey *ecdsa.PrivateKey
+	k          *big.Int
+	used       bool
+}
+
+// SchnorrUserSession 用户一次盲Schnorr签名的状态
+type SchnorrUserSession struct {
+	publicKey  *ecdsa.PublicKey
+	message    []byte
+	commitment *ecc.Point
+	challenge  *big.Int
+	alpha      *big.Int
+	e          *big.Int
+	used       bool
+}
+
+// NewSchnorrSignerSession 签名者开始一次盲签名，返回需要发送给用户的承诺R
+func NewSchnorrSignerSession(privateKey *ecdsa.PrivateKey) (*SchnorrSignerSession, []byte, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	curve := privateKey.Curve
+	k, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment, err := ecc.ScalarBaseMult(curve, k).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &SchnorrSignerSession{privateKey: privateKey, k: k}, commitment, nil
+}
+
+// Sign 签名者对盲化的挑战e计算 s = k - e*x mod n
+func (session *SchnorrSignerSession) Sign(blindedChallenge []byte) ([]byte, error) {
+	if session.used {
+		return nil, SessionAlreadyUsedError
+	}
+	curve := session.privateKey.Curve
+	n := curve.Params().N
+
+	e := new(big.Int).SetBytes(blindedChallenge)
+	if len(blindedChallenge) == 0 || e.Sign() == 0 || e.Cmp(n) >= 0 {
+		return nil, InvalidChallengeError
+	}
+
+	k := session.k
+	session.used = true
+	session.k = nil
+
+	s := new(big.Int).Mul(e, session.privateKey.D)
+	s.Sub(k, s)
+	s.Mod(s, n)
+	return ecc.ScalarBytes(curve, s), nil
+}
+
+// NewSchnorrUserSession 用户对消息盲化，返回需要发送给签名者的挑战e
+func NewSchnorrUserSession(publicKey *ecdsa.PublicKey, commitment, message []byte) (*SchnorrUserSession, []byte, error) {
+	if publicKey == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	if len(message) == 0 {
+		return nil, nil, EmptyMessageError
+	}
+	curve := publicKey.Curve
+	n := curve.Params().N
+
+	r, err := ecc.UnmarshalPoint(curve, commitment)
+	if err != nil {
+		return nil, nil, InvalidCommitmentError
+	}
+	p := &ecc.Point{Curve: curve, X: publicKey.X, Y: publicKey.Y}
+
+	for {
+		alpha, err := ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		beta, err := ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// R' = R + α*G + β*P
+		rPrime := ecc.MultiScalarMult(curve, []*big.Int{big.NewInt(1), alpha, beta}, []*ecc.Point{r, ecc.BasePoint(curve), p})
+		if rPrime.IsInfinity() {
+			continue
+		}
+
+		// e' = H(m || R')，与schnorr_sign.Sign一致，e'不做模n约简
+		ePrime := new(big.Int).SetBytes(hash.HashUsingSM3(utils.BytesCombine(message, elliptic.Marshal(curve, rPrime.X, rPrime.Y))))
+		challenge := new(big.Int).Sub(ePrime, beta)
+		challenge.Mod(challenge, n)
+		if challenge.Sign() == 0 {
+			continue
+		}
+
+		session := &SchnorrUserSession{
+			publicKey:  publicKey,
+			message:    append([]byte{}, message...),
+			commitment: r,
+			challenge:  challenge,
+			alpha:      alpha,
+			e:          ePrime,
+		}
+		return session, ecc.ScalarBytes(curve, challenge), nil
+	}
+}
+
+// Unblind 用户验证签名者返回的s，去除盲化后得到schnorr_sign.Sign格式的超级签名
+func (session *SchnorrUserSession) Unblind(blindSignature []byte) ([]byte, error) {
+	if session.used {
+		return nil, SessionAlreadyUsedError
+	}
+	curve := session.publicKey.Curve
+	n := curve.Params().N
+
+	s := new(big.Int).SetBytes(blindSignature)
+	if len(blindSignature) == 0 || s.Cmp(n) >= 0 {
+		return nil, InvalidBlindSignatureError
+	}
+
+	// s*G + e*P = R
+	p := &ecc.Point{Curve: curve, X: session.publicKey.X, Y: session.publicKey.Y}
+	check := ecc.MultiScalarMult(curve, []*big.Int{s, session.challenge}, []*ecc.Point{ecc.BasePoint(curve), p})
+	if !check.Equals(session.commitment) {
+		return nil, InvalidBlindSignatureError
+	}
+	session.used = true
+
+	// s' = s + α mod n
+	sPrime := new(big.Int).Add(s, session.alpha)
+	sPrime.Mod(sPrime, n)
+	if sPrime.Sign() == 0 {
+		return nil, InvalidBlindSignatureError
+	}
+
+	sigContent, err := json.Marshal(&common.SchnorrSignature{E: session.e, S: sPrime})
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := schnorr_sign.Verify(session.publicKey, sigContent, session.message); err != nil || !ok {
+		return nil, InvalidBlindSignatureError
+	}
+
+	xuperSig := &common.XuperSignature{
+		SigType:    common.Schnorr,
+		SigContent: sigContent,
+	}
+	return json.Marshal(xuperSig)
+}
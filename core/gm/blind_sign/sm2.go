@@ -0,0 +1,205 @@
+package blind_sign
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 盲SM2签名，最终的签名是标准的ASN.1编码SM2签名，可以使用sm2.Sm2Verify验证：
+// SM2签名满足 s*(G + P) + r*P = K，K = k*G，记 Q = G + P
+// 1. 签名者随机选择k，发送承诺 K = k*G
+// 2. 用户计算 e' = SM3(ZA || M)，随机选择盲化因子α、β、γ，
+// 计算 K' = α*K + β*Q + γ*P，r' = (e' + x(K')) mod n，发送盲化的 r = α^-1 * (r' - γ) mod n
+// 3. 签名者按SM2的签名公式计算 s = (1 + d)^-1 * (k - r*d) mod n
+// 4. 用户验证 s*Q + r*P = K，计算 s' = α*s + β mod n，签名为(r', s')
+// 此时 s'*Q + r'*P = α*(s*Q + r*P) + β*Q + γ*P = K'，即 r' = e' + x(s'*G + (r' + s')*P)，满足SM2的验证等式。
+// 对任意的(K, r, s)与(r', s')都存在一组α、β、γ使等式成立，因此签名者无法关联签发与使用。
+//
+// 签名者只执行标准SM2签名中 s 的计算，私钥的使用方式与sm2.Sign相同。
+// 与盲Schnorr签名相同，签名者应当限制并发会话的数量，每个会话的k只能使用一次。
+
+// SM2SignerSession 签名者一次盲SM2签名的状态
+type SM2SignerSession struct {
+	privateKey *ecdsa.PrivateKey
+	k          *big.Int
+	used       bool
+}
+
+// SM2UserSession 用户一次盲SM2签名的状态
+type SM2UserSession struct {
+	publicKey  *ecdsa.PublicKey
+	message    []byte
+	uid        []byte
+	commitment *ecc.Point
+	r          *big.Int
+	rPrime     *big.Int
+	alpha      *big.Int
+	beta       *big.Int
+	used       bool
+}
+
+// NewSM2SignerSession 签名者开始一次盲签名，返回需要发送给用户的承诺K
+func NewSM2SignerSession(privateKey *ecdsa.PrivateKey) (*SM2SignerSession, []byte, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	curve := privateKey.Curve
+	if curve.Params().Name != config.CurveGm {
+		return nil, nil, UnsupportedCurveError
+	}
+	k, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment, err := ecc.ScalarBaseMult(curve, k).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	return &SM2SignerSession{privateKey: privateKey, k: k}, commitment, nil
+}
+
+// Sign 签名者对盲化的r计算 s = (1 + d)^-1 * (k - r*d) mod n
+func (session *SM2SignerSession) Sign(blindedR []byte) ([]byte, error) {
+	if session.used {
+		return nil, SessionAlreadyUsedError
+	}
+	curve := session.privateKey.Curve
+	n := curve.Params().N
+
+	r := new(big.Int).SetBytes(blindedR)
+	if len(blindedR) == 0 || r.Sign() == 0 || r.Cmp(n) >= 0 {
+		return nil, InvalidChallengeError
+	}
+
+	k := session.k
+	session.used = true
+	session.k = nil
+
+	d := session.privateKey.D
+	s := new(big.Int).Mul(r, d)
+	s.Sub(k, s)
+	s.Mul(s, new(big.Int).ModInverse(new(big.Int).Add(d, big.NewInt(1)), n))
+	s.Mod(s, n)
+	if s.Sign() == 0 {
+		return nil, InvalidChallengeError
+	}
+	return ecc.ScalarBytes(curve, s), nil
+}
+
+// NewSM2UserSession 用户对消息盲化，uid为SM2签名者的用户标识，返回需要发送给签名者的盲化r
+func NewSM2UserSession(publicKey *ecdsa.PublicKey, commitment, message, uid []byte) (*SM2UserSession, []byte, error) {
+	if publicKey == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	if len(message) == 0 {
+		return nil, nil, EmptyMessageError
+	}
+	curve := publicKey.Curve
+	if curve.Params().Name != config.CurveGm {
+		return nil, nil, UnsupportedCurveError
+	}
+	n := curve.Params().N
+
+	k, err := ecc.UnmarshalPoint(curve, commitment)
+	if err != nil {
+		return nil, nil, InvalidCommitmentError
+	}
+	p := &ecc.Point{Curve: curve, X: publicKey.X, Y: publicKey.Y}
+
+	// e' = SM3(ZA || M)
+	za, err := sm2.ZA(&sm2.PublicKey{Curve: curve, X: publicKey.X, Y: publicKey.Y}, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+	e := new(big.Int).SetBytes(sm3.Sm3Sum(append(za, message...)))
+
+	for {
+		alpha, err := ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		beta, err := ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+		gamma, err := ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// K' = α*K + β*(G + P) + γ*P = α*K + β*G + (β + γ)*P
+		betaGamma := new(big.Int).Add(beta, gamma)
+		betaGamma.Mod(betaGamma, n)
+		kPrime := ecc.MultiScalarMult(curve, []*big.Int{alpha, beta, betaGamma}, []*ecc.Point{k, ecc.BasePoint(curve), p})
+		if kPrime.IsInfinity() {
+			continue
+		}
+
+		// r' = (e' + x(K')) mod n
+		rPrime := new(big.Int).Add(e, kPrime.X)
+		rPrime.Mod(rPrime, n)
+		if rPrime.Sign() == 0 {
+			continue
+		}
+
+		// r = α^-1 * (r' - γ) mod n
+		r := new(big.Int).Sub(rPrime, gamma)
+		r.Mul(r, new(big.Int).ModInverse(alpha, n))
+		r.Mod(r, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		session := &SM2UserSession{
+			publicKey:  publicKey,
+			message:    append([]byte{}, message...),
+			uid:        append([]byte{}, uid...),
+			commitment: k,
+			r:          r,
+			rPrime:     rPrime,
+			alpha:      alpha,
+			beta:       beta,
+		}
+		return session, ecc.ScalarBytes(curve, r), nil
+	}
+}
+
+// Unblind 用户验证签名者返回的s，去除盲化后得到ASN.1编码的SM2签名
+func (session *SM2UserSession) Unblind(blindSignature []byte) ([]byte, error) {
+	if session.used {
+		return nil, SessionAlreadyUsedError
+	}
+	curve := session.publicKey.Curve
+	n := curve.Params().N
+
+	s := new(big.Int).SetBytes(blindSignature)
+	if len(blindSignature) == 0 || s.Sign() == 0 || s.Cmp(n) >= 0 {
+		return nil, InvalidBlindSignatureError
+	}
+
+	// s*(G + P) + r*P = s*G + (r + s)*P = K
+	p := &ecc.Point{Curve: curve, X: session.publicKey.X, Y: session.publicKey.Y}
+	t := new(big.Int).Add(session.r, s)
+	t.Mod(t, n)
+	check := ecc.MultiScalarMult(curve, []*big.Int{s, t}, []*ecc.Point{ecc.BasePoint(curve), p})
+	if !check.Equals(session.commitment) {
+		return nil, InvalidBlindSignatureError
+	}
+	session.used = true
+
+	// s' = α*s + β mod n
+	sPrime := new(big.Int).Mul(session.alpha, s)
+	sPrime.Add(sPrime, session.beta)
+	sPrime.Mod(sPrime, n)
+
+	pub := &sm2.PublicKey{Curve: curve, X: session.publicKey.X, Y: session.publicKey.Y}
+	if !sm2.Sm2Verify(pub, session.message, session.uid, session.rPrime, sPrime) {
+		return nil, InvalidBlindSignatureError
+	}
+	return sm2.SignDigitToSignData(session.rPrime, sPrime)
+}
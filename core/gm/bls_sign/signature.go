@@ -0,0 +1,395 @@
+package bls_sign
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+)
+
+// BLS12-381上的BLS签名，参考draft-irtf-cfrg-bls-signature-05中的proof-of-possession方案
+//
+// 私钥x，签名 σ = x * H(m)，验证 e(P, H(m)) = e(g, σ)，H为hash_to_curve（SSWU_RO，SHA-256）
+// 同一条消息上的多个签名可以相加得到聚合签名，对应公钥相加得到聚合公钥，只需两次配对即可完成验证（FastAggregateVerify）。
+// 公钥直接相加会受到rogue-key攻击，因此聚合前必须验证每个公钥的所有权证明（PopProve/PopVerify）。
+//
+// 两种参数选择：
+// MinPk: 公钥位于G1（48字节），签名位于G2（96字节），适合公钥数量多、需要保存大量公钥的场景
+// MinSig: 公钥位于G2（96字节），签名位于G1（48字节），适合签名数量多的场景
+
+const (
+	// MinPk 公钥位于G1，签名位于G2
+	MinPk = "MinPk"
+	// MinSig 公钥位于G2，签名位于G1
+	MinSig = "MinSig"
+)
+
+const (
+	minPkSignatureDST  = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+	minPkPopDST        = "BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+	minSigSignatureDST = "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_"
+	minSigPopDST       = "BLS_POP_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_"
+
+	keyGenSalt   = "BLS-SIG-KEYGEN-SALT-"
+	minIKMLength = 32
+)
+
+var (
+	InvalidInputParamsError  = errors.New("Invalid input params")
+	UnsupportedVariantError  = errors.New("The BLS variant is not supported")
+	InvalidPublicKeyError    = errors.New("The BLS public key is invalid")
+	InvalidSignatureError    = errors.New("The BLS signature is invalid")
+	VariantNotMatchError     = errors.New("The BLS variants of the keys and signatures are not the same")
+	TooShortKeyMaterialError = errors.New("The key material should be at least 32 bytes")
+	EmptyMessageError        = errors.New("Message to be sign should not be nil")
+)
+
+// PrivateKey BLS私钥
+type PrivateKey struct {
+	Variant string
+	X       *big.Int
+}
+
+// GenerateKey 随机生成私钥
+func GenerateKey(variant string) (*PrivateKey, error) {
+	ikm := make([]byte, minIKMLength)
+	if _, err := rand.Read(ikm); err != nil {
+		return nil, err
+	}
+	return GenerateKeyFromSeed(variant, ikm)
+}
+
+// GenerateKeyFromSeed 按draft-irtf-cfrg-bls-signature的KeyGen从密钥材料ikm派生私钥，ikm至少32字节
+func GenerateKeyFromSeed(variant string, ikm []byte) (*PrivateKey, error) {
+	if err := checkVariant(variant); err != nil {
+		return nil, err
+	}
+	if len(ikm) < minIKMLength {
+		return nil, TooShortKeyMaterialError
+	}
+
+	// L = ceil((3 * ceil(log2(r))) / 16)
+	const l = 48
+	order := fr.Modulus()
+	salt := []byte(keyGenSalt)
+	x := new(big.Int)
+	for x.Sign() == 0 {
+		h := sha256.Sum256(salt)
+		salt = h[:]
+		reader := hkdf.New(sha256.New, append(append([]byte{}, ikm...), 0), salt, []byte{0, l})
+		okm := make([]byte, l)
+		if _, err := io.ReadFull(reader, okm); err != nil {
+			return nil, err
+		}
+		x.SetBytes(okm)
+		x.Mod(x, order)
+	}
+	return &PrivateKey{Variant: variant, X: x}, nil
+}
+
+// PublicKey 返回压缩格式的公钥
+func (priv *PrivateKey) PublicKey() []byte {
+	_, _, g1, g2 := bls12381.Generators()
+	if priv.Variant == MinSig {
+		p := new(bls12381.G2Affine).ScalarMultiplication(&g2, priv.X)
+		b := p.Bytes()
+		return b[:]
+	}
+	p := new(bls12381.G1Affine).ScalarMultiplication(&g1, priv.X)
+	b := p.Bytes()
+	return b[:]
+}
+
+// Sign 对消息签名，返回超级签名
+func Sign(priv *PrivateKey, message []byte) ([]byte, error) {
+	if priv == nil || priv.X == nil {
+		return nil, InvalidInputParamsError
+	}
+	if err := checkVariant(priv.Variant); err != nil {
+		return nil, err
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	sig, err := coreSign(priv.Variant, priv.X, message, signatureDST(priv.Variant))
+	if err != nil {
+		return nil, err
+	}
+	return marshalSignature(priv.Variant, sig)
+}
+
+// Verify 验证单个签名，sig为超级签名中的SigContent
+func Verify(publicKey, sig, message []byte) (bool, error) {
+	return FastAggregateVerify([][]byte{publicKey}, sig, message)
+}
+
+// PopProve 生成公钥的所有权证明
+func PopProve(priv *PrivateKey) ([]byte, error) {
+	if priv == nil || priv.X == nil {
+		return nil, InvalidInputParamsError
+	}
+	if err := checkVariant(priv.Variant); err != nil {
+		return nil, err
+	}
+	return coreSign(priv.Variant, priv.X, priv.PublicKey(), popDST(priv.Variant))
+}
+
+// PopVerify 验证公钥的所有权证明，公钥参与聚合之前必须通过验证
+func PopVerify(variant string, publicKey, proof []byte) (bool, error) {
+	if err := checkVariant(variant); err != nil {
+		return false, err
+	}
+	return coreAggregateVerify(variant, [][]byte{publicKey}, proof, [][]byte{publicKey}, popDST(variant))
+}
+
+// AggregateSignatures 聚合多个签名（Sign返回的超级签名），返回聚合后的超级签名
+func AggregateSignatures(signatures [][]byte) ([]byte, error) {
+	if len(signatures) == 0 {
+		return nil, InvalidInputParamsError
+	}
+	variant := ""
+	points := make([][]byte, len(signatures))
+	for i, signature := range signatures {
+		xuperSig := new(common.XuperSignature)
+		if err := json.Unmarshal(signature, xuperSig); err != nil || xuperSig.SigType != common.BLS {
+			return nil, InvalidSignatureError
+		}
+		sig, err := unmarshalSignature(xuperSig.SigContent)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && sig.Variant != variant {
+			return nil, VariantNotMatchError
+		}
+		variant = sig.Variant
+		points[i] = sig.Signature
+	}
+
+	// MinPk的签名位于G2，MinSig的签名位于G1
+	var aggregated []byte
+	var err error
+	if variant == MinPk {
+		aggregated, err = sumG2(points)
+	} else {
+		aggregated, err = sumG1(points)
+	}
+	if err != nil {
+		return nil, InvalidSignatureError
+	}
+	return marshalSignature(variant, aggregated)
+}
+
+// AggregatePublicKeys 聚合同一种参数的公钥，调用方需要事先验证每个公钥的所有权证明
+func AggregatePublicKeys(variant string, publicKeys [][]byte) ([]byte, error) {
+	if err := checkVariant(variant); err != nil {
+		return nil, err
+	}
+	if len(publicKeys) == 0 {
+		return nil, InvalidInputParamsError
+	}
+	var aggregated []byte
+	var err error
+	if variant == MinPk {
+		aggregated, err = sumG1(publicKeys)
+	} else {
+		aggregated, err = sumG2(publicKeys)
+	}
+	if err != nil {
+		return nil, InvalidPublicKeyError
+	}
+	return aggregated, nil
+}
+
+// FastAggregateVerify 验证同一条消息上的聚合签名，publicKeys必须都已经通过PopVerify验证
+func FastAggregateVerify(publicKeys [][]byte, sig, message []byte) (bool, error) {
+	if len(publicKeys) == 0 || len(message) == 0 {
+		return false, InvalidInputParamsError
+	}
+	s, err := unmarshalSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	aggregated, err := AggregatePublicKeys(s.Variant, publicKeys)
+	if err != nil {
+		return false, err
+	}
+	return coreAggregateVerify(s.Variant, [][]byte{aggregated}, s.Signature, [][]byte{message}, signatureDST(s.Variant))
+}
+
+// AggregateVerify 验证不同消息上的聚合签名，publicKeys[i]对messages[i]签名，publicKeys必须都已经通过PopVerify验证
+func AggregateVerify(publicKeys [][]byte, sig []byte, messages [][]byte) (bool, error) {
+	if len(publicKeys) == 0 || len(publicKeys) != len(messages) {
+		return false, InvalidInputParamsError
+	}
+	s, err := unmarshalSignature(sig)
+	if err != nil {
+		return false, err
+	}
+	return coreAggregateVerify(s.Variant, publicKeys, s.Signature, messages, signatureDST(s.Variant))
+}
+
+// coreSign σ = x * H(m)
+func coreSign(variant string, x *big.Int, message []byte, dst string) ([]byte, error) {
+	if variant == MinPk {
+		h, err := bls12381.HashToG2(message, []byte(dst))
+		if err != nil {
+			return nil, err
+		}
+		sig := new(bls12381.G2Affine).ScalarMultiplication(&h, x)
+		b := sig.Bytes()
+		return b[:], nil
+	}
+	h, err := bls12381.HashToG1(message, []byte(dst))
+	if err != nil {
+		return nil, err
+	}
+	sig := new(bls12381.G1Affine).ScalarMultiplication(&h, x)
+	b := sig.Bytes()
+	return b[:], nil
+}
+
+// coreAggregateVerify 检查 ∏ e(P_i, H(m_i)) = e(g, σ)
+func coreAggregateVerify(variant string, publicKeys [][]byte, sig []byte, messages [][]byte, dst string) (bool, error) {
+	_, _, g1, g2 := bls12381.Generators()
+	n := len(publicKeys)
+
+	if variant == MinPk {
+		var sigPoint bls12381.G2Affine
+		if _, err := sigPoint.SetBytes(sig); err != nil {
+			return false, InvalidSignatureError
+		}
+		ps := make([]bls12381.G1Affine, n+1)
+		qs := make([]bls12381.G2Affine, n+1)
+		for i := range publicKeys {
+			if err := setPublicKeyG1(&ps[i], publicKeys[i]); err != nil {
+				return false, err
+			}
+			h, err := bls12381.HashToG2(messages[i], []byte(dst))
+			if err != nil {
+				return false, err
+			}
+			qs[i] = h
+		}
+		ps[n].Neg(&g1)
+		qs[n] = sigPoint
+		return bls12381.PairingCheck(ps, qs)
+	}
+
+	var sigPoint bls12381.G1Affine
+	if _, err := sigPoint.SetBytes(sig); err != nil {
+		return false, InvalidSignatureError
+	}
+	ps := make([]bls12381.G1Affine, n+1)
+	qs := make([]bls12381.G2Affine, n+1)
+	for i := range publicKeys {
+		if err := setPublicKeyG2(&qs[i], publicKeys[i]); err != nil {
+			return false, err
+		}
+		h, err := bls12381.HashToG1(messages[i], []byte(dst))
+		if err != nil {
+			return false, err
+		}
+		ps[i] = h
+	}
+	ps[n].Neg(&sigPoint)
+	qs[n] = g2
+	return bls12381.PairingCheck(ps, qs)
+}
+
+// setPublicKeyG1 解码公钥并做KeyValidate：位于子群中且不是无穷远点
+func setPublicKeyG1(p *bls12381.G1Affine, publicKey []byte) error {
+	if len(publicKey) != bls12381.SizeOfG1AffineCompressed {
+		return InvalidPublicKeyError
+	}
+	if _, err := p.SetBytes(publicKey); err != nil || p.IsInfinity() {
+		return InvalidPublicKeyError
+	}
+	return nil
+}
+
+func setPublicKeyG2(p *bls12381.G2Affine, publicKey []byte) error {
+	if len(publicKey) != bls12381.SizeOfG2AffineCompressed {
+		return InvalidPublicKeyError
+	}
+	if _, err := p.SetBytes(publicKey); err != nil || p.IsInfinity() {
+		return InvalidPublicKeyError
+	}
+	return nil
+}
+
+func sumG1(points [][]byte) ([]byte, error) {
+	var sum bls12381.G1Jac
+	for _, b := range points {
+		var p bls12381.G1Affine
+		if err := setPublicKeyG1(&p, b); err != nil {
+			return nil, err
+		}
+		sum.AddMixed(&p)
+	}
+	res := new(bls12381.G1Affine).FromJacobian(&sum).Bytes()
+	return res[:], nil
+}
+
+func sumG2(points [][]byte) ([]byte, error) {
+	var sum bls12381.G2Jac
+	for _, b := range points {
+		var p bls12381.G2Affine
+		if err := setPublicKeyG2(&p, b); err != nil {
+			return nil, err
+		}
+		sum.AddMixed(&p)
+	}
+	res := new(bls12381.G2Affine).FromJacobian(&sum).Bytes()
+	return res[:], nil
+}
+
+func marshalSignature(variant string, sig []byte) ([]byte, error) {
+	sigContent, err := json.Marshal(&common.BLSSignature{Variant: variant, Signature: sig})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&common.XuperSignature{
+		SigType:    common.BLS,
+		SigContent: sigContent,
+	})
+}
+
+func unmarshalSignature(sig []byte) (*common.BLSSignature, error) {
+	s := new(common.BLSSignature)
+	if err := json.Unmarshal(sig, s); err != nil {
+		return nil, InvalidSignatureError
+	}
+	if err := checkVariant(s.Variant); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func signatureDST(variant string) string {
+	if variant == MinPk {
+		return minPkSignatureDST
+	}
+	return minSigSignatureDST
+}
+
+func popDST(variant string) string {
+	if variant == MinPk {
+		return minPkPopDST
+	}
+	return minSigPopDST
+}
+
+func checkVariant(variant string) error {
+	if variant != MinPk && variant != MinSig {
+		return UnsupportedVariantError
+	}
+	return nil
+}
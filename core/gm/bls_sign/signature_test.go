@@ -0,0 +1,302 @@
+package bls_sign
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+)
+
+var variants = []string{MinPk, MinSig}
+
+func sigContent(t *testing.T, sig []byte) []byte {
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	require.Equal(t, common.BLS, xuperSig.SigType)
+	return xuperSig.SigContent
+}
+
+func generateKeys(t *testing.T, variant string, num int) ([]*PrivateKey, [][]byte) {
+	keys := make([]*PrivateKey, num)
+	publicKeys := make([][]byte, num)
+	for i := range keys {
+		key, err := GenerateKey(variant)
+		require.NoError(t, err)
+		keys[i] = key
+		publicKeys[i] = key.PublicKey()
+	}
+	return keys, publicKeys
+}
+
+func TestSignVerify(t *testing.T) {
+	message := []byte("vote for block 100")
+	for _, variant := range variants {
+		keys, publicKeys := generateKeys(t, variant, 2)
+
+		sig, err := Sign(keys[0], message)
+		require.NoError(t, err)
+		ok, err := Verify(publicKeys[0], sigContent(t, sig), message)
+		require.NoError(t, err)
+		require.True(t, ok, variant)
+
+		ok, err = Verify(publicKeys[0], sigContent(t, sig), []byte("vote for block 101"))
+		require.NoError(t, err)
+		require.False(t, ok, variant)
+		ok, err = Verify(publicKeys[1], sigContent(t, sig), message)
+		require.NoError(t, err)
+		require.False(t, ok, variant)
+	}
+
+	// 公钥与签名的参数不一致
+	minPkKey, err := GenerateKey(MinPk)
+	require.NoError(t, err)
+	minSigKey, err := GenerateKey(MinSig)
+	require.NoError(t, err)
+	sig, err := Sign(minSigKey, message)
+	require.NoError(t, err)
+	_, err = Verify(minPkKey.PublicKey(), sigContent(t, sig), message)
+	require.Equal(t, InvalidPublicKeyError, err)
+}
+
+func TestGenerateKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, 32)
+	key1, err := GenerateKeyFromSeed(MinPk, seed)
+	require.NoError(t, err)
+	key2, err := GenerateKeyFromSeed(MinPk, seed)
+	require.NoError(t, err)
+	require.Equal(t, key1.X, key2.X)
+
+	other, err := GenerateKeyFromSeed(MinPk, bytes.Repeat([]byte{0x43}, 32))
+	require.NoError(t, err)
+	require.NotEqual(t, key1.X, other.X)
+
+	_, err = GenerateKeyFromSeed(MinPk, seed[:31])
+	require.Equal(t, TooShortKeyMaterialError, err)
+	_, err = GenerateKeyFromSeed("MinAll", seed)
+	require.Equal(t, UnsupportedVariantError, err)
+}
+
+func TestAggregate(t *testing.T) {
+	num := 8
+	for _, variant := range variants {
+		keys, publicKeys := generateKeys(t, variant, num)
+
+		// 所有权证明
+		for i, key := range keys {
+			proof, err := PopProve(key)
+			require.NoError(t, err)
+			ok, err := PopVerify(variant, publicKeys[i], proof)
+			require.NoError(t, err)
+			require.True(t, ok, variant)
+
+			ok, err = PopVerify(variant, publicKeys[(i+1)%num], proof)
+			require.NoError(t, err)
+			require.False(t, ok, variant)
+		}
+
+		// 同一条消息上的聚合签名
+		message := []byte("attestation of epoch 7")
+		sigs := make([][]byte, num)
+		for i, key := range keys {
+			sig, err := Sign(key, message)
+			require.NoError(t, err)
+			sigs[i] = sig
+		}
+		aggregated, err := AggregateSignatures(sigs)
+		require.NoError(t, err)
+		ok, err := FastAggregateVerify(publicKeys, sigContent(t, aggregated), message)
+		require.NoError(t, err)
+		require.True(t, ok, variant)
+		ok, err = FastAggregateVerify(publicKeys[1:], sigContent(t, aggregated), message)
+		require.NoError(t, err)
+		require.False(t, ok, variant)
+
+		// 聚合公钥与单个公钥的验证方式相同
+		aggregatedKey, err := AggregatePublicKeys(variant, publicKeys)
+		require.NoError(t, err)
+		ok, err = Verify(aggregatedKey, sigContent(t, aggregated), message)
+		require.NoError(t, err)
+		require.True(t, ok, variant)
+
+		// 不同消息上的聚合签名
+		messages := make([][]byte, num)
+		for i, key := range keys {
+			messages[i] = []byte(fmt.Sprintf("message %d", i))
+			sig, err := Sign(key, messages[i])
+			require.NoError(t, err)
+			sigs[i] = sig
+		}
+		aggregated, err = AggregateSignatures(sigs)
+		require.NoError(t, err)
+		ok, err = AggregateVerify(publicKeys, sigContent(t, aggregated), messages)
+		require.NoError(t, err)
+		require.True(t, ok, variant)
+		messages[0], messages[1] = messages[1], messages[0]
+		ok, err = AggregateVerify(publicKeys, sigContent(t, aggregated), messages)
+		require.NoError(t, err)
+		require.False(t, ok, variant)
+	}
+
+	minPkKey, err := GenerateKey(MinPk)
+	require.NoError(t, err)
+	minSigKey, err := GenerateKey(MinSig)
+	require.NoError(t, err)
+	sig1, err := Sign(minPkKey, []byte("m"))
+	require.NoError(t, err)
+	sig2, err := Sign(minSigKey, []byte("m"))
+	require.NoError(t, err)
+	_, err = AggregateSignatures([][]byte{sig1, sig2})
+	require.Equal(t, VariantNotMatchError, err)
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// EIP-2333 中derive_master_SK的测试向量，其即为draft-irtf-cfrg-bls-signature-04的KeyGen（key_info为空）
+func TestKeyGenVectors(t *testing.T) {
+	vectors := []struct {
+		seed string
+		sk   string
+	}{
+		{
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			"6083874454709270928345386274498605044986640685124978867557563392430687146096",
+		},
+		{
+			"3141592653589793238462643383279502884197169399375105820974944592",
+			"29757020647961307431480504535336562678282505419141012933316116377660817309383",
+		},
+		{
+			"0099ff991111002299dd7744ee3355bbdd8844115566cc55663355668888cc00",
+			"27580842291869792442942448775674722299803720648445448686099262467207037398656",
+		},
+	}
+	for _, variant := range variants {
+		for _, v := range vectors {
+			key, err := GenerateKeyFromSeed(variant, mustDecodeHex(t, v.seed))
+			require.NoError(t, err)
+			require.Equal(t, v.sk, key.X.String())
+		}
+	}
+}
+
+// 以太坊共识层的BLS测试向量，使用与MinPk相同的密码套件 BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_
+var minPkVectorKeys = []struct {
+	sk string
+	pk string
+}{
+	{"263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3", "a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a"},
+	{"47b8192d77bf871b62e87859d653922725724a5c031afeabc60bcef5ff665138", "b301803f8b5ac4a1133581fc676dfedc60d891dd5fa99028805e5ea5b08d3491af75d0707adab3b70c6a6a580217bf81"},
+	{"328388aff0d4a5b7dc9205abd374e7e98f3cd9f3418edb4eafda5fb16473d216", "b53d21a4cfd562c469cc81514d4ce5a6b577d8403d32a394dc265dd190b47fa9f829fdd7963afdf972e5e77854051f6f"},
+}
+
+func TestMinPkVectors(t *testing.T) {
+	keys := make([]*PrivateKey, len(minPkVectorKeys))
+	publicKeys := make([][]byte, len(minPkVectorKeys))
+	for i, v := range minPkVectorKeys {
+		x, ok := new(big.Int).SetString(v.sk, 16)
+		require.True(t, ok)
+		keys[i] = &PrivateKey{Variant: MinPk, X: x}
+		publicKeys[i] = keys[i].PublicKey()
+		require.Equal(t, v.pk, hex.EncodeToString(publicKeys[i]))
+	}
+
+	// Sign
+	zero := make([]byte, 32)
+	expectedSigs := []string{
+		"b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55",
+		"b23c46be3a001c63ca711f87a005c200cc550b9429d5f4eb38d74322144f1b63926da3388979e5321012fb1a0526bcd100b5ef5fe72628ce4cd5e904aeaa3279527843fae5ca9ca675f4f51ed8f83bbf7155da9ecc9663100a885d5dc6df96d9",
+		"948a7cb99f76d616c2c564ce9bf4a519f1bea6b0a624a02276443c245854219fabb8d4ce061d255af5330b078d5380681751aa7053da2c98bae898edc218c75f07e24d8802a17cd1f6833b71e58f5eb5b94208b4d0bb3848cecb075ea21be115",
+	}
+	for i, key := range keys {
+		sig, err := Sign(key, zero)
+		require.NoError(t, err)
+		s, err := unmarshalSignature(sigContent(t, sig))
+		require.NoError(t, err)
+		require.Equal(t, expectedSigs[i], hex.EncodeToString(s.Signature))
+
+		ok, err := Verify(publicKeys[i], sigContent(t, sig), zero)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// Aggregate与FastAggregateVerify：三个私钥对同一条消息签名
+	message := bytes.Repeat([]byte{0xab}, 32)
+	sigs := make([][]byte, len(keys))
+	for i, key := range keys {
+		sig, err := Sign(key, message)
+		require.NoError(t, err)
+		sigs[i] = sig
+	}
+	aggregated, err := AggregateSignatures(sigs)
+	require.NoError(t, err)
+	s, err := unmarshalSignature(sigContent(t, aggregated))
+	require.NoError(t, err)
+	require.Equal(t, "9712c3edd73a209c742b8250759db12549b3eaf43b5ca61376d9f30e2747dbcf842d8b2ac0901d2a093713e20284a7670fcf6954e9ab93de991bb9b313e664785a075fc285806fa5224c82bde146561b446ccfc706a64b8579513cfc4ff1d930", hex.EncodeToString(s.Signature))
+	ok, err := FastAggregateVerify(publicKeys, sigContent(t, aggregated), message)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// AggregateVerify：三个私钥分别对不同的消息签名
+	messages := [][]byte{zero, bytes.Repeat([]byte{0x56}, 32), message}
+	sigBytes := mustDecodeHex(t, "9104e74b9dfd3ad502f25d6a5ef57db0ed7d9a0e00f3500586d8ce44231212542fcfaf87840539b398bf07626705cf1105d246ca1062c6c2e1a53029a0f790ed5e3cb1f52f8234dc5144c45fc847c0cd37a92d68e7c5ba7c648a8a339f171244")
+	aggregated, err = marshalSignature(MinPk, sigBytes)
+	require.NoError(t, err)
+	ok, err = AggregateVerify(publicKeys, sigContent(t, aggregated), messages)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = AggregateVerify(publicKeys, sigContent(t, aggregated), [][]byte{message, messages[1], zero})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+// 私钥为1时签名即为hash_to_curve的结果，使用RFC 9380附录J的向量分别检查
+// MinSig（签名位于G1，BLS12381G1_XMD:SHA-256_SSWU_RO_）与MinPk（签名位于G2，BLS12381G2_XMD:SHA-256_SSWU_RO_）的签名计算
+func TestHashToCurveVectors(t *testing.T) {
+	one := big.NewInt(1)
+
+	g1Vectors := []struct {
+		msg  string
+		x, y string
+	}{
+		{
+			"",
+			"052926add2207b76ca4fa57a8734416c8dc95e24501772c814278700eed6d1e4e8cf62d9c09db0fac349612b759e79a1",
+			"08ba738453bfed09cb546dbb0783dbb3a5f1f566ed67bb6be0e8c67e2e81a4cc68ee29813bb7994998f3eae0c9c6a265",
+		},
+		{
+			"abc",
+			"03567bc5ef9c690c2ab2ecdf6a96ef1c139cc0b2f284dca0a9a7943388a49a3aee664ba5379a7655d3c68900be2f6903",
+			"0b9c15f3fe6e5cf4211f346271d7b01c8f3b28be689c8429c85b67af215533311f0b8dfaaa154fa6b88176c229f2885d",
+		},
+	}
+	for _, v := range g1Vectors {
+		sig, err := coreSign(MinSig, one, []byte(v.msg), "QUUX-V01-CS02-with-BLS12381G1_XMD:SHA-256_SSWU_RO_")
+		require.NoError(t, err)
+		var p bls12381.G1Affine
+		_, err = p.SetBytes(sig)
+		require.NoError(t, err)
+		x, y := p.X.Bytes(), p.Y.Bytes()
+		require.Equal(t, v.x, hex.EncodeToString(x[:]))
+		require.Equal(t, v.y, hex.EncodeToString(y[:]))
+	}
+
+	sig, err := coreSign(MinPk, one, []byte{}, "QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	require.NoError(t, err)
+	var p bls12381.G2Affine
+	_, err = p.SetBytes(sig)
+	require.NoError(t, err)
+	x0, x1 := p.X.A0.Bytes(), p.X.A1.Bytes()
+	require.Equal(t, "0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a", hex.EncodeToString(x0[:]))
+	require.Equal(t, "05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d", hex.EncodeToString(x1[:]))
+}
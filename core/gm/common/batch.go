@@ -0,0 +1,42 @@
+package common
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelVerify 使用与CPU核数相同的goroutine并行执行verify(0), ..., verify(count-1)，
+// 返回验证失败的下标，按升序排列；全部通过时返回nil
+func ParallelVerify(count int, verify func(index int) bool) []int {
+	results := make([]bool, count)
+
+	workers := runtime.NumCPU()
+	if workers > count {
+		workers = count
+	}
+	indexes := make(chan int, count)
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = verify(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var invalid []int
+	for i, ok := range results {
+		if !ok {
+			invalid = append(invalid, i)
+		}
+	}
+	return invalid
+}
@@ -40,6 +40,14 @@ func unmarshalXuperSignature(rawSig []byte) (*XuperSignature, error) {
 	case SchnorrRing:
 	// 多重签名
 	case MultiSig:
+	// MuSig2多重签名
+	case MuSig2:
+	// 可链接环签名
+	case LinkableRing:
+	// BLS签名
+	case BLS:
+	// Ed25519签名
+	case Ed25519:
 	// 不支持的签名类型
 	default:
 		err = fmt.Errorf("This XuperSignature type[%v] is not supported in this version.", sig.SigType)
@@ -0,0 +1,363 @@
+package common
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+)
+
+// 超级签名的二进制编码：
+//   版本号(1字节) || 签名类型(1字节) || 签名内容的ASN.1 DER编码
+// 签名内容中的整数使用DER的最小编码，环签名中的点使用SEC1压缩格式，Ed25519签名编码为OCTET STRING，同一个签名只有唯一的编码。
+// 版本号不会与JSON格式（以'{'开头）以及ASN.1编码的ECDSA签名（以0x30开头）冲突，可以据此自动识别格式。
+
+// XuperSignatureBinaryVersion 当前二进制编码的版本号
+const XuperSignatureBinaryVersion = 0x01
+
+// 二进制编码中签名类型的取值
+const (
+	binaryTypeECDSA        = 0x01
+	binaryTypeSchnorr      = 0x02
+	binaryTypeSchnorrRing  = 0x03
+	binaryTypeMultiSig     = 0x04
+	binaryTypeMuSig2       = 0x05
+	binaryTypeLinkableRing = 0x06
+	binaryTypeBLS          = 0x07
+	binaryTypeEd25519      = 0x08
+)
+
+var binarySigTypes = map[string]byte{
+	ECDSA:        binaryTypeECDSA,
+	Schnorr:      binaryTypeSchnorr,
+	SchnorrRing:  binaryTypeSchnorrRing,
+	MultiSig:     binaryTypeMultiSig,
+	MuSig2:       binaryTypeMuSig2,
+	LinkableRing: binaryTypeLinkableRing,
+	BLS:          binaryTypeBLS,
+	Ed25519:      binaryTypeEd25519,
+}
+
+var (
+	UnsupportedBinaryVersionError = errors.New("The version of the binary XuperSignature is not supported")
+	UnsupportedBinarySigTypeError = errors.New("The XuperSignature type has no binary encoding")
+	NonCanonicalSignatureError    = errors.New("The binary XuperSignature is not canonically encoded")
+)
+
+// 各签名类型在二进制编码中的ASN.1结构
+type (
+	asn1ScalarPair struct {
+		A, B *big.Int
+	}
+
+	asn1RingSignature struct {
+		CurveName string `asn1:"utf8"`
+		Members   [][]byte
+		E         *big.Int
+		S         []*big.Int
+	}
+
+	asn1LinkableRingSignature struct {
+		CurveName string `asn1:"utf8"`
+		Members   [][]byte
+		KeyImage  []byte
+		C         *big.Int
+		S         []*big.Int
+	}
+
+	asn1MultiSignature struct {
+		S *big.Int
+		R []byte
+	}
+
+	asn1BLSSignature struct {
+		Variant   string `asn1:"utf8"`
+		Signature []byte
+	}
+)
+
+// IsBinaryXuperSignature 判断签名是否为二进制编码的超级签名
+func IsBinaryXuperSignature(data []byte) bool {
+	return len(data) > 0 && data[0] == XuperSignatureBinaryVersion
+}
+
+// MarshalXuperSignatureBinary 将超级签名编码为二进制格式，sig.SigContent为JSON格式的签名内容
+func MarshalXuperSignatureBinary(sig *XuperSignature) ([]byte, error) {
+	if sig == nil || len(sig.SigContent) == 0 {
+		return nil, InvalidInputParamsError
+	}
+	sigType, ok := binarySigTypes[sig.SigType]
+	if !ok {
+		return nil, UnsupportedBinarySigTypeError
+	}
+
+	// asn1.Marshal不支持指针，签名内容以值的形式传入
+	var content interface{}
+	switch sigType {
+	case binaryTypeECDSA:
+		ecdsaSig := new(ECDSASignature)
+		if err := json.Unmarshal(sig.SigContent, ecdsaSig); err != nil {
+			return nil, InValidSignatureError
+		}
+		content = asn1ScalarPair{A: ecdsaSig.R, B: ecdsaSig.S}
+	case binaryTypeSchnorr:
+		schnorrSig := new(SchnorrSignature)
+		if err := json.Unmarshal(sig.SigContent, schnorrSig); err != nil {
+			return nil, InValidSignatureError
+		}
+		content = asn1ScalarPair{A: schnorrSig.E, B: schnorrSig.S}
+	case binaryTypeSchnorrRing:
+		ringSig, err := ringSignatureToASN1(sig.SigContent)
+		if err != nil {
+			return nil, err
+		}
+		content = *ringSig
+	case binaryTypeLinkableRing:
+		ringSig, err := linkableRingSignatureToASN1(sig.SigContent)
+		if err != nil {
+			return nil, err
+		}
+		content = *ringSig
+	case binaryTypeMultiSig, binaryTypeMuSig2:
+		multiSig := new(MultiSignature)
+		if err := json.Unmarshal(sig.SigContent, multiSig); err != nil || len(multiSig.R) == 0 {
+			return nil, InValidSignatureError
+		}
+		content = asn1MultiSignature{S: new(big.Int).SetBytes(multiSig.S), R: multiSig.R}
+	case binaryTypeBLS:
+		blsSig := new(BLSSignature)
+		if err := json.Unmarshal(sig.SigContent, blsSig); err != nil {
+			return nil, InValidSignatureError
+		}
+		content = asn1BLSSignature{Variant: blsSig.Variant, Signature: blsSig.Signature}
+	case binaryTypeEd25519:
+		content = sig.SigContent
+	}
+
+	der, err := marshalASN1Content(content)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{XuperSignatureBinaryVersion, sigType}, der...), nil
+}
+
+// UnmarshalXuperSignatureBinary 解析二进制格式的超级签名，返回的SigContent为JSON格式，可以直接交给各签名算法验证。
+// 非规范的编码（例如非最小编码的整数、非压缩格式的点、多余的字节）会被拒绝
+func UnmarshalXuperSignatureBinary(data []byte) (*XuperSignature, error) {
+	if len(data) < 2 {
+		return nil, InValidSignatureError
+	}
+	if data[0] != XuperSignatureBinaryVersion {
+		return nil, UnsupportedBinaryVersionError
+	}
+
+	sig := new(XuperSignature)
+	der := data[2:]
+	var err error
+	switch data[1] {
+	case binaryTypeECDSA:
+		pair := new(asn1ScalarPair)
+		if err = unmarshalASN1Content(der, pair); err == nil {
+			sig.SigType = ECDSA
+			sig.SigContent, err = json.Marshal(&ECDSASignature{R: pair.A, S: pair.B})
+		}
+	case binaryTypeSchnorr:
+		pair := new(asn1ScalarPair)
+		if err = unmarshalASN1Content(der, pair); err == nil {
+			sig.SigType = Schnorr
+			sig.SigContent, err = json.Marshal(&SchnorrSignature{E: pair.A, S: pair.B})
+		}
+	case binaryTypeSchnorrRing:
+		ringSig := new(asn1RingSignature)
+		if err = unmarshalASN1Content(der, ringSig); err == nil {
+			sig.SigType = SchnorrRing
+			sig.SigContent, err = ringSignatureFromASN1(ringSig)
+		}
+	case binaryTypeLinkableRing:
+		ringSig := new(asn1LinkableRingSignature)
+		if err = unmarshalASN1Content(der, ringSig); err == nil {
+			sig.SigType = LinkableRing
+			sig.SigContent, err = linkableRingSignatureFromASN1(ringSig)
+		}
+	case binaryTypeMultiSig, binaryTypeMuSig2:
+		multiSig := new(asn1MultiSignature)
+		if err = unmarshalASN1Content(der, multiSig); err == nil {
+			sig.SigType = MultiSig
+			if data[1] == binaryTypeMuSig2 {
+				sig.SigType = MuSig2
+			}
+			sig.SigContent, err = json.Marshal(&MultiSignature{S: multiSig.S.Bytes(), R: multiSig.R})
+		}
+	case binaryTypeBLS:
+		blsSig := new(asn1BLSSignature)
+		if err = unmarshalASN1Content(der, blsSig); err == nil {
+			sig.SigType = BLS
+			sig.SigContent, err = json.Marshal(&BLSSignature{Variant: blsSig.Variant, Signature: blsSig.Signature})
+		}
+	case binaryTypeEd25519:
+		var ed25519Sig []byte
+		if err = unmarshalASN1Content(der, &ed25519Sig); err == nil {
+			sig.SigType = Ed25519
+			sig.SigContent = ed25519Sig
+		}
+	default:
+		return nil, UnsupportedBinarySigTypeError
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 重新编码后必须与输入完全一致，保证编码的唯一性
+	encoded, err := MarshalXuperSignatureBinary(sig)
+	if err != nil || !bytes.Equal(encoded, data) {
+		return nil, NonCanonicalSignatureError
+	}
+	return sig, nil
+}
+
+// ConvertXuperSignatureToBinary 将JSON格式的超级签名转换为二进制格式
+func ConvertXuperSignatureToBinary(signature []byte) ([]byte, error) {
+	sig := new(XuperSignature)
+	if err := json.Unmarshal(signature, sig); err != nil {
+		return nil, InValidSignatureError
+	}
+	return MarshalXuperSignatureBinary(sig)
+}
+
+// ParseXuperSignature 自动识别二进制与JSON格式，解析超级签名
+func ParseXuperSignature(signature []byte) (*XuperSignature, error) {
+	if IsBinaryXuperSignature(signature) {
+		return UnmarshalXuperSignatureBinary(signature)
+	}
+	sig := new(XuperSignature)
+	if err := json.Unmarshal(signature, sig); err != nil {
+		return nil, InValidSignatureError
+	}
+	return sig, nil
+}
+
+func marshalASN1Content(content interface{}) ([]byte, error) {
+	der, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, InValidSignatureError
+	}
+	return der, nil
+}
+
+func unmarshalASN1Content(der []byte, content interface{}) error {
+	rest, err := asn1.Unmarshal(der, content)
+	if err != nil {
+		return InValidSignatureError
+	}
+	if len(rest) != 0 {
+		return NonCanonicalSignatureError
+	}
+	return nil
+}
+
+func ringSignatureToASN1(sigContent []byte) (*asn1RingSignature, error) {
+	ringSig := new(RingSignature)
+	if err := json.Unmarshal(sigContent, ringSig); err != nil {
+		return nil, InValidSignatureError
+	}
+	members, err := compressPoints(ringSig.CurveName, ringSig.Members)
+	if err != nil {
+		return nil, err
+	}
+	return &asn1RingSignature{
+		CurveName: ringSig.CurveName,
+		Members:   members,
+		E:         ringSig.E,
+		S:         ringSig.S,
+	}, nil
+}
+
+func ringSignatureFromASN1(ringSig *asn1RingSignature) ([]byte, error) {
+	members, err := decompressPoints(ringSig.CurveName, ringSig.Members)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&RingSignature{
+		CurveName: ringSig.CurveName,
+		Members:   members,
+		E:         ringSig.E,
+		S:         ringSig.S,
+	})
+}
+
+func linkableRingSignatureToASN1(sigContent []byte) (*asn1LinkableRingSignature, error) {
+	ringSig := new(LinkableRingSignature)
+	if err := json.Unmarshal(sigContent, ringSig); err != nil || ringSig.KeyImage == nil {
+		return nil, InValidSignatureError
+	}
+	members, err := compressPoints(ringSig.CurveName, ringSig.Members)
+	if err != nil {
+		return nil, err
+	}
+	keyImage, err := compressPoints(ringSig.CurveName, []*PublicKeyFactor{ringSig.KeyImage})
+	if err != nil {
+		return nil, err
+	}
+	return &asn1LinkableRingSignature{
+		CurveName: ringSig.CurveName,
+		Members:   members,
+		KeyImage:  keyImage[0],
+		C:         ringSig.C,
+		S:         ringSig.S,
+	}, nil
+}
+
+func linkableRingSignatureFromASN1(ringSig *asn1LinkableRingSignature) ([]byte, error) {
+	members, err := decompressPoints(ringSig.CurveName, ringSig.Members)
+	if err != nil {
+		return nil, err
+	}
+	keyImage, err := decompressPoints(ringSig.CurveName, [][]byte{ringSig.KeyImage})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&LinkableRingSignature{
+		CurveName: ringSig.CurveName,
+		Members:   members,
+		KeyImage:  keyImage[0],
+		C:         ringSig.C,
+		S:         ringSig.S,
+	})
+}
+
+func compressPoints(curveName string, points []*PublicKeyFactor) ([][]byte, error) {
+	curve, err := ecc.CurveByName(curveName)
+	if err != nil {
+		return nil, InValidSignatureError
+	}
+	compressed := make([][]byte, len(points))
+	for i, point := range points {
+		if point == nil {
+			return nil, InValidSignatureError
+		}
+		compressed[i], err = ecc.MarshalPoint(curve, point.X, point.Y, ecc.PointFormatCompressed)
+		if err != nil {
+			return nil, InValidSignatureError
+		}
+	}
+	return compressed, nil
+}
+
+func decompressPoints(curveName string, data [][]byte) ([]*PublicKeyFactor, error) {
+	curve, err := ecc.CurveByName(curveName)
+	if err != nil {
+		return nil, InValidSignatureError
+	}
+	points := make([]*PublicKeyFactor, len(data))
+	for i, d := range data {
+		point, err := ecc.UnmarshalPoint(curve, d)
+		if err != nil {
+			return nil, InValidSignatureError
+		}
+		points[i] = &PublicKeyFactor{X: point.X, Y: point.Y}
+	}
+	return points, nil
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXuperSignatureBinary(t *testing.T) {
+	n := elliptic.P256().Params().N
+	e := new(big.Int).Sub(n, big.NewInt(12345))
+	s := new(big.Int).Sub(n, big.NewInt(67890))
+	sigContent, err := json.Marshal(&SchnorrSignature{E: e, S: s})
+	require.NoError(t, err)
+	legacy, err := json.Marshal(&XuperSignature{SigType: Schnorr, SigContent: sigContent})
+	require.NoError(t, err)
+
+	binary, err := ConvertXuperSignatureToBinary(legacy)
+	require.NoError(t, err)
+	require.True(t, IsBinaryXuperSignature(binary))
+	require.False(t, IsBinaryXuperSignature(legacy))
+	// 版本号、类型、SEQUENCE头以及两个33字节的INTEGER
+	require.Len(t, binary, 2+2+2*(2+33))
+	require.Less(t, 3*len(binary), len(legacy))
+
+	sig, err := ParseXuperSignature(binary)
+	require.NoError(t, err)
+	require.Equal(t, Schnorr, sig.SigType)
+	schnorrSig := new(SchnorrSignature)
+	require.NoError(t, json.Unmarshal(sig.SigContent, schnorrSig))
+	require.Equal(t, e, schnorrSig.E)
+	require.Equal(t, s, schnorrSig.S)
+
+	sig, err = ParseXuperSignature(legacy)
+	require.NoError(t, err)
+	require.Equal(t, sigContent, sig.SigContent)
+}
+
+func TestXuperSignatureBinaryCanonical(t *testing.T) {
+	sigContent, err := json.Marshal(&ECDSASignature{R: big.NewInt(1), S: big.NewInt(2)})
+	require.NoError(t, err)
+	binary, err := MarshalXuperSignatureBinary(&XuperSignature{SigType: ECDSA, SigContent: sigContent})
+	require.NoError(t, err)
+	require.Equal(t, []byte{XuperSignatureBinaryVersion, binaryTypeECDSA, 0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x02}, binary)
+
+	// 非最小编码的整数
+	_, err = UnmarshalXuperSignatureBinary([]byte{XuperSignatureBinaryVersion, binaryTypeECDSA, 0x30, 0x07, 0x02, 0x02, 0x00, 0x01, 0x02, 0x01, 0x02})
+	require.Error(t, err)
+	// 多余的字节
+	_, err = UnmarshalXuperSignatureBinary(append(append([]byte{}, binary...), 0x00))
+	require.Equal(t, NonCanonicalSignatureError, err)
+	// 未知的版本与签名类型
+	_, err = UnmarshalXuperSignatureBinary(append([]byte{0x02}, binary[1:]...))
+	require.Equal(t, UnsupportedBinaryVersionError, err)
+	_, err = UnmarshalXuperSignatureBinary(append([]byte{XuperSignatureBinaryVersion, 0x7f}, binary[2:]...))
+	require.Equal(t, UnsupportedBinarySigTypeError, err)
+
+	_, err = MarshalXuperSignatureBinary(&XuperSignature{SigType: "Unknown", SigContent: sigContent})
+	require.Equal(t, UnsupportedBinarySigTypeError, err)
+}
+
+func TestXuperSignatureBinaryRingPoints(t *testing.T) {
+	curve := elliptic.P256()
+	params := curve.Params()
+	sigContent, err := json.Marshal(&RingSignature{
+		CurveName: params.Name,
+		Members:   []*PublicKeyFactor{{X: params.Gx, Y: params.Gy}},
+		E:         big.NewInt(3),
+		S:         []*big.Int{big.NewInt(4)},
+	})
+	require.NoError(t, err)
+	binary, err := MarshalXuperSignatureBinary(&XuperSignature{SigType: SchnorrRing, SigContent: sigContent})
+	require.NoError(t, err)
+
+	sig, err := UnmarshalXuperSignatureBinary(binary)
+	require.NoError(t, err)
+	ringSig := new(RingSignature)
+	require.NoError(t, json.Unmarshal(sig.SigContent, ringSig))
+	require.Equal(t, params.Gx, ringSig.Members[0].X)
+	require.Equal(t, params.Gy, ringSig.Members[0].Y)
+
+	// 成员不在曲线上
+	sigContent, err = json.Marshal(&RingSignature{
+		CurveName: params.Name,
+		Members:   []*PublicKeyFactor{{X: params.Gx, Y: big.NewInt(1)}},
+		E:         big.NewInt(3),
+		S:         []*big.Int{big.NewInt(4)},
+	})
+	require.NoError(t, err)
+	_, err = MarshalXuperSignatureBinary(&XuperSignature{SigType: SchnorrRing, SigContent: sigContent})
+	require.Equal(t, InValidSignatureError, err)
+}
@@ -14,6 +14,14 @@ const (
 	SchnorrRing = "SchnorrRing"
 	// 多重签名算法
 	MultiSig = "MultiSig"
+	// MuSig2多重签名算法，公钥聚合可以抵抗rogue-key攻击
+	MuSig2 = "MuSig2"
+	// 可链接环签名算法（LSAG），同一私钥产生的签名可以被链接
+	LinkableRing = "LinkableRing"
+	// BLS12-381上的BLS签名算法，支持签名与公钥聚合
+	BLS = "BLS"
+	// Ed25519签名算法（RFC 8032），SigContent为64字节的签名
+	Ed25519 = "Ed25519"
 )
 
 // --- 签名数据结构相关 start ---
@@ -34,6 +42,13 @@ type SchnorrSignature struct {
 	E, S *big.Int
 }
 
+// Schnorr适配器签名（预签名），锁定在点T = t*G上，S加上t之后得到完整的Schnorr签名
+type AdaptorSignature struct {
+	CurveName string
+	T         *PublicKeyFactor
+	E, S      *big.Int
+}
+
 // --- Schnorr环签名的数据结构定义 start ---
 
 type PublicKeyFactor struct {
@@ -48,12 +63,27 @@ type RingSignature struct {
 	S         []*big.Int
 }
 
+// 可链接环签名，KeyImage = x*Hp(P)，同一私钥的所有签名具有相同的KeyImage
+type LinkableRingSignature struct {
+	CurveName string
+	Members   []*PublicKeyFactor
+	KeyImage  *PublicKeyFactor
+	C         *big.Int
+	S         []*big.Int
+}
+
 // --- Schnorr环签名的数据结构定义 end ---
 
-// 多重签名
+// 多重签名，MuSig2签名同样使用该结构，其中R为压缩格式的点
 type MultiSignature struct {
 	S []byte
 	R []byte
 }
 
+// BLS签名，Variant区分公钥位于G1（MinPk）还是G2（MinSig），Signature为压缩格式的点，可以是聚合签名
+type BLSSignature struct {
+	Variant   string
+	Signature []byte
+}
+
 // --- 签名数据结构相关 end ---
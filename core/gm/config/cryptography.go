@@ -8,6 +8,8 @@ const (
 	Nist // = 1
 	// 国密
 	Gm // = 2
+	// Ed25519签名与X25519密钥交换
+	Ed25519 // = 3
 )
 
 // 定义创建账户时产生的助记词中的标记符的值，及其所对应的预留标记位的类型
@@ -26,4 +28,6 @@ const (
 	CurveNist = "P-256"
 	// 国密椭圆曲线
 	CurveGm = "SM2-P-256"
+	// Ed25519使用的扭曲爱德华曲线
+	CurveEd25519 = "Ed25519"
 )
@@ -0,0 +1,409 @@
+package dkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/common/math/polynomial"
+)
+
+// 分布式密钥生成（Pedersen DKG，使用Feldman VSS），参考RFC 9591附录C以及FROST论文
+//
+// 参与者的标识为1..n，门限为t：
+// Round 1: 参与者i随机选择t-1次多项式f_i(x) = a_i0 + a_i1*x + ... + a_i(t-1)*x^(t-1)，
+// 广播承诺C_ik = a_ik*G，以及对a_i0的知识证明（防止rogue-key攻击）
+// Round 2: 验证其他参与者的知识证明后，通过秘密信道将f_i(j)发送给参与者j
+// Finalize: 参与者j验证 f_i(j)*G == Σ C_ik * j^k，计算私钥份额 x_j = Σ f_i(j)，
+// 公共公钥 Y = Σ C_i0，以及每个参与者的验证公钥 Y_k = x_k*G
+//
+// 群私钥 x = Σ a_i0 任何人都不知道，任意t个参与者可以通过拉格朗日插值共同使用它
+// Round2Package包含私钥份额，调用方需要自行保证其传输的机密性
+
+var (
+	InvalidInputParamsError      = errors.New("Invalid input params")
+	InvalidThresholdError        = errors.New("The threshold should be in the range of [2, total]")
+	InvalidIdentifierError       = errors.New("The identifier should be in the range of [1, total]")
+	DuplicateIdentifierError     = errors.New("The identifier appears more than once")
+	MissingPackageError          = errors.New("The packages of some participants are missing")
+	RoundStateError              = errors.New("The operation is not allowed in the current round")
+	InvalidProofOfKnowledgeError = errors.New("The proof of knowledge of the secret is invalid")
+	InvalidCommitmentsError      = errors.New("The commitments of the polynomial are invalid")
+	UnsupportedCurveError        = errors.New("The curve is not supported yet")
+	NotEnoughIdentifiersError    = errors.New("The number of identifiers is less than the threshold")
+)
+
+// 参与者的轮次
+const (
+	roundOne = iota
+	roundTwo
+	roundFinished
+	roundDone
+)
+
+const proofContextPrefix = "cu_crypto/dkg/"
+
+// Round1Package 第一轮广播的消息
+type Round1Package struct {
+	Identifier int
+	// 多项式系数的承诺C_ik，压缩格式
+	Commitments [][]byte
+	// 对a_i0的Schnorr知识证明
+	Proof []byte
+}
+
+// Round2Package 第二轮通过秘密信道发送的私钥份额f_i(j)
+type Round2Package struct {
+	From  int
+	To    int
+	Share []byte
+}
+
+// PublicKeyPackage DKG产生的公开信息，签名的协调者与验证者只需要这部分
+type PublicKeyPackage struct {
+	Curve     elliptic.Curve
+	Threshold int
+	Total     int
+	// 群公钥 Y = x*G
+	GroupPublicKey *ecdsa.PublicKey
+	// 每个参与者的验证公钥 Y_k = x_k*G
+	VerificationShares map[int]*ecdsa.PublicKey
+}
+
+// KeyShare 参与者的私钥份额以及公开信息
+type KeyShare struct {
+	Identifier  int
+	SecretShare *big.Int
+	*PublicKeyPackage
+}
+
+// Participant DKG中单个参与者的状态
+type Participant struct {
+	curve      elliptic.Curve
+	field      *polynomial.Field
+	identifier int
+	threshold  int
+	total      int
+	context    []byte
+
+	coefficients polynomial.Polynomial
+	commitments  [][]*ecc.Point
+	round        int
+}
+
+// NewParticipant 创建DKG的参与者，context用于区分不同的DKG会话，所有参与者需要一致
+func NewParticipant(curve elliptic.Curve, identifier, threshold, total int, context []byte) (*Participant, error) {
+	if curve == nil {
+		return nil, InvalidInputParamsError
+	}
+	if _, err := ecc.CurveByName(curve.Params().Name); err != nil {
+		return nil, UnsupportedCurveError
+	}
+	if threshold < 2 || threshold > total {
+		return nil, InvalidThresholdError
+	}
+	if identifier < 1 || identifier > total {
+		return nil, InvalidIdentifierError
+	}
+
+	field, err := polynomial.NewField(curve.Params().N)
+	if err != nil {
+		return nil, err
+	}
+	coefficients, err := field.Random(threshold-1, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 常数项即本参与者贡献的秘密，不能为0
+	for coefficients[0].Sign() == 0 {
+		coefficients[0], err = field.RandomElement()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p := &Participant{
+		curve:        curve,
+		field:        field,
+		identifier:   identifier,
+		threshold:    threshold,
+		total:        total,
+		context:      append([]byte{}, context...),
+		coefficients: coefficients,
+		commitments:  make([][]*ecc.Point, total+1),
+		round:        roundOne,
+	}
+	return p, nil
+}
+
+// Identifier 返回参与者的标识
+func (p *Participant) Identifier() int {
+	return p.identifier
+}
+
+// Round1 生成需要广播的多项式承诺以及知识证明
+func (p *Participant) Round1() (*Round1Package, error) {
+	if p.round != roundOne {
+		return nil, RoundStateError
+	}
+
+	commitments := make([]*ecc.Point, len(p.coefficients))
+	encoded := make([][]byte, len(p.coefficients))
+	for k, a := range p.coefficients {
+		commitments[k] = ecc.ScalarBaseMult(p.curve, a)
+		data, err := commitments[k].MarshalCompressed()
+		if err != nil {
+			return nil, err
+		}
+		encoded[k] = data
+	}
+	p.commitments[p.identifier] = commitments
+
+	proof, err := ecc.ProveDiscreteLog(p.curve, p.coefficients[0], nil, p.proofContext(p.identifier))
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	p.round = roundTwo
+	return &Round1Package{
+		Identifier:  p.identifier,
+		Commitments: encoded,
+		Proof:       proofBytes,
+	}, nil
+}
+
+// Round2 验证其他参与者的第一轮消息，返回需要分别发送给其他参与者的私钥份额
+func (p *Participant) Round2(packages []*Round1Package) ([]*Round2Package, error) {
+	if p.round != roundTwo {
+		return nil, RoundStateError
+	}
+
+	received := make(map[int]bool)
+	for _, pkg := range packages {
+		if pkg == nil {
+			return nil, InvalidInputParamsError
+		}
+		if pkg.Identifier == p.identifier {
+			continue
+		}
+		if pkg.Identifier < 1 || pkg.Identifier > p.total {
+			return nil, InvalidIdentifierError
+		}
+		if received[pkg.Identifier] {
+			return nil, DuplicateIdentifierError
+		}
+
+		commitments, err := p.verifyRound1Package(pkg)
+		if err != nil {
+			return nil, err
+		}
+		p.commitments[pkg.Identifier] = commitments
+		received[pkg.Identifier] = true
+	}
+	if len(received) != p.total-1 {
+		return nil, MissingPackageError
+	}
+
+	shares := make([]*Round2Package, 0, p.total-1)
+	for j := 1; j <= p.total; j++ {
+		if j == p.identifier {
+			continue
+		}
+		share := p.field.Evaluate(p.coefficients, big.NewInt(int64(j)))
+		shares = append(shares, &Round2Package{
+			From:  p.identifier,
+			To:    j,
+			Share: ecc.ScalarBytes(p.curve, share),
+		})
+	}
+
+	p.round = roundFinished
+	return shares, nil
+}
+
+// Finalize 验证收到的私钥份额并计算最终的私钥份额、群公钥以及验证公钥
+func (p *Participant) Finalize(packages []*Round2Package) (*KeyShare, error) {
+	if p.round != roundFinished {
+		return nil, RoundStateError
+	}
+
+	n := p.curve.Params().N
+	secret := p.field.Evaluate(p.coefficients, big.NewInt(int64(p.identifier)))
+	received := make(map[int]bool)
+	for _, pkg := range packages {
+		if pkg == nil || pkg.To != p.identifier {
+			return nil, InvalidInputParamsError
+		}
+		if pkg.From < 1 || pkg.From > p.total || pkg.From == p.identifier {
+			return nil, InvalidIdentifierError
+		}
+		if received[pkg.From] {
+			return nil, DuplicateIdentifierError
+		}
+
+		share := new(big.Int).SetBytes(pkg.Share)
+		if share.Cmp(n) >= 0 || !VerifyShare(p.curve, p.identifier, share, p.commitments[pkg.From]) {
+			return nil, fmt.Errorf("The secret share from participant %d is invalid", pkg.From)
+		}
+		secret.Add(secret, share)
+		received[pkg.From] = true
+	}
+	if len(received) != p.total-1 {
+		return nil, MissingPackageError
+	}
+	secret.Mod(secret, n)
+
+	publicKeyPackage, err := p.publicKeyPackage()
+	if err != nil {
+		return nil, err
+	}
+
+	// 清除多项式系数，此后本参与者的私有信息只剩下私钥份额
+	for _, a := range p.coefficients {
+		a.SetInt64(0)
+	}
+	p.coefficients = nil
+	p.round = roundDone
+
+	return &KeyShare{
+		Identifier:       p.identifier,
+		SecretShare:      secret,
+		PublicKeyPackage: publicKeyPackage,
+	}, nil
+}
+
+// VerifyShare Feldman VSS：验证 share*G == Σ C_k * identifier^k
+func VerifyShare(curve elliptic.Curve, identifier int, share *big.Int, commitments []*ecc.Point) bool {
+	if share == nil || len(commitments) == 0 {
+		return false
+	}
+	lhs := ecc.ScalarBaseMult(curve, share)
+	return lhs.Equals(evaluateCommitments(curve, commitments, identifier))
+}
+
+// LagrangeCoefficient 计算参与者identifier在x=0处的拉格朗日系数，identifiers为参与签名的全部参与者
+func LagrangeCoefficient(curve elliptic.Curve, identifiers []int, identifier int) (*big.Int, error) {
+	field, err := polynomial.NewField(curve.Params().N)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	xs := make([]*big.Int, len(identifiers))
+	for i, id := range identifiers {
+		if id < 1 {
+			return nil, InvalidIdentifierError
+		}
+		if id == identifier {
+			index = i
+		}
+		xs[i] = big.NewInt(int64(id))
+	}
+	if index < 0 {
+		return nil, InvalidIdentifierError
+	}
+
+	coefficients, err := field.LagrangeCoefficients(xs, big.NewInt(0))
+	if err != nil {
+		return nil, DuplicateIdentifierError
+	}
+	return coefficients[index], nil
+}
+
+// SortedIdentifiers 检查标识不重复且满足门限，并按升序返回
+func (pkg *PublicKeyPackage) SortedIdentifiers(identifiers []int) ([]int, error) {
+	if len(identifiers) < pkg.Threshold {
+		return nil, NotEnoughIdentifiersError
+	}
+	sorted := append([]int{}, identifiers...)
+	sort.Ints(sorted)
+	for i, id := range sorted {
+		if _, exist := pkg.VerificationShares[id]; !exist {
+			return nil, InvalidIdentifierError
+		}
+		if i > 0 && sorted[i-1] == id {
+			return nil, DuplicateIdentifierError
+		}
+	}
+	return sorted, nil
+}
+
+func (p *Participant) verifyRound1Package(pkg *Round1Package) ([]*ecc.Point, error) {
+	if len(pkg.Commitments) != p.threshold {
+		return nil, InvalidCommitmentsError
+	}
+
+	commitments := make([]*ecc.Point, len(pkg.Commitments))
+	for k, data := range pkg.Commitments {
+		point, err := ecc.UnmarshalPoint(p.curve, data)
+		if err != nil {
+			return nil, InvalidCommitmentsError
+		}
+		commitments[k] = point
+	}
+
+	proof, err := ecc.UnmarshalSchnorrProof(p.curve, pkg.Proof)
+	if err != nil {
+		return nil, InvalidProofOfKnowledgeError
+	}
+	if !ecc.VerifyDiscreteLog(commitments[0], nil, proof, p.proofContext(pkg.Identifier)) {
+		return nil, InvalidProofOfKnowledgeError
+	}
+	return commitments, nil
+}
+
+func (p *Participant) publicKeyPackage() (*PublicKeyPackage, error) {
+	groupKey := ecc.Infinity(p.curve)
+	for i := 1; i <= p.total; i++ {
+		groupKey = ecc.AddPoints(groupKey, p.commitments[i][0])
+	}
+	if groupKey.IsInfinity() {
+		return nil, InvalidCommitmentsError
+	}
+
+	verificationShares := make(map[int]*ecdsa.PublicKey, p.total)
+	for k := 1; k <= p.total; k++ {
+		share := ecc.Infinity(p.curve)
+		for i := 1; i <= p.total; i++ {
+			share = ecc.AddPoints(share, evaluateCommitments(p.curve, p.commitments[i], k))
+		}
+		verificationShares[k] = &ecdsa.PublicKey{Curve: p.curve, X: share.X, Y: share.Y}
+	}
+
+	return &PublicKeyPackage{
+		Curve:              p.curve,
+		Threshold:          p.threshold,
+		Total:              p.total,
+		GroupPublicKey:     &ecdsa.PublicKey{Curve: p.curve, X: groupKey.X, Y: groupKey.Y},
+		VerificationShares: verificationShares,
+	}, nil
+}
+
+// proofContext 知识证明绑定会话与参与者标识，防止证明被其他参与者重放
+func (p *Participant) proofContext(identifier int) []byte {
+	ctx := []byte(proofContextPrefix + strconv.Itoa(p.threshold) + "/" + strconv.Itoa(p.total) + "/" + strconv.Itoa(identifier) + "/")
+	return append(ctx, p.context...)
+}
+
+// evaluateCommitments 计算 Σ C_k * x^k
+func evaluateCommitments(curve elliptic.Curve, commitments []*ecc.Point, x int) *ecc.Point {
+	n := curve.Params().N
+	scalars := make([]*big.Int, len(commitments))
+	power := big.NewInt(1)
+	bigX := big.NewInt(int64(x))
+	for k := range commitments {
+		scalars[k] = new(big.Int).Set(power)
+		power.Mul(power, bigX)
+		power.Mod(power, n)
+	}
+	return ecc.MultiScalarMult(curve, scalars, commitments)
+}
@@ -0,0 +1,113 @@
+package dkg
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+// runDKG 在本地模拟所有参与者完成一次DKG
+func runDKG(t *testing.T, curve elliptic.Curve, threshold, total int) []*KeyShare {
+	participants := make([]*Participant, total)
+	round1 := make([]*Round1Package, total)
+	for i := range participants {
+		p, err := NewParticipant(curve, i+1, threshold, total, []byte("dkg test"))
+		require.NoError(t, err)
+		participants[i] = p
+		round1[i], err = p.Round1()
+		require.NoError(t, err)
+	}
+
+	inbox := make(map[int][]*Round2Package)
+	for _, p := range participants {
+		packages, err := p.Round2(round1)
+		require.NoError(t, err)
+		for _, pkg := range packages {
+			inbox[pkg.To] = append(inbox[pkg.To], pkg)
+		}
+	}
+
+	keyShares := make([]*KeyShare, total)
+	for i, p := range participants {
+		keyShare, err := p.Finalize(inbox[p.Identifier()])
+		require.NoError(t, err)
+		keyShares[i] = keyShare
+	}
+	return keyShares
+}
+
+func TestDKG(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		keyShares := runDKG(t, curve, 3, 5)
+
+		for _, keyShare := range keyShares {
+			require.Equal(t, keyShares[0].GroupPublicKey.X, keyShare.GroupPublicKey.X)
+			require.Equal(t, keyShares[0].GroupPublicKey.Y, keyShare.GroupPublicKey.Y)
+
+			vx, vy := curve.ScalarBaseMult(keyShare.SecretShare.Bytes())
+			verificationShare := keyShares[0].VerificationShares[keyShare.Identifier]
+			require.Equal(t, vx, verificationShare.X)
+			require.Equal(t, vy, verificationShare.Y)
+		}
+
+		// 任意3个私钥份额插值得到的群私钥对应群公钥
+		for _, identifiers := range [][]int{{1, 2, 3}, {2, 4, 5}, {1, 3, 5}} {
+			x := new(big.Int)
+			for _, id := range identifiers {
+				lambda, err := LagrangeCoefficient(curve, identifiers, id)
+				require.NoError(t, err)
+				x.Add(x, new(big.Int).Mul(lambda, keyShares[id-1].SecretShare))
+			}
+			x.Mod(x, curve.Params().N)
+			gx, gy := curve.ScalarBaseMult(x.Bytes())
+			require.Equal(t, keyShares[0].GroupPublicKey.X, gx)
+			require.Equal(t, keyShares[0].GroupPublicKey.Y, gy)
+		}
+	}
+}
+
+func TestDKGRejectsInvalidPackages(t *testing.T) {
+	curve := sm2.P256Sm2()
+	_, err := NewParticipant(curve, 1, 1, 3, nil)
+	require.Equal(t, InvalidThresholdError, err)
+	_, err = NewParticipant(curve, 4, 2, 3, nil)
+	require.Equal(t, InvalidIdentifierError, err)
+
+	participants := make([]*Participant, 3)
+	round1 := make([]*Round1Package, 3)
+	for i := range participants {
+		participants[i], err = NewParticipant(curve, i+1, 2, 3, []byte("dkg test"))
+		require.NoError(t, err)
+		round1[i], err = participants[i].Round1()
+		require.NoError(t, err)
+	}
+
+	// 知识证明绑定了参与者标识，不能被其他参与者重放
+	replayed := *round1[2]
+	replayed.Proof = round1[1].Proof
+	_, err = participants[0].Round2([]*Round1Package{round1[1], &replayed})
+	require.Equal(t, InvalidProofOfKnowledgeError, err)
+
+	_, err = participants[0].Round2([]*Round1Package{round1[1]})
+	require.Equal(t, MissingPackageError, err)
+
+	shares, err := participants[1].Round2(round1)
+	require.NoError(t, err)
+	_, err = participants[0].Round2(round1)
+	require.NoError(t, err)
+
+	// 篡改发送给参与者1的私钥份额
+	var toFirst *Round2Package
+	for _, pkg := range shares {
+		if pkg.To == 1 {
+			toFirst = pkg
+		}
+	}
+	toFirst.Share[len(toFirst.Share)-1] ^= 1
+	_, err = participants[0].Finalize([]*Round2Package{toFirst})
+	require.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package ecies
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm4"
+	"github.com/legendzhouwd/cu_crypto/core/gm/kdf"
+)
+
+// 基于SM2与SM4-GCM的混合加密，适用于较长的消息：
+// 1. 发送方生成临时SM2密钥对(r, C1 = r*G)，计算共享点 (x2, y2) = r*接收方公钥
+// 2. 使用SM2的KDF（GB/T 32918.4，基于SM3）从 x2 || y2 派生SM4密钥(16字节)与GCM的nonce(12字节)
+// 3. 密文为 C1(65字节，未压缩格式) || SM4-GCM密文，C1作为GCM的附加认证数据
+// 每次加密使用新的临时密钥，派生出的密钥与nonce不会重复。
+
+const (
+	sm4PointSize    = 65
+	sm4NonceSize    = 12
+	sm4TagSize      = 16
+	sm4MaterialSize = sm4.KeySize + sm4NonceSize
+)
+
+// EncryptWithSM4 使用接收方的SM2公钥加密，对称加密使用SM4-GCM
+func EncryptWithSM4(k *ecdsa.PublicKey, msg []byte) (cypherText []byte, err error) {
+	if !checkKeyCurve(k) {
+		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
+	}
+	curve := sm2.P256Sm2()
+	if !curve.IsOnCurve(k.X, k.Y) {
+		return nil, fmt.Errorf("The public key is not on the curve.")
+	}
+
+	ephemeral, err := sm2.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	c1 := elliptic.Marshal(curve, ephemeral.X, ephemeral.Y)
+	x2, y2 := curve.ScalarMult(k.X, k.Y, ephemeral.D.Bytes())
+
+	material, err := sm4KDF(x2, y2)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sm4.NewGCM(material[:sm4.KeySize])
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(c1, material[sm4.KeySize:], msg, c1), nil
+}
+
+// DecryptWithSM4 使用接收方的SM2私钥解密EncryptWithSM4生成的密文
+func DecryptWithSM4(k *ecdsa.PrivateKey, cypherText []byte) (msg []byte, err error) {
+	if !checkKeyCurve(&k.PublicKey) {
+		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
+	}
+	if k.D == nil {
+		return nil, fmt.Errorf("Param D cannot be nil.")
+	}
+	if len(cypherText) < sm4PointSize+sm4TagSize {
+		return nil, fmt.Errorf("The cypher text is invalid.")
+	}
+	curve := sm2.P256Sm2()
+	c1 := cypherText[:sm4PointSize]
+	x1, y1 := elliptic.Unmarshal(curve, c1)
+	if x1 == nil {
+		return nil, fmt.Errorf("The cypher text is invalid.")
+	}
+	x2, y2 := curve.ScalarMult(x1, y1, k.D.Bytes())
+
+	material, err := sm4KDF(x2, y2)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := sm4.NewGCM(material[:sm4.KeySize])
+	if err != nil {
+		return nil, err
+	}
+	msg, err = aead.Open(nil, material[sm4.KeySize:], cypherText[sm4PointSize:], c1)
+	if err != nil {
+		return nil, fmt.Errorf("The cypher text is invalid.")
+	}
+	return msg, nil
+}
+
+// sm4KDF 使用SM2的KDF从 x2 || y2 派生SM4密钥与nonce
+func sm4KDF(x2, y2 *big.Int) ([]byte, error) {
+	z := make([]byte, 64)
+	x2.FillBytes(z[:32])
+	y2.FillBytes(z[32:])
+	return kdf.SM2KDF(z, sm4MaterialSize)
+}
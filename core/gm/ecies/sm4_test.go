@@ -0,0 +1,50 @@
+package ecies
+
+import (
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func TestSM4EncryptDecrypt(t *testing.T) {
+	key, err := sm2.GenerateKey()
+	require.NoError(t, err)
+	privateKey := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: key.Curve, X: key.X, Y: key.Y},
+		D:         key.D,
+	}
+	msg := []byte("sealed with SM2 and SM4-GCM")
+
+	cypherText, err := EncryptWithSM4(&privateKey.PublicKey, msg)
+	require.NoError(t, err)
+	require.Len(t, cypherText, 65+len(msg)+16)
+	plainText, err := DecryptWithSM4(privateKey, cypherText)
+	require.NoError(t, err)
+	require.Equal(t, msg, plainText)
+
+	// 每次加密使用不同的临时密钥
+	other, err := EncryptWithSM4(&privateKey.PublicKey, msg)
+	require.NoError(t, err)
+	require.NotEqual(t, cypherText, other)
+
+	// 篡改C1或密文都会导致认证失败
+	cypherText[len(cypherText)-1] ^= 1
+	_, err = DecryptWithSM4(privateKey, cypherText)
+	require.Error(t, err)
+	other[1] ^= 1
+	_, err = DecryptWithSM4(privateKey, other)
+	require.Error(t, err)
+
+	wrongKey, err := sm2.GenerateKey()
+	require.NoError(t, err)
+	other, err = EncryptWithSM4(&privateKey.PublicKey, msg)
+	require.NoError(t, err)
+	_, err = DecryptWithSM4(&ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: wrongKey.Curve, X: wrongKey.X, Y: wrongKey.Y},
+		D:         wrongKey.D,
+	}, other)
+	require.Error(t, err)
+}
@@ -0,0 +1,183 @@
+package ecies
+
+import (
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"math/big"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// 基于X25519的ECIES：
+// 1. 发送方生成临时密钥对(e, E = X25519(e, 9))，计算共享秘密 Z = X25519(e, 接收方公钥)
+// 2. 使用HKDF-SHA256从Z派生ChaCha20-Poly1305的密钥与nonce，info为 标签 || E || 接收方公钥
+// 3. 密文为 E(32字节) || AEAD密文
+// 每次加密使用新的临时密钥，派生出的密钥与nonce不会重复。
+// Ed25519账户的公私钥可以通过Ed25519PublicKeyToX25519、Ed25519PrivateKeyToX25519转换为X25519的公私钥后使用。
+
+const x25519Info = "cu_crypto ECIES X25519 ChaCha20-Poly1305"
+
+var (
+	InvalidX25519PublicKeyError   = errors.New("The X25519 public key is invalid")
+	InvalidX25519PrivateKeyError  = errors.New("The X25519 private key is invalid")
+	InvalidEd25519PublicKeyError  = errors.New("The Ed25519 public key is invalid")
+	InvalidEd25519PrivateKeyError = errors.New("The Ed25519 private key is invalid")
+	InvalidCypherTextError        = errors.New("The cypher text is invalid")
+)
+
+// GenerateX25519Key 随机生成X25519私钥，返回公钥与私钥
+func GenerateX25519Key() (publicKey, privateKey []byte, err error) {
+	privateKey = make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand.Reader, privateKey); err != nil {
+		return nil, nil, err
+	}
+	publicKey, err = curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	return publicKey, privateKey, nil
+}
+
+// EncryptX25519 使用接收方的X25519公钥加密
+func EncryptX25519(publicKey, msg []byte) (cypherText []byte, err error) {
+	if len(publicKey) != curve25519.PointSize {
+		return nil, InvalidX25519PublicKeyError
+	}
+	ephemeralPublicKey, ephemeralPrivateKey, err := GenerateX25519Key()
+	if err != nil {
+		return nil, err
+	}
+	// 小阶点会得到全零的共享秘密，X25519会返回错误
+	shared, err := curve25519.X25519(ephemeralPrivateKey, publicKey)
+	if err != nil {
+		return nil, InvalidX25519PublicKeyError
+	}
+
+	aead, nonce, err := x25519AEAD(shared, ephemeralPublicKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(ephemeralPublicKey, nonce, msg, nil), nil
+}
+
+// DecryptX25519 使用接收方的X25519私钥解密
+func DecryptX25519(privateKey, cypherText []byte) (msg []byte, err error) {
+	if len(privateKey) != curve25519.ScalarSize {
+		return nil, InvalidX25519PrivateKeyError
+	}
+	if len(cypherText) < curve25519.PointSize+chacha20poly1305.Overhead {
+		return nil, InvalidCypherTextError
+	}
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, InvalidX25519PrivateKeyError
+	}
+	ephemeralPublicKey := cypherText[:curve25519.PointSize]
+	shared, err := curve25519.X25519(privateKey, ephemeralPublicKey)
+	if err != nil {
+		return nil, InvalidCypherTextError
+	}
+
+	aead, nonce, err := x25519AEAD(shared, ephemeralPublicKey, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	msg, err = aead.Open(nil, nonce, cypherText[curve25519.PointSize:], nil)
+	if err != nil {
+		return nil, InvalidCypherTextError
+	}
+	return msg, nil
+}
+
+// Ed25519PrivateKeyToX25519 将Ed25519私钥转换为X25519私钥：SHA-512(seed)的前32字节（RFC 8032 5.1.5）
+func Ed25519PrivateKeyToX25519(privateKey ed25519.PrivateKey) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, InvalidEd25519PrivateKeyError
+	}
+	h := sha512.Sum512(privateKey.Seed())
+	scalar := h[:curve25519.ScalarSize]
+	scalar[0] &= 248
+	scalar[31] &= 127
+	scalar[31] |= 64
+	return scalar, nil
+}
+
+// 曲线25519的参数：p = 2^255 - 19，d = -121665/121666 mod p
+var (
+	curve25519P = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	ed25519D    = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), curve25519P)), curve25519P)
+)
+
+// Ed25519PublicKeyToX25519 将Ed25519公钥转换为X25519公钥：u = (1 + y) / (1 - y) mod p
+func Ed25519PublicKeyToX25519(publicKey ed25519.PublicKey) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, InvalidEd25519PublicKeyError
+	}
+
+	// 公钥为小端序的y坐标，最高位为x的符号位
+	le := make([]byte, ed25519.PublicKeySize)
+	copy(le, publicKey)
+	le[31] &= 0x7f
+	y := new(big.Int).SetBytes(reverseBytes(le))
+	p := curve25519P
+	if y.Cmp(p) >= 0 {
+		return nil, InvalidEd25519PublicKeyError
+	}
+
+	// 检查点在曲线上：x^2 = (y^2 - 1) / (d*y^2 + 1) 必须是二次剩余
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, p)
+	num := new(big.Int).Sub(y2, big.NewInt(1))
+	den := new(big.Int).Mul(ed25519D, y2)
+	den.Add(den, big.NewInt(1))
+	den.Mod(den, p)
+	x2 := new(big.Int).Mul(num, new(big.Int).ModInverse(den, p))
+	x2.Mod(x2, p)
+	if x2.Sign() != 0 && big.Jacobi(x2, p) != 1 {
+		return nil, InvalidEd25519PublicKeyError
+	}
+
+	// y = 1对应单位元，无法转换
+	oneMinusY := new(big.Int).Sub(big.NewInt(1), y)
+	oneMinusY.Mod(oneMinusY, p)
+	if oneMinusY.Sign() == 0 {
+		return nil, InvalidEd25519PublicKeyError
+	}
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, new(big.Int).ModInverse(oneMinusY, p))
+	u.Mod(u, p)
+
+	return reverseBytes(u.FillBytes(make([]byte, curve25519.PointSize))), nil
+}
+
+// x25519AEAD 从共享秘密派生AEAD与nonce
+func x25519AEAD(shared, ephemeralPublicKey, publicKey []byte) (cipher.AEAD, []byte, error) {
+	info := make([]byte, 0, len(x25519Info)+2*curve25519.PointSize)
+	info = append(info, x25519Info...)
+	info = append(info, ephemeralPublicKey...)
+	info = append(info, publicKey...)
+
+	material := make([]byte, chacha20poly1305.KeySize+chacha20poly1305.NonceSize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), material); err != nil {
+		return nil, nil, err
+	}
+	aead, err := chacha20poly1305.New(material[:chacha20poly1305.KeySize])
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, material[chacha20poly1305.KeySize:], nil
+}
+
+func reverseBytes(data []byte) []byte {
+	for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+		data[i], data[j] = data[j], data[i]
+	}
+	return data
+}
@@ -0,0 +1,71 @@
+package ecies
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestX25519EncryptDecrypt(t *testing.T) {
+	publicKey, privateKey, err := GenerateX25519Key()
+	require.NoError(t, err)
+	msg := []byte("sealed for the partner system")
+
+	cypherText, err := EncryptX25519(publicKey, msg)
+	require.NoError(t, err)
+	require.Len(t, cypherText, curve25519.PointSize+len(msg)+16)
+	plainText, err := DecryptX25519(privateKey, cypherText)
+	require.NoError(t, err)
+	require.Equal(t, msg, plainText)
+
+	// 每次加密使用不同的临时密钥
+	other, err := EncryptX25519(publicKey, msg)
+	require.NoError(t, err)
+	require.NotEqual(t, cypherText, other)
+
+	cypherText[len(cypherText)-1] ^= 1
+	_, err = DecryptX25519(privateKey, cypherText)
+	require.Equal(t, InvalidCypherTextError, err)
+
+	_, wrongKey, err := GenerateX25519Key()
+	require.NoError(t, err)
+	_, err = DecryptX25519(wrongKey, other)
+	require.Equal(t, InvalidCypherTextError, err)
+
+	// 小阶点
+	_, err = EncryptX25519(make([]byte, curve25519.PointSize), msg)
+	require.Equal(t, InvalidX25519PublicKeyError, err)
+}
+
+func TestEd25519ToX25519(t *testing.T) {
+	for i := 0; i < 16; i++ {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		xPublicKey, err := Ed25519PublicKeyToX25519(publicKey)
+		require.NoError(t, err)
+		xPrivateKey, err := Ed25519PrivateKeyToX25519(privateKey)
+		require.NoError(t, err)
+		expected, err := curve25519.X25519(xPrivateKey, curve25519.Basepoint)
+		require.NoError(t, err)
+		require.Equal(t, expected, xPublicKey)
+
+		msg := []byte("encrypted to an Ed25519 account")
+		cypherText, err := EncryptX25519(xPublicKey, msg)
+		require.NoError(t, err)
+		plainText, err := DecryptX25519(xPrivateKey, cypherText)
+		require.NoError(t, err)
+		require.Equal(t, msg, plainText)
+	}
+
+	// y = 1为单位元
+	identity := make([]byte, ed25519.PublicKeySize)
+	identity[0] = 1
+	_, err := Ed25519PublicKeyToX25519(identity)
+	require.Equal(t, InvalidEd25519PublicKeyError, err)
+	_, err = Ed25519PublicKeyToX25519(identity[:31])
+	require.Equal(t, InvalidEd25519PublicKeyError, err)
+}
@@ -0,0 +1,61 @@
+package ed25519_sign
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+)
+
+// Ed25519签名（RFC 8032），用于与使用Ed25519的外部系统互通。
+// Ed25519的公私钥不是椭圆曲线的ecdsa公私钥，签名生成统一超级签名格式，SigContent为64字节的签名。
+
+var (
+	InvalidInputParamsError = errors.New("Invalid input params")
+	EmptyMessageError       = errors.New("Message to be sign should not be nil")
+	InvalidPrivateKeyError  = errors.New("The Ed25519 private key is invalid")
+	InvalidPublicKeyError   = errors.New("The Ed25519 public key is invalid")
+	InvalidSeedError        = errors.New("The seed of Ed25519 private key should be 32 bytes")
+)
+
+// GenerateKey 随机生成Ed25519私钥
+func GenerateKey() (ed25519.PrivateKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	return privateKey, err
+}
+
+// GenerateKeyFromSeed 使用32字节的种子生成Ed25519私钥，同一个种子总是得到相同的私钥
+func GenerateKeyFromSeed(seed []byte) (ed25519.PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, InvalidSeedError
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Sign 生成Ed25519超级签名
+func Sign(privateKey ed25519.PrivateKey, message []byte) ([]byte, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, InvalidPrivateKeyError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+
+	return json.Marshal(&common.XuperSignature{
+		SigType:    common.Ed25519,
+		SigContent: ed25519.Sign(privateKey, message),
+	})
+}
+
+// Verify 验证Ed25519签名，sig为超级签名中的SigContent
+func Verify(publicKey ed25519.PublicKey, sig, message []byte) (bool, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return false, InvalidPublicKeyError
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return false, nil
+	}
+	return ed25519.Verify(publicKey, message, sig), nil
+}
@@ -0,0 +1,63 @@
+package ed25519_sign
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
+
+// RFC 8032 7.1 TEST 2
+func TestSignKnownAnswer(t *testing.T) {
+	seed := decodeHex(t, "4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	publicKey := decodeHex(t, "3d4017c3e843895a92b70aa74d1b7ebc9c982ccf2ec4968cc0cd55f12af4660c")
+	expected := decodeHex(t, "92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da"+
+		"085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00")
+	message := []byte{0x72}
+
+	privateKey, err := GenerateKeyFromSeed(seed)
+	require.NoError(t, err)
+	require.Equal(t, publicKey, []byte(privateKey.Public().(ed25519.PublicKey)))
+
+	sig, err := Sign(privateKey, message)
+	require.NoError(t, err)
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	require.Equal(t, common.Ed25519, xuperSig.SigType)
+	require.Equal(t, expected, xuperSig.SigContent)
+
+	ok, err := Verify(publicKey, xuperSig.SigContent, message)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = Verify(publicKey, xuperSig.SigContent, []byte{0x73})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSignInvalidParams(t *testing.T) {
+	privateKey, err := GenerateKey()
+	require.NoError(t, err)
+
+	_, err = Sign(privateKey, nil)
+	require.Equal(t, EmptyMessageError, err)
+	_, err = Sign(privateKey[:32], []byte("m"))
+	require.Equal(t, InvalidPrivateKeyError, err)
+	_, err = GenerateKeyFromSeed(make([]byte, 31))
+	require.Equal(t, InvalidSeedError, err)
+	_, err = Verify(make(ed25519.PublicKey, 31), make([]byte, ed25519.SignatureSize), []byte("m"))
+	require.Equal(t, InvalidPublicKeyError, err)
+
+	ok, err := Verify(privateKey.Public().(ed25519.PublicKey), make([]byte, 10), []byte("m"))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
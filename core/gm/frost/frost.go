@@ -0,0 +1,403 @@
+package frost
+
+import (
+	"crypto/elliptic"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/dkg"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+// FROST门限Schnorr签名，参考RFC 9591，签名结果与schnorr_sign.Verify兼容
+//
+// 密钥由dkg包生成：群公钥 Y = x*G，参与者k持有私钥份额x_k，任意t个参与者可以签名。
+// Preprocess: 参与者i生成一对一次性随机数(d_i, e_i)，公开承诺 D_i = d_i*G、E_i = e_i*G
+// Sign: 协调者选择签名者集合S，并将全部承诺B = {(i, D_i, E_i)}与消息m发给签名者
+//  1. 绑定系数 ρ_i = H_rho(Y, m, B, i)
+//  2. 聚合随机数 R = Σ (D_i + ρ_i*E_i)
+//  3. 挑战值与schnorr_sign一致：e = SM3(m || R)
+//  4. 签名份额 z_i = d_i + ρ_i*e_i - λ_i*x_i*e，λ_i为拉格朗日系数
+//
+// Aggregate: 验证每个份额 z_i*G == D_i + ρ_i*E_i - λ_i*e*Y_i，签名为(e, z = Σ z_i)
+// 验证：z*G + e*Y == Σ(d_i + ρ_i*e_i)*G == R，即SM3(m || z*G + e*Y) == e
+//
+// 与schnorr_sign的约定相同，s = k - e*x，所以z_i中x_i的符号为负
+const (
+	tagNonce   = "cu_crypto/frost/nonce"
+	tagRho     = "cu_crypto/frost/rho"
+	tagMessage = "cu_crypto/frost/msg"
+	tagCommit  = "cu_crypto/frost/com"
+)
+
+var (
+	InvalidInputParamsError      = errors.New("Invalid input params")
+	EmptyMessageError            = errors.New("The message to be signed should not be empty")
+	NonceAlreadyUsedError        = errors.New("The signing nonces have already been used")
+	CommitmentNotFoundError      = errors.New("The commitment of the signer is not in the commitment list")
+	CommitmentMismatchError      = errors.New("The commitment in the list does not match the signing nonces")
+	InvalidCommitmentError       = errors.New("The signing commitment is invalid")
+	InvalidSignatureShareError   = errors.New("The signature share is invalid")
+	SignatureSharesMismatchError = errors.New("The signature shares do not match the commitment list")
+	InvalidGroupCommitmentError  = errors.New("The group commitment is the point at infinity")
+)
+
+// SigningCommitment 参与者公开的随机数承诺 (D_i, E_i)，压缩格式
+type SigningCommitment struct {
+	Identifier int
+	Hiding     []byte
+	Binding    []byte
+}
+
+// SigningNonces 参与者保存的一次性随机数 (d_i, e_i)
+type SigningNonces struct {
+	hiding     *big.Int
+	binding    *big.Int
+	commitment *SigningCommitment
+	used       bool
+}
+
+// SignatureShare 参与者的签名份额z_i
+type SignatureShare struct {
+	Identifier int
+	Share      []byte
+}
+
+// signingPackage 由承诺列表与消息计算出的公共数据
+type signingPackage struct {
+	curve        elliptic.Curve
+	identifiers  []int
+	hidings      map[int]*ecc.Point
+	bindings     map[int]*ecc.Point
+	bindingRhos  map[int]*big.Int
+	groupCommit  *ecc.Point
+	challenge    *big.Int
+	challengeRaw *big.Int
+}
+
+// Commit 预处理阶段：生成一次性随机数及其公开承诺
+// 随机数由系统随机数与私钥份额共同派生，即使随机数发生器有缺陷也不会直接泄露私钥
+func Commit(keyShare *dkg.KeyShare) (*SigningNonces, *SigningCommitment, error) {
+	if keyShare == nil || keyShare.PublicKeyPackage == nil || keyShare.SecretShare == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	curve := keyShare.Curve
+
+	hiding, err := generateNonce(curve, keyShare.SecretShare)
+	if err != nil {
+		return nil, nil, err
+	}
+	binding, err := generateNonce(curve, keyShare.SecretShare)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hidingPoint, err := ecc.ScalarBaseMult(curve, hiding).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	bindingPoint, err := ecc.ScalarBaseMult(curve, binding).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commitment := &SigningCommitment{
+		Identifier: keyShare.Identifier,
+		Hiding:     hidingPoint,
+		Binding:    bindingPoint,
+	}
+	nonces := &SigningNonces{
+		hiding:     hiding,
+		binding:    binding,
+		commitment: commitment,
+	}
+	return nonces, copyCommitment(commitment), nil
+}
+
+// Sign 计算签名份额 z_i = d_i + ρ_i*e_i - λ_i*x_i*e，随机数使用后立即清除
+func Sign(keyShare *dkg.KeyShare, nonces *SigningNonces, commitments []*SigningCommitment, message []byte) (*SignatureShare, error) {
+	if keyShare == nil || keyShare.PublicKeyPackage == nil || keyShare.SecretShare == nil || nonces == nil {
+		return nil, InvalidInputParamsError
+	}
+	if nonces.used {
+		return nil, NonceAlreadyUsedError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+
+	var own *SigningCommitment
+	for _, commitment := range commitments {
+		if commitment != nil && commitment.Identifier == keyShare.Identifier {
+			own = commitment
+		}
+	}
+	if own == nil {
+		return nil, CommitmentNotFoundError
+	}
+	if string(own.Hiding) != string(nonces.commitment.Hiding) || string(own.Binding) != string(nonces.commitment.Binding) {
+		return nil, CommitmentMismatchError
+	}
+
+	pkg, err := newSigningPackage(keyShare.PublicKeyPackage, commitments, message)
+	if err != nil {
+		return nil, err
+	}
+	lambda, err := dkg.LagrangeCoefficient(pkg.curve, pkg.identifiers, keyShare.Identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	// 无论签名是否成功，随机数都不能再次使用
+	hiding, binding := nonces.hiding, nonces.binding
+	nonces.used = true
+	nonces.hiding, nonces.binding = nil, nil
+
+	n := pkg.curve.Params().N
+	z := new(big.Int).Mul(pkg.bindingRhos[keyShare.Identifier], binding)
+	z.Add(z, hiding)
+	lx := new(big.Int).Mul(lambda, keyShare.SecretShare)
+	lx.Mul(lx, pkg.challenge)
+	z.Sub(z, lx)
+	z.Mod(z, n)
+
+	hiding.SetInt64(0)
+	binding.SetInt64(0)
+
+	return &SignatureShare{
+		Identifier: keyShare.Identifier,
+		Share:      ecc.ScalarBytes(pkg.curve, z),
+	}, nil
+}
+
+// VerifySignatureShare 验证签名份额 z_i*G == D_i + ρ_i*E_i - λ_i*e*Y_i
+func VerifySignatureShare(publicKeyPackage *dkg.PublicKeyPackage, commitments []*SigningCommitment, message []byte, share *SignatureShare) error {
+	if publicKeyPackage == nil || share == nil {
+		return InvalidInputParamsError
+	}
+	pkg, err := newSigningPackage(publicKeyPackage, commitments, message)
+	if err != nil {
+		return err
+	}
+	return pkg.verifyShare(publicKeyPackage, share)
+}
+
+// Aggregate 验证全部签名份额并生成Schnorr签名，可以使用schnorr_sign.Verify和群公钥验证
+// 签名份额无效时，返回的错误中包含对应参与者的标识
+func Aggregate(publicKeyPackage *dkg.PublicKeyPackage, commitments []*SigningCommitment, message []byte, shares []*SignatureShare) ([]byte, error) {
+	if publicKeyPackage == nil {
+		return nil, InvalidInputParamsError
+	}
+	pkg, err := newSigningPackage(publicKeyPackage, commitments, message)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) != len(pkg.identifiers) {
+		return nil, SignatureSharesMismatchError
+	}
+
+	n := pkg.curve.Params().N
+	seen := make(map[int]bool, len(shares))
+	z := new(big.Int)
+	for _, share := range shares {
+		if share == nil {
+			return nil, InvalidInputParamsError
+		}
+		if _, exist := pkg.hidings[share.Identifier]; !exist || seen[share.Identifier] {
+			return nil, SignatureSharesMismatchError
+		}
+		seen[share.Identifier] = true
+
+		if err := pkg.verifyShare(publicKeyPackage, share); err != nil {
+			return nil, err
+		}
+		z.Add(z, new(big.Int).SetBytes(share.Share))
+	}
+	z.Mod(z, n)
+
+	schnorrSig := &common.SchnorrSignature{
+		E: pkg.challengeRaw,
+		S: z,
+	}
+	sigContent, err := json.Marshal(schnorrSig)
+	if err != nil {
+		return nil, err
+	}
+
+	xuperSig := &common.XuperSignature{
+		SigType:    common.Schnorr,
+		SigContent: sigContent,
+	}
+	return json.Marshal(xuperSig)
+}
+
+// newSigningPackage 校验承诺列表，计算绑定系数、聚合随机数与挑战值
+func newSigningPackage(publicKeyPackage *dkg.PublicKeyPackage, commitments []*SigningCommitment, message []byte) (*signingPackage, error) {
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	curve := publicKeyPackage.Curve
+
+	identifiers := make([]int, len(commitments))
+	byIdentifier := make(map[int]*SigningCommitment, len(commitments))
+	for i, commitment := range commitments {
+		if commitment == nil {
+			return nil, InvalidCommitmentError
+		}
+		identifiers[i] = commitment.Identifier
+		byIdentifier[commitment.Identifier] = commitment
+	}
+	identifiers, err := publicKeyPackage.SortedIdentifiers(identifiers)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg := &signingPackage{
+		curve:       curve,
+		identifiers: identifiers,
+		hidings:     make(map[int]*ecc.Point, len(identifiers)),
+		bindings:    make(map[int]*ecc.Point, len(identifiers)),
+		bindingRhos: make(map[int]*big.Int, len(identifiers)),
+	}
+
+	// 按标识排序后编码承诺列表，保证所有参与者得到相同的绑定系数
+	encodedList := []byte{}
+	for _, id := range identifiers {
+		commitment := byIdentifier[id]
+		hiding, err := ecc.UnmarshalPoint(curve, commitment.Hiding)
+		if err != nil {
+			return nil, InvalidCommitmentError
+		}
+		binding, err := ecc.UnmarshalPoint(curve, commitment.Binding)
+		if err != nil {
+			return nil, InvalidCommitmentError
+		}
+		pkg.hidings[id] = hiding
+		pkg.bindings[id] = binding
+		encodedList = append(encodedList, identifierBytes(id)...)
+		encodedList = append(encodedList, commitment.Hiding...)
+		encodedList = append(encodedList, commitment.Binding...)
+	}
+
+	groupKey, err := ecc.MarshalPoint(curve, publicKeyPackage.GroupPublicKey.X, publicKeyPackage.GroupPublicKey.Y, ecc.PointFormatCompressed)
+	if err != nil {
+		return nil, err
+	}
+	messageHash := taggedHash(tagMessage, message)
+	listHash := taggedHash(tagCommit, encodedList)
+
+	groupCommit := ecc.Infinity(curve)
+	for _, id := range identifiers {
+		rho := hashToScalar(curve, tagRho, groupKey, messageHash, listHash, identifierBytes(id))
+		pkg.bindingRhos[id] = rho
+
+		groupCommit = ecc.AddPoints(groupCommit, pkg.hidings[id])
+		groupCommit = ecc.AddPoints(groupCommit, pkg.bindings[id].ScalarMult(rho))
+	}
+	if groupCommit.IsInfinity() {
+		return nil, InvalidGroupCommitmentError
+	}
+	pkg.groupCommit = groupCommit
+
+	// 与schnorr_sign一致：e = SM3(m || R)，R为非压缩格式
+	e := hash.HashUsingSM3(utils.BytesCombine(message, elliptic.Marshal(curve, groupCommit.X, groupCommit.Y)))
+	pkg.challengeRaw = new(big.Int).SetBytes(e)
+	pkg.challenge = new(big.Int).Mod(pkg.challengeRaw, curve.Params().N)
+
+	return pkg, nil
+}
+
+func (pkg *signingPackage) verifyShare(publicKeyPackage *dkg.PublicKeyPackage, share *SignatureShare) error {
+	curve := pkg.curve
+	n := curve.Params().N
+
+	hiding, exist := pkg.hidings[share.Identifier]
+	if !exist {
+		return CommitmentNotFoundError
+	}
+	verificationShare := publicKeyPackage.VerificationShares[share.Identifier]
+	z := new(big.Int).SetBytes(share.Share)
+	if len(share.Share) == 0 || z.Cmp(n) >= 0 {
+		return fmt.Errorf("%v: participant %d", InvalidSignatureShareError, share.Identifier)
+	}
+
+	lambda, err := dkg.LagrangeCoefficient(curve, pkg.identifiers, share.Identifier)
+	if err != nil {
+		return err
+	}
+	le := new(big.Int).Mul(lambda, pkg.challenge)
+	le.Neg(le)
+	le.Mod(le, n)
+
+	lhs := ecc.ScalarBaseMult(curve, z)
+	rhs := ecc.MultiScalarMult(curve,
+		[]*big.Int{big.NewInt(1), pkg.bindingRhos[share.Identifier], le},
+		[]*ecc.Point{hiding, pkg.bindings[share.Identifier], {Curve: curve, X: verificationShare.X, Y: verificationShare.Y}})
+	if !lhs.Equals(rhs) {
+		return fmt.Errorf("%v: participant %d", InvalidSignatureShareError, share.Identifier)
+	}
+	return nil
+}
+
+// generateNonce 随机数 = H_nonce(random || secret) mod N
+func generateNonce(curve elliptic.Curve, secret *big.Int) (*big.Int, error) {
+	for {
+		randomBytes, err := rand.GenerateSeedWithStrengthAndKeyLen(rand.KeyStrengthHard, rand.KeyLengthInt32)
+		if err != nil {
+			return nil, err
+		}
+		k := hashToScalar(curve, tagNonce, randomBytes, ecc.ScalarBytes(curve, secret))
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+// sm3DigestSize SM3的输出长度
+const sm3DigestSize = 32
+
+// hashToScalar 使用两次SM3得到512比特的输出再模N，避免取模带来的偏差
+func hashToScalar(curve elliptic.Curve, tag string, data ...[]byte) *big.Int {
+	var wide [2 * sm3DigestSize]byte
+	for counter := 0; counter < 2; counter++ {
+		h := sm3.New()
+		h.Write([]byte(tag))
+		h.Write([]byte{byte(counter)})
+		for _, d := range data {
+			var length [4]byte
+			binary.BigEndian.PutUint32(length[:], uint32(len(d)))
+			h.Write(length[:])
+			h.Write(d)
+		}
+		copy(wide[counter*sm3DigestSize:], h.Sum(nil))
+	}
+
+	k := new(big.Int).SetBytes(wide[:])
+	return k.Mod(k, curve.Params().N)
+}
+
+func taggedHash(tag string, data []byte) []byte {
+	return hash.HashUsingSM3(utils.BytesCombine([]byte(tag), data))
+}
+
+func identifierBytes(id int) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(id))
+	return b[:]
+}
+
+func copyCommitment(commitment *SigningCommitment) *SigningCommitment {
+	return &SigningCommitment{
+		Identifier: commitment.Identifier,
+		Hiding:     append([]byte{}, commitment.Hiding...),
+		Binding:    append([]byte{}, commitment.Binding...),
+	}
+}
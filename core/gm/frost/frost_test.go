@@ -0,0 +1,215 @@
+package frost
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/dkg"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/signature"
+)
+
+func generateKeyShares(t *testing.T, curve elliptic.Curve, threshold, total int) []*dkg.KeyShare {
+	participants := make([]*dkg.Participant, total)
+	round1 := make([]*dkg.Round1Package, total)
+	for i := range participants {
+		p, err := dkg.NewParticipant(curve, i+1, threshold, total, []byte("frost test"))
+		require.NoError(t, err)
+		participants[i] = p
+		round1[i], err = p.Round1()
+		require.NoError(t, err)
+	}
+
+	inbox := make(map[int][]*dkg.Round2Package)
+	for _, p := range participants {
+		packages, err := p.Round2(round1)
+		require.NoError(t, err)
+		for _, pkg := range packages {
+			inbox[pkg.To] = append(inbox[pkg.To], pkg)
+		}
+	}
+
+	keyShares := make([]*dkg.KeyShare, total)
+	for i, p := range participants {
+		keyShare, err := p.Finalize(inbox[p.Identifier()])
+		require.NoError(t, err)
+		keyShares[i] = keyShare
+	}
+	return keyShares
+}
+
+// thresholdSign 由signers中的参与者完成一次FROST签名
+func thresholdSign(t *testing.T, keyShares []*dkg.KeyShare, signers []int, message []byte) ([]*SigningCommitment, []*SignatureShare) {
+	nonces := make([]*SigningNonces, len(signers))
+	commitments := make([]*SigningCommitment, len(signers))
+	for i, id := range signers {
+		var err error
+		nonces[i], commitments[i], err = Commit(keyShares[id-1])
+		require.NoError(t, err)
+	}
+
+	shares := make([]*SignatureShare, len(signers))
+	for i, id := range signers {
+		var err error
+		shares[i], err = Sign(keyShares[id-1], nonces[i], commitments, message)
+		require.NoError(t, err)
+
+		_, err = Sign(keyShares[id-1], nonces[i], commitments, message)
+		require.Equal(t, NonceAlreadyUsedError, err)
+	}
+	return commitments, shares
+}
+
+func TestFROST(t *testing.T) {
+	message := []byte("FROST threshold signature")
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		keyShares := generateKeyShares(t, curve, 3, 5)
+		publicKeyPackage := keyShares[0].PublicKeyPackage
+
+		for _, signers := range [][]int{{1, 2, 3}, {5, 2, 4}, {1, 2, 3, 4, 5}} {
+			commitments, shares := thresholdSign(t, keyShares, signers, message)
+			for _, share := range shares {
+				require.NoError(t, VerifySignatureShare(publicKeyPackage, commitments, message, share))
+			}
+
+			sig, err := Aggregate(publicKeyPackage, commitments, message, shares)
+			require.NoError(t, err)
+
+			xuperSig := new(common.XuperSignature)
+			require.NoError(t, json.Unmarshal(sig, xuperSig))
+			require.Equal(t, common.Schnorr, xuperSig.SigType)
+
+			ok, err := schnorr_sign.Verify(publicKeyPackage.GroupPublicKey, xuperSig.SigContent, message)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			ok, err = schnorr_sign.Verify(publicKeyPackage.GroupPublicKey, xuperSig.SigContent, []byte("another message"))
+			require.NoError(t, err)
+			require.False(t, ok)
+
+			if curve == sm2.P256Sm2() {
+				ok, err = signature.XuperSigVerify([]*ecdsa.PublicKey{publicKeyPackage.GroupPublicKey}, sig, message)
+				require.NoError(t, err)
+				require.True(t, ok)
+			}
+		}
+	}
+}
+
+func TestFROSTRejectsInvalidShares(t *testing.T) {
+	message := []byte("FROST threshold signature")
+	keyShares := generateKeyShares(t, sm2.P256Sm2(), 2, 3)
+	publicKeyPackage := keyShares[0].PublicKeyPackage
+
+	// 签名者少于门限
+	nonces, commitment, err := Commit(keyShares[0])
+	require.NoError(t, err)
+	_, err = Sign(keyShares[0], nonces, []*SigningCommitment{commitment}, message)
+	require.Equal(t, dkg.NotEnoughIdentifiersError, err)
+
+	commitments, shares := thresholdSign(t, keyShares, []int{1, 3}, message)
+
+	// 篡改参与者3的签名份额
+	shares[1].Share[len(shares[1].Share)-1] ^= 1
+	require.Error(t, VerifySignatureShare(publicKeyPackage, commitments, message, shares[1]))
+	_, err = Aggregate(publicKeyPackage, commitments, message, shares)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "participant 3")
+
+	// 份额与承诺列表不一致
+	_, err = Aggregate(publicKeyPackage, commitments, message, shares[:1])
+	require.Equal(t, SignatureSharesMismatchError, err)
+}
+
+// fixedKeyShares 使用固定多项式 f(x) = a0 + a1*x 生成2-of-3的私钥份额
+func fixedKeyShares(t *testing.T, curve elliptic.Curve) []*dkg.KeyShare {
+	a0, _ := new(big.Int).SetString("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8", 16)
+	a1, _ := new(big.Int).SetString("6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE", 16)
+	n := curve.Params().N
+
+	gx, gy := curve.ScalarBaseMult(a0.Bytes())
+	pkg := &dkg.PublicKeyPackage{
+		Curve:              curve,
+		Threshold:          2,
+		Total:              3,
+		GroupPublicKey:     &ecdsa.PublicKey{Curve: curve, X: gx, Y: gy},
+		VerificationShares: make(map[int]*ecdsa.PublicKey),
+	}
+	keyShares := make([]*dkg.KeyShare, pkg.Total)
+	for i := range keyShares {
+		id := i + 1
+		share := new(big.Int).Mul(a1, big.NewInt(int64(id)))
+		share.Add(share, a0)
+		share.Mod(share, n)
+		x, y := curve.ScalarBaseMult(share.Bytes())
+		pkg.VerificationShares[id] = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		keyShares[i] = &dkg.KeyShare{Identifier: id, SecretShare: share, PublicKeyPackage: pkg}
+	}
+	return keyShares
+}
+
+// fixedNonces 使用固定的随机数(d_i, e_i)代替Commit
+func fixedNonces(t *testing.T, keyShare *dkg.KeyShare, hiding, binding string) (*SigningNonces, *SigningCommitment) {
+	curve := keyShare.Curve
+	d, _ := new(big.Int).SetString(hiding, 16)
+	e, _ := new(big.Int).SetString(binding, 16)
+	hidingPoint, err := ecc.ScalarBaseMult(curve, d).MarshalCompressed()
+	require.NoError(t, err)
+	bindingPoint, err := ecc.ScalarBaseMult(curve, e).MarshalCompressed()
+	require.NoError(t, err)
+
+	commitment := &SigningCommitment{Identifier: keyShare.Identifier, Hiding: hidingPoint, Binding: bindingPoint}
+	nonces := &SigningNonces{hiding: d, binding: e, commitment: commitment}
+	return nonces, copyCommitment(commitment)
+}
+
+// 已知答案测试：固定私钥份额与随机数，固定绑定系数、签名份额与最终签名
+// 期望值由独立实现按本包的哈希约定计算得到
+func TestFROSTKnownAnswer(t *testing.T) {
+	curve := sm2.P256Sm2()
+	message := []byte("FROST known answer test")
+	keyShares := fixedKeyShares(t, curve)
+	publicKeyPackage := keyShares[0].PublicKeyPackage
+
+	nonces1, commitment1 := fixedNonces(t, keyShares[0],
+		"83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563",
+		"33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80")
+	nonces3, commitment3 := fixedNonces(t, keyShares[2],
+		"5E35D7D3F3C54DBAC72E61819E730B019A84208CA3A35E4C2E353DFCCB2A3B53",
+		"59276E27D506861A16680F3AD9C02DCCEF3CC1FA3CDBE4CE6D54B80DEAC1BC21")
+	commitments := []*SigningCommitment{commitment3, commitment1}
+
+	pkg, err := newSigningPackage(publicKeyPackage, commitments, message)
+	require.NoError(t, err)
+	require.Equal(t, "2445925C2B2884376A36595193E86B43D6005483949C4891709D944181C6FAFB", fmt.Sprintf("%064X", pkg.bindingRhos[1]))
+	require.Equal(t, "1F3AD07F9D85D665152363D1D5D11C6095274825AE35CD54DC98BC8BBE8BED75", fmt.Sprintf("%064X", pkg.bindingRhos[3]))
+
+	share1, err := Sign(keyShares[0], nonces1, commitments, message)
+	require.NoError(t, err)
+	share3, err := Sign(keyShares[2], nonces3, commitments, message)
+	require.NoError(t, err)
+	require.Equal(t, "4289E4F2F96AB80D250EEBD0443B6642FF3943A28F0A1001230E173EA46CDF42", fmt.Sprintf("%X", share1.Share))
+	require.Equal(t, "60CECCAD1A89D09BCE3774D1045C767804E7C6721AC1FE629A792D910442F885", fmt.Sprintf("%X", share3.Share))
+
+	sig, err := Aggregate(publicKeyPackage, commitments, message, []*SignatureShare{share1, share3})
+	require.NoError(t, err)
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	schnorrSig := new(common.SchnorrSignature)
+	require.NoError(t, json.Unmarshal(xuperSig.SigContent, schnorrSig))
+	require.Equal(t, "870A1D10BDCA720B979F767227C05071555B864818F006E6A9CA4EB170A9F380", fmt.Sprintf("%064X", schnorrSig.E))
+	require.Equal(t, "A358B1A013F488A8F34660A14897DCBB04210A14A9CC0E63BD8744CFA8AFD7C7", fmt.Sprintf("%064X", schnorrSig.S))
+
+	ok, err := schnorr_sign.Verify(publicKeyPackage.GroupPublicKey, xuperSig.SigContent, message)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
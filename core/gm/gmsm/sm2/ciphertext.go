@@ -0,0 +1,155 @@
+/*
+Copyright Suzhou Tongji Fintech Research Institute 2017 All Rights Reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sm2
+
+import (
+	"encoding/asn1"
+	"errors"
+	"math/big"
+)
+
+// SM2密文的编码格式：
+// C1C3C2: 0x04 || x1 || y1 || C3 || C2，GB/T 32918.4-2016的格式，Encrypt与Decrypt默认使用
+// C1C2C3: 0x04 || x1 || y1 || C2 || C3，GM/T 0003-2012及部分密码机使用的格式
+// ASN1:   GM/T 0009-2012定义的DER编码
+//
+//	SM2Cipher ::= SEQUENCE {
+//		XCoordinate INTEGER,
+//		YCoordinate INTEGER,
+//		HASH        OCTET STRING SIZE(32),
+//		CipherText  OCTET STRING
+//	}
+type CiphertextFormat int
+
+const (
+	C1C3C2 CiphertextFormat = iota
+	C1C2C3
+	ASN1
+)
+
+const (
+	c1Size = 65 // 0x04 || x1 || y1
+	c3Size = 32 // SM3(x2 || M || y2)
+)
+
+var (
+	errInvalidCiphertext       = errors.New("SM2: invalid ciphertext")
+	errInvalidCiphertextFormat = errors.New("SM2: unsupported ciphertext format")
+)
+
+type sm2Cipher struct {
+	XCoordinate *big.Int
+	YCoordinate *big.Int
+	HASH        []byte
+	CipherText  []byte
+}
+
+// EncryptWithFormat 加密并按指定格式编码密文
+func EncryptWithFormat(pub *PublicKey, data []byte, format CiphertextFormat) ([]byte, error) {
+	if format != C1C3C2 && format != C1C2C3 && format != ASN1 {
+		return nil, errInvalidCiphertextFormat
+	}
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+	c, err := Encrypt(pub, data)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertCiphertext(c, C1C3C2, format)
+}
+
+// DecryptWithFormat 解密指定格式的密文
+func DecryptWithFormat(priv *PrivateKey, data []byte, format CiphertextFormat) ([]byte, error) {
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+	c, err := ConvertCiphertext(data, format, C1C3C2)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(priv, c)
+}
+
+// ConvertCiphertext 在不同的密文格式之间转换，会检查密文的长度与C1的编码，不检查C1是否在曲线上
+func ConvertCiphertext(data []byte, from, to CiphertextFormat) ([]byte, error) {
+	c1, c3, c2, err := splitCiphertext(data, from)
+	if err != nil {
+		return nil, err
+	}
+
+	switch to {
+	case C1C3C2:
+		return joinBytes(c1, c3, c2), nil
+	case C1C2C3:
+		return joinBytes(c1, c2, c3), nil
+	case ASN1:
+		return asn1.Marshal(sm2Cipher{
+			XCoordinate: new(big.Int).SetBytes(c1[1:33]),
+			YCoordinate: new(big.Int).SetBytes(c1[33:]),
+			HASH:        c3,
+			CipherText:  c2,
+		})
+	default:
+		return nil, errInvalidCiphertextFormat
+	}
+}
+
+// splitCiphertext 将密文拆分为C1（0x04 || x1 || y1）、C3、C2
+func splitCiphertext(data []byte, format CiphertextFormat) (c1, c3, c2 []byte, err error) {
+	switch format {
+	case C1C3C2, C1C2C3:
+		// C2至少为1个字节
+		if len(data) <= c1Size+c3Size || data[0] != 0x04 {
+			return nil, nil, nil, errInvalidCiphertext
+		}
+		c1 = data[:c1Size]
+		if format == C1C3C2 {
+			return c1, data[c1Size : c1Size+c3Size], data[c1Size+c3Size:], nil
+		}
+		return c1, data[len(data)-c3Size:], data[c1Size : len(data)-c3Size], nil
+	case ASN1:
+		var cipher sm2Cipher
+		rest, err := asn1.Unmarshal(data, &cipher)
+		if err != nil || len(rest) != 0 {
+			return nil, nil, nil, errInvalidCiphertext
+		}
+		p := P256Sm2().Params().P
+		x, y := cipher.XCoordinate, cipher.YCoordinate
+		if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+			return nil, nil, nil, errInvalidCiphertext
+		}
+		if len(cipher.HASH) != c3Size || len(cipher.CipherText) == 0 {
+			return nil, nil, nil, errInvalidCiphertext
+		}
+		c1 = joinBytes([]byte{0x04}, toBytes32(x), toBytes32(y))
+		return c1, cipher.HASH, cipher.CipherText, nil
+	default:
+		return nil, nil, nil, errInvalidCiphertextFormat
+	}
+}
+
+func joinBytes(parts ...[]byte) []byte {
+	var size int
+	for _, p := range parts {
+		size += len(p)
+	}
+	buf := make([]byte, 0, size)
+	for _, p := range parts {
+		buf = append(buf, p...)
+	}
+	return buf
+}
@@ -0,0 +1,151 @@
+/*
+Copyright Suzhou Tongji Fintech Research Institute 2017 All Rights Reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sm2
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+)
+
+func TestCiphertextFormats(t *testing.T) {
+	priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("sm2 ciphertext formats")
+
+	for _, format := range []CiphertextFormat{C1C3C2, C1C2C3, ASN1} {
+		c, err := EncryptWithFormat(&priv.PublicKey, msg, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := DecryptWithFormat(priv, c, format)
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+		if !bytes.Equal(m, msg) {
+			t.Fatalf("format %d: plaintext mismatch", format)
+		}
+
+		// 转换为其他格式后仍然可以解密，再转换回来与原密文一致
+		for _, to := range []CiphertextFormat{C1C3C2, C1C2C3, ASN1} {
+			converted, err := ConvertCiphertext(c, format, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := DecryptWithFormat(priv, converted, to)
+			if err != nil || !bytes.Equal(m, msg) {
+				t.Fatalf("convert %d to %d: %v", format, to, err)
+			}
+			back, err := ConvertCiphertext(converted, to, format)
+			if err != nil || !bytes.Equal(back, c) {
+				t.Fatalf("convert %d to %d and back: %v", format, to, err)
+			}
+		}
+	}
+
+	// 默认格式与Encrypt一致
+	c, err := Encrypt(&priv.PublicKey, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2c3, err := ConvertCiphertext(c, C1C3C2, C1C2C3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c2c3[:c1Size], c[:c1Size]) || !bytes.Equal(c2c3[len(c2c3)-c3Size:], c[c1Size:c1Size+c3Size]) {
+		t.Fatal("C1C2C3 layout mismatch")
+	}
+	der, err := ConvertCiphertext(c, C1C3C2, ASN1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cipher sm2Cipher
+	if _, err := asn1.Unmarshal(der, &cipher); err != nil {
+		t.Fatal(err)
+	}
+	if cipher.XCoordinate.Cmp(priv.Curve.Params().P) >= 0 || !bytes.Equal(cipher.CipherText, c[c1Size+c3Size:]) {
+		t.Fatal("ASN.1 layout mismatch")
+	}
+}
+
+func TestCiphertextLength(t *testing.T) {
+	priv, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Encrypt(&priv.PublicKey, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 截断的密文返回错误而不是越界
+	for i := 1; i < len(c); i++ {
+		if _, err := Decrypt(priv, c[:i]); err == nil {
+			t.Fatalf("truncated ciphertext of length %d should be rejected", i)
+		}
+		for _, format := range []CiphertextFormat{C1C3C2, C1C2C3, ASN1} {
+			if _, err := DecryptWithFormat(priv, c[:i], format); err == nil {
+				t.Fatalf("truncated ciphertext of length %d should be rejected", i)
+			}
+		}
+	}
+
+	// 边界：97字节的密文只有C1与C3，C2为空，必须拒绝；98字节的密文可以解密
+	if len(c) != c1Size+c3Size+1 {
+		t.Fatalf("unexpected ciphertext length %d", len(c))
+	}
+	if _, err := Decrypt(priv, c); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := CipherC1(priv.Curve, c[:c1Size+c3Size]); err == nil {
+		t.Fatal("ciphertext without C2 should be rejected by CipherC1")
+	}
+	x1, y1 := new(big.Int).SetBytes(c[1:33]), new(big.Int).SetBytes(c[33:65])
+	x2, y2 := priv.Curve.ScalarMult(x1, y1, priv.D.Bytes())
+	if _, err := DecryptWithSharedPoint(c[:c1Size+c3Size], x2, y2); err == nil {
+		t.Fatal("ciphertext without C2 should be rejected by DecryptWithSharedPoint")
+	}
+	if _, err := DecryptWithSharedPoint(c, x2, y2); err != nil {
+		t.Fatal(err)
+	}
+
+	// C1必须是曲线上的未压缩点
+	bad := append([]byte{}, c...)
+	bad[0] = 0x02
+	if _, err := Decrypt(priv, bad); err == nil {
+		t.Fatal("compressed C1 should be rejected")
+	}
+	bad = append([]byte{}, c...)
+	bad[64] ^= 1
+	if _, err := Decrypt(priv, bad); err == nil {
+		t.Fatal("C1 not on the curve should be rejected")
+	}
+
+	// ASN.1编码后不能有多余的数据
+	der, err := ConvertCiphertext(c, C1C3C2, ASN1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptWithFormat(priv, append(der, 0), ASN1); err != errInvalidCiphertext {
+		t.Fatalf("trailing data should be rejected, got %v", err)
+	}
+	if _, err := ConvertCiphertext(c, C1C3C2, CiphertextFormat(3)); err != errInvalidCiphertextFormat {
+		t.Fatalf("unsupported format should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+/*
+Copyright Suzhou Tongji Fintech Research Institute 2017 All Rights Reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sm2
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// SM2密钥交换协议（GB/T 32918.3-2016）
+//
+// 发起方A与响应方B各自持有SM2密钥对与用户身份标识，交换临时公钥后协商出相同的密钥：
+// 1. A调用InitKeyExchange生成临时公钥RA，发送给B
+// 2. B调用RespondKeyExchange生成临时公钥RB，得到协商密钥KB与可选的确认值SB，将RB、SB发送给A
+// 3. A调用ConfirmResponder得到协商密钥KA，校验SB（SB为nil时不校验），得到确认值SA发送给B
+// 4. B调用ConfirmInitiator校验SA（可选）
+//
+// 协商密钥 K = KDF(xU || yU || ZA || ZB, klen)，其中ZA总是发起方的ZA，ZB总是响应方的ZA。
+// 每个KeyExchange只能用于一次协商，临时私钥不能重复使用。
+
+var (
+	errKeyExchangeRole      = errors.New("SM2: invalid key exchange role")
+	errKeyExchangeState     = errors.New("SM2: key exchange is not initialized")
+	errKeyExchangeKeyLength = errors.New("SM2: invalid key length")
+	errKeyExchangePoint     = errors.New("SM2: invalid ephemeral public key")
+	errKeyExchangeFailed    = errors.New("SM2: key exchange failed")
+	errKeyExchangeConfirm   = errors.New("SM2: key confirmation failed")
+)
+
+// KeyExchange 密钥交换中的一方
+type KeyExchange struct {
+	curve     elliptic.Curve
+	initiator bool
+	keyLen    int
+	priv      *PrivateKey
+	peerPub   *PublicKey
+	za        []byte // 发起方的ZA
+	zb        []byte // 响应方的ZA
+
+	ephemeral *PrivateKey // 本方临时密钥
+	peerEph   *PublicKey  // 对方临时公钥
+	v         *PublicKey  // 共享点，发起方为U，响应方为V
+}
+
+// NewKeyExchange 创建密钥交换的一方，uid与peerUID分别为本方与对方的身份标识，keyLen为协商密钥的字节长度
+func NewKeyExchange(priv *PrivateKey, peerPub *PublicKey, uid, peerUID []byte, keyLen int, initiator bool) (*KeyExchange, error) {
+	return newKeyExchange(P256Sm2(), sm2P256ToBig(&sm2P256.a), priv, peerPub, uid, peerUID, keyLen, initiator)
+}
+
+// newKeyExchange 在指定曲线上创建密钥交换的一方，a为曲线方程的系数，用于计算ZA；曲线的余因子需要为1
+func newKeyExchange(curve elliptic.Curve, a *big.Int, priv *PrivateKey, peerPub *PublicKey, uid, peerUID []byte, keyLen int, initiator bool) (*KeyExchange, error) {
+	if priv == nil || priv.D == nil || peerPub == nil || peerPub.X == nil || peerPub.Y == nil {
+		return nil, errors.New("SM2: invalid key")
+	}
+	if !curve.IsOnCurve(peerPub.X, peerPub.Y) {
+		return nil, errors.New("SM2: invalid peer public key")
+	}
+	if keyLen <= 0 {
+		return nil, errKeyExchangeKeyLength
+	}
+	z, err := zaWithCurve(curve, a, &priv.PublicKey, uid)
+	if err != nil {
+		return nil, err
+	}
+	peerZ, err := zaWithCurve(curve, a, peerPub, peerUID)
+	if err != nil {
+		return nil, err
+	}
+
+	ke := &KeyExchange{
+		curve:     curve,
+		initiator: initiator,
+		keyLen:    keyLen,
+		priv:      priv,
+		peerPub:   peerPub,
+	}
+	if initiator {
+		ke.za, ke.zb = z, peerZ
+	} else {
+		ke.za, ke.zb = peerZ, z
+	}
+	return ke, nil
+}
+
+// InitKeyExchange 发起方生成临时密钥，返回临时公钥RA
+func (ke *KeyExchange) InitKeyExchange() (*PublicKey, error) {
+	if !ke.initiator {
+		return nil, errKeyExchangeRole
+	}
+	r, err := randFieldElement(ke.curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return ke.initWithEphemeral(r), nil
+}
+
+// RespondKeyExchange 响应方收到RA后生成临时公钥RB，返回RB、协商密钥与确认值SB
+func (ke *KeyExchange) RespondKeyExchange(rA *PublicKey) (rB *PublicKey, key, sB []byte, err error) {
+	if ke.initiator {
+		return nil, nil, nil, errKeyExchangeRole
+	}
+	r, err := randFieldElement(ke.curve, rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ke.respond(rA, r)
+}
+
+// ConfirmResponder 发起方收到RB与SB后计算协商密钥，SB不为nil时校验SB，返回协商密钥与确认值SA
+func (ke *KeyExchange) ConfirmResponder(rB *PublicKey, sB []byte) (key, sA []byte, err error) {
+	if !ke.initiator {
+		return nil, nil, errKeyExchangeRole
+	}
+	if ke.ephemeral == nil || ke.v != nil {
+		return nil, nil, errKeyExchangeState
+	}
+	key, err = ke.agree(rB)
+	if err != nil {
+		return nil, nil, err
+	}
+	if sB != nil && subtle.ConstantTimeCompare(sB, ke.confirmation(0x02)) != 1 {
+		return nil, nil, errKeyExchangeConfirm
+	}
+	return key, ke.confirmation(0x03), nil
+}
+
+// ConfirmInitiator 响应方校验发起方的确认值SA
+func (ke *KeyExchange) ConfirmInitiator(sA []byte) error {
+	if ke.initiator {
+		return errKeyExchangeRole
+	}
+	if ke.v == nil {
+		return errKeyExchangeState
+	}
+	if subtle.ConstantTimeCompare(sA, ke.confirmation(0x03)) != 1 {
+		return errKeyExchangeConfirm
+	}
+	return nil
+}
+
+func (ke *KeyExchange) initWithEphemeral(r *big.Int) *PublicKey {
+	curve := ke.curve
+	ke.ephemeral = new(PrivateKey)
+	ke.ephemeral.Curve = curve
+	ke.ephemeral.D = r
+	ke.ephemeral.X, ke.ephemeral.Y = curve.ScalarBaseMult(r.Bytes())
+	return &ke.ephemeral.PublicKey
+}
+
+func (ke *KeyExchange) respond(rA *PublicKey, r *big.Int) (rB *PublicKey, key, sB []byte, err error) {
+	if ke.v != nil {
+		return nil, nil, nil, errKeyExchangeState
+	}
+	rB = ke.initWithEphemeral(r)
+	key, err = ke.agree(rA)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return rB, key, ke.confirmation(0x02), nil
+}
+
+// agree 计算共享点与协商密钥：
+// t = (d + x̄ * r) mod n，共享点 = [h * t](P' + [x̄'] R')，x̄ = 2^w + (x & (2^w - 1))，w = 127，SM2曲线的余因子h = 1
+func (ke *KeyExchange) agree(peerEph *PublicKey) ([]byte, error) {
+	curve := ke.curve
+	if peerEph == nil || peerEph.X == nil || peerEph.Y == nil || !curve.IsOnCurve(peerEph.X, peerEph.Y) {
+		return nil, errKeyExchangePoint
+	}
+	n := curve.Params().N
+
+	t := new(big.Int).Mul(reduceX(ke.ephemeral.X), ke.ephemeral.D)
+	t.Add(t, ke.priv.D)
+	t.Mod(t, n)
+
+	x1, y1 := curve.ScalarMult(peerEph.X, peerEph.Y, reduceX(peerEph.X).Bytes())
+	x, y := curve.Add(ke.peerPub.X, ke.peerPub.Y, x1, y1)
+	x, y = curve.ScalarMult(x, y, t.Bytes())
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errKeyExchangeFailed
+	}
+
+	z := make([]byte, 0, 128)
+	z = append(z, toBytes32(x)...)
+	z = append(z, toBytes32(y)...)
+	z = append(z, ke.za...)
+	key, ok := kdf(z, ke.zb, ke.keyLen)
+	if !ok {
+		return nil, errKeyExchangeFailed
+	}
+
+	ke.peerEph = peerEph
+	ke.v = &PublicKey{Curve: curve, X: x, Y: y}
+	return key, nil
+}
+
+// confirmation S = Hash(prefix || yV || Hash(xV || ZA || ZB || x1 || y1 || x2 || y2))，
+// (x1, y1)为发起方临时公钥，(x2, y2)为响应方临时公钥；SB的prefix为0x02，SA的prefix为0x03
+func (ke *KeyExchange) confirmation(prefix byte) []byte {
+	rA, rB := &ke.ephemeral.PublicKey, ke.peerEph
+	if !ke.initiator {
+		rA, rB = rB, rA
+	}
+
+	h := sm3.New()
+	h.Write(toBytes32(ke.v.X))
+	h.Write(ke.za)
+	h.Write(ke.zb)
+	h.Write(toBytes32(rA.X))
+	h.Write(toBytes32(rA.Y))
+	h.Write(toBytes32(rB.X))
+	h.Write(toBytes32(rB.Y))
+	inner := h.Sum(nil)
+
+	h = sm3.New()
+	h.Write([]byte{prefix})
+	h.Write(toBytes32(ke.v.Y))
+	h.Write(inner)
+	return h.Sum(nil)
+}
+
+// reduceX x̄ = 2^127 + (x & (2^127 - 1))
+func reduceX(x *big.Int) *big.Int {
+	w := new(big.Int).Lsh(one, 127)
+	mask := new(big.Int).Sub(w, one)
+	return new(big.Int).Add(w, new(big.Int).And(x, mask))
+}
+
+func toBytes32(x *big.Int) []byte {
+	buf := x.Bytes()
+	if n := len(buf); n < 32 {
+		buf = append(zeroByteSlice()[:32-n], buf...)
+	}
+	return buf
+}
@@ -0,0 +1,353 @@
+/*
+Copyright Suzhou Tongji Fintech Research Institute 2017 All Rights Reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+                 http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sm2
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func kepPrivateKey(t *testing.T, s string) *PrivateKey {
+	d, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		t.Fatalf("invalid private key %s", s)
+	}
+	priv := new(PrivateKey)
+	priv.Curve = P256Sm2()
+	priv.D = d
+	priv.X, priv.Y = priv.Curve.ScalarBaseMult(d.Bytes())
+	return priv
+}
+
+func TestKeyExchange(t *testing.T) {
+	privA, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privB, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uidA, uidB := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+
+	a, err := NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 16, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewKeyExchange(privB, &privA.PublicKey, uidB, uidA, 16, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rA, err := a.InitKeyExchange()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rB, keyB, sB, err := b.RespondKeyExchange(rA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, sA, err := a.ConfirmResponder(rB, sB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyA) != 16 || !bytes.Equal(keyA, keyB) {
+		t.Fatalf("key mismatch: %x != %x", keyA, keyB)
+	}
+	if err := b.ConfirmInitiator(sA); err != nil {
+		t.Fatal(err)
+	}
+
+	// 共享点 U = V = [tA * tB]G，与协商过程独立计算的结果一致
+	n := P256Sm2().Params().N
+	tA := new(big.Int).Mul(reduceX(rA.X), a.ephemeral.D)
+	tA.Add(tA, privA.D)
+	tB := new(big.Int).Mul(reduceX(rB.X), b.ephemeral.D)
+	tB.Add(tB, privB.D)
+	tAB := new(big.Int).Mul(tA, tB)
+	tAB.Mod(tAB, n)
+	x, y := P256Sm2().ScalarBaseMult(tAB.Bytes())
+	za, _ := ZA(&privA.PublicKey, uidA)
+	zb, _ := ZA(&privB.PublicKey, uidB)
+	z := append(append(append(toBytes32(x), toBytes32(y)...), za...), zb...)
+	if expected, _ := kdf(z, nil, 16); !bytes.Equal(keyA, expected) {
+		t.Fatalf("key mismatch: %x != %x", keyA, expected)
+	}
+
+	// 不校验SB时发起方仍然可以得到协商密钥
+	a2, _ := NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 48, true)
+	b2, _ := NewKeyExchange(privB, &privA.PublicKey, uidB, uidA, 48, false)
+	rA, _ = a2.InitKeyExchange()
+	rB, keyB, _, err = b2.RespondKeyExchange(rA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, _, err = a2.ConfirmResponder(rB, nil)
+	if err != nil || !bytes.Equal(keyA, keyB) {
+		t.Fatalf("key mismatch without confirmation: %x != %x, %v", keyA, keyB, err)
+	}
+}
+
+func TestKeyExchangeFailure(t *testing.T) {
+	privA, _ := GenerateKey()
+	privB, _ := GenerateKey()
+	uidA, uidB := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+
+	// 双方使用的身份标识不一致时确认失败
+	a, _ := NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 16, true)
+	b, _ := NewKeyExchange(privB, &privA.PublicKey, uidB, []byte("MALLORY"), 16, false)
+	rA, _ := a.InitKeyExchange()
+	rB, _, sB, err := b.RespondKeyExchange(rA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := a.ConfirmResponder(rB, sB); err != errKeyExchangeConfirm {
+		t.Fatalf("expected confirmation failure, got %v", err)
+	}
+
+	a, _ = NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 16, true)
+	b, _ = NewKeyExchange(privB, &privA.PublicKey, uidB, uidA, 16, false)
+	rA, _ = a.InitKeyExchange()
+	rB, _, sB, _ = b.RespondKeyExchange(rA)
+	_, sA, err := a.ConfirmResponder(rB, sB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sA[0] ^= 1
+	if err := b.ConfirmInitiator(sA); err != errKeyExchangeConfirm {
+		t.Fatalf("expected confirmation failure, got %v", err)
+	}
+
+	// 临时公钥不在曲线上
+	c, _ := NewKeyExchange(privB, &privA.PublicKey, uidB, uidA, 16, false)
+	bad := &PublicKey{Curve: P256Sm2(), X: new(big.Int).Set(rA.X), Y: new(big.Int).Add(rA.Y, one)}
+	if _, _, _, err := c.RespondKeyExchange(bad); err != errKeyExchangePoint {
+		t.Fatalf("expected invalid point, got %v", err)
+	}
+
+	// 角色与调用顺序
+	if _, _, _, err := a.RespondKeyExchange(rA); err != errKeyExchangeRole {
+		t.Fatalf("expected role error, got %v", err)
+	}
+	d, _ := NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 16, true)
+	if _, _, err := d.ConfirmResponder(rB, nil); err != errKeyExchangeState {
+		t.Fatalf("expected state error, got %v", err)
+	}
+}
+
+// 密钥交换的回归向量：私钥与临时私钥固定，为SM2推荐曲线上的计算结果
+func TestKeyExchangeVector(t *testing.T) {
+	privA := kepPrivateKey(t, "3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8")
+	privB := kepPrivateKey(t, "1649AB77A00637BD5E2EFE283FBF353534AA7F7CB89463F208DDBC2920BB0DA0")
+	rA, _ := new(big.Int).SetString("83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563", 16)
+	rB, _ := new(big.Int).SetString("33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80", 16)
+	uidA, uidB := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+
+	a, err := NewKeyExchange(privA, &privB.PublicKey, uidA, uidB, 16, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewKeyExchange(privB, &privA.PublicKey, uidB, uidA, 16, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubA := a.initWithEphemeral(rA)
+	pubB, keyB, sB, err := b.respond(pubA, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, sA, err := a.ConfirmResponder(pubB, sB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ConfirmInitiator(sA); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatalf("key mismatch: %x != %x", keyA, keyB)
+	}
+	if fmt.Sprintf("%X", keyA) != "746327FE811316CFF7E6AF5AE186C34D" {
+		t.Errorf("key mismatch: %X", keyA)
+	}
+	if fmt.Sprintf("%X", sB) != "8220061358FFA0E12A8B7397227E9ED3232F3F1266EF3ED5549C22B92F1910B3" {
+		t.Errorf("SB mismatch: %X", sB)
+	}
+	if fmt.Sprintf("%X", sA) != "7C7B8A46BD0ACDEDF3E309DF75A3B463B342208AA0176EDD7BBDD171E12007F2" {
+		t.Errorf("SA mismatch: %X", sA)
+	}
+}
+
+// exampleCurve GB/T 32918.3附录示例使用的曲线 y^2 = x^3 + ax + b，a不等于-3，
+// 使用仿射坐标实现，仅用于测试
+type exampleCurve struct {
+	params *elliptic.CurveParams
+	a      *big.Int
+}
+
+func newExampleCurve() *exampleCurve {
+	hex := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 16)
+		return v
+	}
+	return &exampleCurve{
+		params: &elliptic.CurveParams{
+			P:       hex("8542D69E4C044F18E8B92435BF6FF7DE457283915C45517D722EDB8B08F1DFC3"),
+			N:       hex("8542D69E4C044F18E8B92435BF6FF7DD297720630485628D5AE74EE7C32E79B7"),
+			B:       hex("63E4C6D3B23B0C849CF84241484BFE48F61D59A5B16BA06E6E12D1DA27C5249A"),
+			Gx:      hex("421DEBD61B62EAB6746434EBC3CC315E32220B3BADD50BDC4C4E6C147FEDD43D"),
+			Gy:      hex("0680512BCBB42C07D47349D2153B70C4E5D7FDFCBFA36EA1A85841B9E46E09A2"),
+			BitSize: 256,
+			Name:    "SM2-EXAMPLE-256",
+		},
+		a: hex("787968B4FA32C3FD2417842E73BBFEFF2F3C848B6831D7E0EC65228B3937E498"),
+	}
+}
+
+func (c *exampleCurve) Params() *elliptic.CurveParams {
+	return c.params
+}
+
+func (c *exampleCurve) IsOnCurve(x, y *big.Int) bool {
+	p := c.params.P
+	y2 := new(big.Int).Mul(y, y)
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Add(rhs, c.a)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, c.params.B)
+	diff := y2.Sub(y2, rhs)
+	return diff.Mod(diff, p).Sign() == 0
+}
+
+// Add 无穷远点使用(0, 0)表示
+func (c *exampleCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	p := c.params.P
+	if x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if x2.Sign() == 0 && y2.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+	var l *big.Int
+	if x1.Cmp(x2) == 0 {
+		sum := new(big.Int).Add(y1, y2)
+		if sum.Mod(sum, p).Sign() == 0 {
+			return new(big.Int), new(big.Int)
+		}
+		// l = (3x^2 + a) / 2y
+		l = new(big.Int).Mul(x1, x1)
+		l.Mul(l, big.NewInt(3))
+		l.Add(l, c.a)
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Lsh(y1, 1), p))
+	} else {
+		// l = (y2 - y1) / (x2 - x1)
+		l = new(big.Int).Sub(y2, y1)
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Mod(new(big.Int).Sub(x2, x1), p), p))
+	}
+	l.Mod(l, p)
+
+	x3 := new(big.Int).Mul(l, l)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, p)
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, l)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, p)
+	return x3, y3
+}
+
+func (c *exampleCurve) Double(x, y *big.Int) (*big.Int, *big.Int) {
+	return c.Add(x, y, x, y)
+}
+
+func (c *exampleCurve) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	rx, ry := new(big.Int), new(big.Int)
+	scalar := new(big.Int).SetBytes(k)
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		rx, ry = c.Double(rx, ry)
+		if scalar.Bit(i) == 1 {
+			rx, ry = c.Add(rx, ry, x, y)
+		}
+	}
+	return rx, ry
+}
+
+func (c *exampleCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return c.ScalarMult(c.params.Gx, c.params.Gy, k)
+}
+
+// GB/T 32918.3-2016 附录中的密钥交换示例，ZA、ZB、KB、SB与SA均与标准一致
+func TestKeyExchangeStandardVector(t *testing.T) {
+	curve := newExampleCurve()
+	newKey := func(s string) *PrivateKey {
+		d, _ := new(big.Int).SetString(s, 16)
+		priv := new(PrivateKey)
+		priv.Curve = curve
+		priv.D = d
+		priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+		return priv
+	}
+	privA := newKey("6FCBA2EF9AE0AB902BC3BDE3FF915D44BA4CC78F88E2F8E7F8996D3B8CCEEDEE")
+	privB := newKey("5E35D7D3F3C54DBAC72E61819E730B019A84208CA3A35E4C2E353DFCCB2A3B53")
+	rA, _ := new(big.Int).SetString("83A2C9C8B96E5AF70BD480B472409A9A327257F1EBB73F5B073354B248668563", 16)
+	rB, _ := new(big.Int).SetString("33FE21940342161C55619C4A0C060293D543C80AF19748CE176D83477DE71C80", 16)
+	uidA, uidB := []byte("ALICE123@YAHOO.COM"), []byte("BILL456@YAHOO.COM")
+
+	a, err := newKeyExchange(curve, curve.a, privA, &privB.PublicKey, uidA, uidB, 16, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newKeyExchange(curve, curve.a, privB, &privA.PublicKey, uidB, uidA, 16, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprintf("%X", a.za) != "E4D1D0C3CA4C7F11BC8FF8CB3F4C02A78F108FA098E51A668487240F75E20F31" {
+		t.Errorf("ZA mismatch: %X", a.za)
+	}
+	if fmt.Sprintf("%X", a.zb) != "6B4B6D0E276691BD4A11BF72F4FB501AE309FDACB72FA6CC336E6656119ABD67" {
+		t.Errorf("ZB mismatch: %X", a.zb)
+	}
+
+	pubA := a.initWithEphemeral(rA)
+	pubB, keyB, sB, err := b.respond(pubA, rB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyA, sA, err := a.ConfirmResponder(pubB, sB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ConfirmInitiator(sA); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(keyA, keyB) {
+		t.Fatalf("key mismatch: %x != %x", keyA, keyB)
+	}
+	if fmt.Sprintf("%X", keyB) != "55B0AC62A6B927BA23703832C853DED4" {
+		t.Errorf("KB mismatch: %X", keyB)
+	}
+	if fmt.Sprintf("%X", sB) != "284C8F198F141B502E81250F1581C7E9EEB4CA6990F9E02DF388B45471F5BC5C" {
+		t.Errorf("SB mismatch: %X", sB)
+	}
+	if fmt.Sprintf("%X", sA) != "23444DAF8ED7534366CB901C84B3BDBB63504F4065C1116C91A4C00697E6CF7A" {
+		t.Errorf("SA mismatch: %X", sA)
+	}
+}
@@ -120,6 +120,11 @@ func zForAffine(x, y *big.Int) *big.Int {
 func (curve sm2P256Curve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
 	var X1, Y1, Z1, X2, Y2, Z2, X3, Y3, Z3 sm2P256FieldElement
 
+	// sm2P256PointAdd的结果在两点相同时不正确，需要使用倍点运算
+	if x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0 {
+		return curve.Double(x1, y1)
+	}
+
 	z1 := zForAffine(x1, y1)
 	z2 := zForAffine(x2, y2)
 	sm2P256FromBig(&X1, x1)
@@ -147,6 +152,10 @@ func (curve sm2P256Curve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.
 	var scalarReversed [32]byte
 	var X, Y, Z, X1, Y1 sm2P256FieldElement
 
+	// 无穷远点与零标量的结果都是无穷远点
+	if (x1.Sign() == 0 && y1.Sign() == 0) || isZeroScalar(k) {
+		return new(big.Int), new(big.Int)
+	}
 	sm2P256FromBig(&X1, x1)
 	sm2P256FromBig(&Y1, y1)
 	sm2P256GetScalar(&scalarReversed, k)
@@ -158,11 +167,19 @@ func (curve sm2P256Curve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
 	var scalarReversed [32]byte
 	var X, Y, Z sm2P256FieldElement
 
+	if isZeroScalar(k) {
+		return new(big.Int), new(big.Int)
+	}
 	sm2P256GetScalar(&scalarReversed, k)
 	sm2P256ScalarBaseMult(&X, &Y, &Z, &scalarReversed)
 	return sm2P256ToAffine(&X, &Y, &Z)
 }
 
+// isZeroScalar 判断标量模N后是否为0
+func isZeroScalar(k []byte) bool {
+	return new(big.Int).Mod(new(big.Int).SetBytes(k), sm2P256.N).Sign() == 0
+}
+
 var sm2P256Precomputed = [9 * 2 * 16 * 2]uint32{
 	0, 0, 0, 0, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0,
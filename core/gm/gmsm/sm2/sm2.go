@@ -25,13 +25,15 @@ import (
 	"crypto/rand"
 	"crypto/sha512"
 	"encoding/asn1"
-	"encoding/binary"
 	"errors"
 	"io"
 	"math/big"
 
 	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
 	//	"github.com/tjfoc/gmsm/sm3"
+
+	kdfUtil "github.com/legendzhouwd/cu_crypto/core/gm/kdf"
 )
 
 const (
@@ -100,30 +102,14 @@ func (pub *PublicKey) Encrypt(data []byte) ([]byte, error) {
 
 var one = new(big.Int).SetInt64(1)
 
-func intToBytes(x int) []byte {
-	var buf = make([]byte, 4)
-
-	binary.BigEndian.PutUint32(buf, uint32(x))
-	return buf
-}
-
+// kdf GB/T 32918.4的密钥派生函数，输入为 x || y，派生的密钥全为0时返回false
 func kdf(x, y []byte, length int) ([]byte, bool) {
-	var c []byte
-
-	ct := 1
-	h := sm3.New()
-	x = append(x, y...)
-	for i, j := 0, (length+31)/32; i < j; i++ {
-		h.Reset()
-		h.Write(x)
-		h.Write(intToBytes(ct))
-		hash := h.Sum(nil)
-		if i+1 == j && length%32 != 0 {
-			c = append(c, hash[:length%32]...)
-		} else {
-			c = append(c, hash...)
-		}
-		ct++
+	z := make([]byte, 0, len(x)+len(y))
+	z = append(z, x...)
+	z = append(z, y...)
+	c, err := kdfUtil.SM2KDF(z, length)
+	if err != nil {
+		return nil, false
 	}
 	for i := 0; i < length; i++ {
 		if c[i] != 0 {
@@ -232,6 +218,47 @@ func Sign(priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
 	return
 }
 
+// SignWithNonceMode 与Sign相同，随机数k按mode生成：
+// nonce.ModeRandom与Sign一致；nonce.ModeDeterministic使用基于HMAC-SM3的RFC 6979构造，
+// 相同的私钥与hash总是得到相同的签名；nonce.ModeHedged在确定性构造中混入新鲜的随机数
+func SignWithNonceMode(priv *PrivateKey, hash []byte, mode nonce.Mode) (r, s *big.Int, err error) {
+	if mode == nonce.ModeRandom {
+		return Sign(priv, hash)
+	}
+
+	c := priv.PublicKey.Curve
+	N := c.Params().N
+	if N.Sign() == 0 {
+		return nil, nil, errZeroParam
+	}
+	g, err := nonce.NewGenerator(c, priv.D, hash, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := new(big.Int).SetBytes(hash)
+	d1Inv := new(big.Int).ModInverse(new(big.Int).Add(priv.D, one), N)
+	for {
+		k, err := g.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		r, _ = c.ScalarBaseMult(k.Bytes())
+		r.Add(r, e)
+		r.Mod(r, N)
+		if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(N) == 0 {
+			continue
+		}
+		s = new(big.Int).Mul(priv.D, r)
+		s.Sub(k, s)
+		s.Mul(s, d1Inv)
+		s.Mod(s, N)
+		if s.Sign() != 0 {
+			return r, s, nil
+		}
+	}
+}
+
 func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	c := pub.Curve
 	N := c.Params().N
@@ -348,6 +375,11 @@ func msgHash(za, msg []byte) (*big.Int, error) {
 
 // ZA = H256(ENTLA || IDA || a || b || xG || yG || xA || yA)
 func ZA(pub *PublicKey, uid []byte) ([]byte, error) {
+	return zaWithCurve(P256Sm2(), sm2P256ToBig(&sm2P256.a), pub, uid)
+}
+
+// zaWithCurve 使用指定曲线的参数计算ZA，a为曲线方程 y^2 = x^3 + ax + b 的系数
+func zaWithCurve(curve elliptic.Curve, a *big.Int, pub *PublicKey, uid []byte) ([]byte, error) {
 	za := sm3.New()
 	uidLen := len(uid)
 	if uidLen >= 8192 {
@@ -357,18 +389,13 @@ func ZA(pub *PublicKey, uid []byte) ([]byte, error) {
 	za.Write([]byte{byte((Entla >> 8) & 0xFF)})
 	za.Write([]byte{byte(Entla & 0xFF)})
 	za.Write(uid)
-	za.Write(sm2P256ToBig(&sm2P256.a).Bytes())
-	za.Write(sm2P256.B.Bytes())
-	za.Write(sm2P256.Gx.Bytes())
-	za.Write(sm2P256.Gy.Bytes())
-
-	xBuf := pub.X.Bytes()
-	yBuf := pub.Y.Bytes()
-	if n := len(xBuf); n < 32 {
-		xBuf = append(zeroByteSlice()[:32-n], xBuf...)
-	}
-	za.Write(xBuf)
-	za.Write(yBuf)
+	params := curve.Params()
+	za.Write(toBytes32(a))
+	za.Write(toBytes32(params.B))
+	za.Write(toBytes32(params.Gx))
+	za.Write(toBytes32(params.Gy))
+	za.Write(toBytes32(pub.X))
+	za.Write(toBytes32(pub.Y))
 	return za.Sum(nil)[:32], nil
 }
 
@@ -447,12 +474,46 @@ func Decrypt(priv *PrivateKey, data []byte) ([]byte, error) {
 	if len(data) == 0 {
 		return []byte{}, nil
 	}
-	data = data[1:]
-	length := len(data) - 96
+	// 检查密文长度与C1，C1不在曲线上时拒绝解密
 	curve := priv.Curve
-	x := new(big.Int).SetBytes(data[:32])
-	y := new(big.Int).SetBytes(data[32:64])
+	x, y, err := CipherC1(curve, data)
+	if err != nil {
+		return nil, err
+	}
 	x2, y2 := curve.ScalarMult(x, y, priv.D.Bytes())
+	return decryptWithSharedPoint(data[1:], x2, y2)
+}
+
+// CipherC1 返回密文中的C1点，并检查其是否在曲线上
+func CipherC1(curve elliptic.Curve, data []byte) (*big.Int, *big.Int, error) {
+	// C2至少为1个字节
+	if len(data) <= c1Size+c3Size || data[0] != 0x04 {
+		return nil, nil, errors.New("CipherC1: invalid ciphertext")
+	}
+	x := new(big.Int).SetBytes(data[1:33])
+	y := new(big.Int).SetBytes(data[33:65])
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil, errors.New("CipherC1: C1 is not on the curve")
+	}
+	return x, y, nil
+}
+
+// DecryptWithSharedPoint 已知 (x2, y2) = d*C1 时完成解密，即计算KDF并校验C3，
+// 用于私钥被分散保存、由多方协同计算d*C1的场景
+func DecryptWithSharedPoint(data []byte, x2, y2 *big.Int) ([]byte, error) {
+	if len(data) <= c1Size+c3Size || data[0] != 0x04 {
+		return nil, errors.New("Decrypt: invalid ciphertext")
+	}
+	c, err := decryptWithSharedPoint(data[1:], x2, y2)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// decryptWithSharedPoint data为去掉0x04前缀的 C1 || C3 || C2
+func decryptWithSharedPoint(data []byte, x2, y2 *big.Int) ([]byte, error) {
+	length := len(data) - 96
 	x2Buf := x2.Bytes()
 	y2Buf := y2.Bytes()
 	if n := len(x2Buf); n < 32 {
@@ -27,6 +27,9 @@ import (
 	"os"
 	"testing"
 	"time"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
 )
 
 func TestSm2(t *testing.T) {
@@ -208,6 +211,88 @@ func TestGenerateKey(t *testing.T) {
 	}
 }
 
+func TestP256Sm2GroupLaw(t *testing.T) {
+	curve := P256Sm2()
+	gx, gy := curve.Params().Gx, curve.Params().Gy
+
+	// P + P 与倍点结果一致
+	ax, ay := curve.Add(gx, gy, gx, gy)
+	dx, dy := curve.Double(gx, gy)
+	if ax.Cmp(dx) != 0 || ay.Cmp(dy) != 0 {
+		t.Errorf("G + G != 2G")
+	}
+
+	// 零标量和无穷远点的数乘结果都是无穷远点
+	if x, y := curve.ScalarBaseMult([]byte{}); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("0 * G is not the point at infinity")
+	}
+	if x, y := curve.ScalarBaseMult(curve.Params().N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("N * G is not the point at infinity")
+	}
+	if x, y := curve.ScalarMult(new(big.Int), new(big.Int), []byte{3}); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("3 * O is not the point at infinity")
+	}
+	px, py := curve.ScalarBaseMult([]byte{7})
+	if x, y := curve.ScalarMult(px, py, curve.Params().N.Bytes()); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("N * P is not the point at infinity")
+	}
+
+	// 无穷远点是加法的单位元，P + (-P) 是无穷远点
+	zero := new(big.Int)
+	if x, y := curve.Add(zero, zero, gx, gy); x.Cmp(gx) != 0 || y.Cmp(gy) != 0 {
+		t.Errorf("O + G != G")
+	}
+	if x, y := curve.Add(gx, gy, zero, zero); x.Cmp(gx) != 0 || y.Cmp(gy) != 0 {
+		t.Errorf("G + O != G")
+	}
+	negGy := new(big.Int).Sub(curve.Params().P, gy)
+	if x, y := curve.Add(gx, gy, gx, negGy); x.Sign() != 0 || y.Sign() != 0 {
+		t.Errorf("G + (-G) is not the point at infinity")
+	}
+
+	// 标量按模N处理
+	k := new(big.Int).Add(curve.Params().N, big.NewInt(5))
+	x5, y5 := curve.ScalarBaseMult([]byte{5})
+	if x, y := curve.ScalarBaseMult(k.Bytes()); x.Cmp(x5) != 0 || y.Cmp(y5) != 0 {
+		t.Errorf("(N + 5) * G != 5 * G")
+	}
+	if x, y := curve.ScalarMult(gx, gy, k.Bytes()); x.Cmp(x5) != 0 || y.Cmp(y5) != 0 {
+		t.Errorf("ScalarMult(G, N + 5) != 5 * G")
+	}
+}
+
+// 公钥坐标不足32字节时，ZA中的xA、yA都要左侧补零到32字节
+func TestZAPadding(t *testing.T) {
+	curve := P256Sm2()
+	params := curve.Params()
+	pub := new(PublicKey)
+	pub.Curve = curve
+	for k := int64(1); ; k++ {
+		pub.X, pub.Y = curve.ScalarBaseMult(big.NewInt(k).Bytes())
+		if len(pub.Y.Bytes()) < 32 {
+			break
+		}
+	}
+
+	uid := []byte("1234567812345678")
+	za, err := ZA(pub, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := new(big.Int).Sub(params.P, big.NewInt(3))
+	h := sm3.New()
+	h.Write([]byte{byte(len(uid) * 8 >> 8), byte(len(uid) * 8)})
+	h.Write(uid)
+	for _, v := range []*big.Int{a, params.B, params.Gx, params.Gy, pub.X, pub.Y} {
+		buf := make([]byte, 32)
+		h.Write(v.FillBytes(buf))
+	}
+	if expected := h.Sum(nil); string(za) != string(expected) {
+		t.Errorf("ZA = %x, want %x", za, expected)
+	}
+}
+
 func BenchmarkSM2(t *testing.B) {
 	t.ReportAllocs()
 	for i := 0; i < t.N; i++ {
@@ -228,3 +313,58 @@ func BenchmarkSM2(t *testing.B) {
 		// }
 	}
 }
+
+// SignWithNonceMode的回归向量：GB/T 32918中的示例私钥，hash = SM3(message)，
+// 随机数由HMAC-SM3构造的RFC 6979生成
+func TestSignWithNonceMode(t *testing.T) {
+	d, _ := new(big.Int).SetString("3945208F7B2144B13F36E38AC6D39F95889393692860B51A42FB81EF4DF7C5B8", 16)
+	priv := new(PrivateKey)
+	priv.Curve = P256Sm2()
+	priv.D = d
+	priv.X, priv.Y = priv.Curve.ScalarBaseMult(d.Bytes())
+
+	vectors := []struct {
+		message string
+		r, s    string
+	}{
+		{
+			"sample",
+			"01FF11BB661F3819661FB3DE71B5836CEE7C8A5B544CCEF186966448EE3F87C9",
+			"4799C1560E356C729783AB81F0F863FA3A4DC14D494F7531281A68083CEC0CF2",
+		},
+		{
+			"test",
+			"B8F436C0F6E2F37ACDF869CEB8869B9B723673A37F9DB18536CADE555C48004E",
+			"1A886E95E20C5EBEE86967EA079EC464DF7F5DBB1A48458D0C5619E5FFF15FBE",
+		},
+	}
+	for _, v := range vectors {
+		hash := sm3.Sm3Sum([]byte(v.message))
+		r, s, err := SignWithNonceMode(priv, hash, nonce.ModeDeterministic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprintf("%064X", r) != v.r || fmt.Sprintf("%064X", s) != v.s {
+			t.Errorf("deterministic signature of %q mismatch: r = %X, s = %X", v.message, r, s)
+		}
+		if !Verify(&priv.PublicKey, hash, r, s) {
+			t.Errorf("deterministic signature of %q is invalid", v.message)
+		}
+
+		// 混合模式每次的签名不同，但都可以验证通过
+		r1, s1, err := SignWithNonceMode(priv, hash, nonce.ModeHedged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r2, s2, err := SignWithNonceMode(priv, hash, nonce.ModeHedged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r1.Cmp(r2) == 0 || r1.Cmp(r) == 0 {
+			t.Errorf("hedged signature of %q repeated", v.message)
+		}
+		if !Verify(&priv.PublicKey, hash, r1, s1) || !Verify(&priv.PublicKey, hash, r2, s2) {
+			t.Errorf("hedged signature of %q is invalid", v.message)
+		}
+	}
+}
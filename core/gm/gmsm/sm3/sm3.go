@@ -176,25 +176,20 @@ func (sm3 *SM3) Write(p []byte) (int, error) {
 // Sum appends the current hash to b and returns the resulting slice.
 // It does not change the underlying hash state.
 func (sm3 *SM3) Sum(in []byte) []byte {
-	sm3.Write(in)
-	msg := sm3.pad()
+	// 在副本上完成填充与压缩，不改变当前的哈希状态
+	d := *sm3
+	d.unhandleMsg = append([]byte{}, sm3.unhandleMsg...)
+	msg := d.pad()
 
 	// Finialize
-	sm3.update(msg, len(msg)/sm3.BlockSize())
-
-	// save hash to in
-	needed := sm3.Size()
-	if cap(in)-len(in) < needed {
-		newIn := make([]byte, len(in), len(in)+needed)
-		copy(newIn, in)
-		in = newIn
-	}
-	out := in[len(in) : len(in)+needed]
+	d.update(msg, len(msg)/d.BlockSize())
 
+	// append hash to in
+	var out [32]byte
 	for i := 0; i < 8; i++ {
-		binary.BigEndian.PutUint32(out[i*4:], sm3.digest[i])
+		binary.BigEndian.PutUint32(out[i*4:], d.digest[i])
 	}
-	return out
+	return append(in, out[:]...)
 }
 
 func Sm3Sum(data []byte) []byte {
@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -63,3 +64,30 @@ func BenchmarkSm3(t *testing.B) {
 		Sm3Sum(msg)
 	}
 }
+
+// GB/T 32905-2016 附录A，Sum按hash.Hash的约定追加摘要且不改变哈希状态
+func TestSm3Vectors(t *testing.T) {
+	vectors := []struct {
+		msg    string
+		digest string
+	}{
+		{"abc", "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"},
+		{strings.Repeat("abcd", 16), "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"},
+	}
+	for _, v := range vectors {
+		if got := byteToString(Sm3Sum([]byte(v.msg))); got != v.digest {
+			t.Errorf("SM3(%q) = %s, want %s", v.msg, got, v.digest)
+		}
+
+		h := New()
+		h.Write([]byte(v.msg[:1]))
+		first := h.Sum([]byte("prefix"))
+		if string(first[:6]) != "prefix" || len(first) != 6+h.Size() {
+			t.Errorf("Sum should append the digest to its input")
+		}
+		h.Write([]byte(v.msg[1:]))
+		if got := byteToString(h.Sum(nil)); got != v.digest {
+			t.Errorf("Sum changed the hash state: %s, want %s", got, v.digest)
+		}
+	}
+}
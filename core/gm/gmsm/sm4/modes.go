@@ -0,0 +1,125 @@
+package sm4
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"errors"
+)
+
+// SM4的常用工作模式：
+// ECB、CBC按PKCS#7填充明文；CTR为流模式，不需要填充；GCM为带认证的加密（AEAD），推荐优先使用。
+// CBC与CTR的iv长度为16字节，同一个密钥下不能重复使用；GCM的nonce长度为12字节，同一个密钥下同样不能重复。
+
+var (
+	InvalidIVSizeError  = errors.New("The SM4 iv should be 16 bytes")
+	InvalidPaddingError = errors.New("The PKCS#7 padding is invalid")
+)
+
+// PKCS7Padding 按PKCS#7将数据填充为blockSize的整数倍，原数据长度恰好为整数倍时补充一个完整的分组
+func PKCS7Padding(src []byte, blockSize int) []byte {
+	padding := blockSize - len(src)%blockSize
+	return append(src, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+// PKCS7UnPadding 去除PKCS#7填充
+func PKCS7UnPadding(src []byte, blockSize int) ([]byte, error) {
+	length := len(src)
+	if length == 0 || length%blockSize != 0 {
+		return nil, InvalidPaddingError
+	}
+	padding := int(src[length-1])
+	if padding == 0 || padding > blockSize {
+		return nil, InvalidPaddingError
+	}
+	for _, b := range src[length-padding:] {
+		if int(b) != padding {
+			return nil, InvalidPaddingError
+		}
+	}
+	return src[:length-padding], nil
+}
+
+// EncryptECB ECB模式加密，明文按PKCS#7填充。相同的明文分组得到相同的密文分组，仅用于兼容已有系统
+func EncryptECB(key, plainText []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	src := PKCS7Padding(append([]byte{}, plainText...), BlockSize)
+	dst := make([]byte, len(src))
+	for i := 0; i < len(src); i += BlockSize {
+		block.Encrypt(dst[i:], src[i:])
+	}
+	return dst, nil
+}
+
+// DecryptECB ECB模式解密并去除PKCS#7填充
+func DecryptECB(key, cipherText []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(cipherText) == 0 || len(cipherText)%BlockSize != 0 {
+		return nil, InvalidBlockSizeError
+	}
+	dst := make([]byte, len(cipherText))
+	for i := 0; i < len(cipherText); i += BlockSize {
+		block.Decrypt(dst[i:], cipherText[i:])
+	}
+	return PKCS7UnPadding(dst, BlockSize)
+}
+
+// EncryptCBC CBC模式加密，明文按PKCS#7填充
+func EncryptCBC(key, iv, plainText []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != BlockSize {
+		return nil, InvalidIVSizeError
+	}
+	src := PKCS7Padding(append([]byte{}, plainText...), BlockSize)
+	dst := make([]byte, len(src))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(dst, src)
+	return dst, nil
+}
+
+// DecryptCBC CBC模式解密并去除PKCS#7填充
+func DecryptCBC(key, iv, cipherText []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != BlockSize {
+		return nil, InvalidIVSizeError
+	}
+	if len(cipherText) == 0 || len(cipherText)%BlockSize != 0 {
+		return nil, InvalidBlockSizeError
+	}
+	dst := make([]byte, len(cipherText))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(dst, cipherText)
+	return PKCS7UnPadding(dst, BlockSize)
+}
+
+// XORKeyStreamCTR CTR模式加解密，加密与解密是同一个操作
+func XORKeyStreamCTR(key, iv, in []byte) ([]byte, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != BlockSize {
+		return nil, InvalidIVSizeError
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// NewGCM 创建SM4-GCM（RFC 8998），nonce为12字节，认证标签为16字节
+func NewGCM(key []byte) (cipher.AEAD, error) {
+	block, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
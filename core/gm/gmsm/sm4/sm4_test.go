@@ -0,0 +1,129 @@
+package sm4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+// GB/T 32907-2016 附录A
+func TestGBT32907(t *testing.T) {
+	key := mustHex(t, "0123456789abcdeffedcba9876543210")
+	block, err := NewCipher(key)
+	require.NoError(t, err)
+
+	// 示例1：加密一次
+	dst := make([]byte, BlockSize)
+	block.Encrypt(dst, key)
+	require.Equal(t, "681edf34d206965e86b3e94f536e4246", hex.EncodeToString(dst))
+
+	out := make([]byte, BlockSize)
+	block.Decrypt(out, dst)
+	require.Equal(t, key, out)
+
+	// 示例2：使用同一密钥对明文反复加密1000000次
+	if testing.Short() {
+		t.Skip("skipping 1000000 iterations in short mode")
+	}
+	copy(dst, key)
+	for i := 0; i < 1000000; i++ {
+		block.Encrypt(dst, dst)
+	}
+	require.Equal(t, "595298c7c6fd271f0402f804c33d3f66", hex.EncodeToString(dst))
+}
+
+// RFC 8998 附录A.1
+func TestGCM(t *testing.T) {
+	key := mustHex(t, "0123456789abcdeffedcba9876543210")
+	nonce := mustHex(t, "00001234567800000000abcd")
+	aad := mustHex(t, "feedfacedeadbeeffeedfacedeadbeefabaddad2")
+	plainText := mustHex(t, "aaaaaaaaaaaaaaaabbbbbbbbbbbbbbbbccccccccccccccccdddddddddddddddd"+
+		"eeeeeeeeeeeeeeeeffffffffffffffffeeeeeeeeeeeeeeeeaaaaaaaaaaaaaaaa")
+	expected := "17f399f08c67d5ee19d0dc9969c4bb7d5fd46fd3756489069157b282bb200735" +
+		"d82710ca5c22f0ccfa7cbf93d496ac15a56834cbcf98c397b4024a2691233b8d" +
+		"83de3541e4c2b58177e065a9bf7b62ec"
+
+	aead, err := NewGCM(key)
+	require.NoError(t, err)
+	sealed := aead.Seal(nil, nonce, plainText, aad)
+	require.Equal(t, expected, hex.EncodeToString(sealed))
+
+	opened, err := aead.Open(nil, nonce, sealed, aad)
+	require.NoError(t, err)
+	require.Equal(t, plainText, opened)
+
+	sealed[0] ^= 1
+	_, err = aead.Open(nil, nonce, sealed, aad)
+	require.Error(t, err)
+}
+
+func TestModes(t *testing.T) {
+	key := mustHex(t, "0123456789abcdeffedcba9876543210")
+	iv := mustHex(t, "000102030405060708090a0b0c0d0e0f")
+
+	for _, size := range []int{0, 1, 15, 16, 17, 64} {
+		msg := bytes.Repeat([]byte{0xab}, size)
+
+		cipherText, err := EncryptECB(key, msg)
+		require.NoError(t, err)
+		require.Equal(t, (size/BlockSize+1)*BlockSize, len(cipherText))
+		plainText, err := DecryptECB(key, cipherText)
+		require.NoError(t, err)
+		require.Equal(t, msg, plainText)
+
+		cipherText, err = EncryptCBC(key, iv, msg)
+		require.NoError(t, err)
+		plainText, err = DecryptCBC(key, iv, cipherText)
+		require.NoError(t, err)
+		require.Equal(t, msg, plainText)
+
+		cipherText, err = XORKeyStreamCTR(key, iv, msg)
+		require.NoError(t, err)
+		require.Equal(t, size, len(cipherText))
+		plainText, err = XORKeyStreamCTR(key, iv, cipherText)
+		require.NoError(t, err)
+		require.Equal(t, msg, plainText)
+	}
+
+	// 单个分组的ECB与分组密码直接加密一致
+	block, err := NewCipher(key)
+	require.NoError(t, err)
+	expected := make([]byte, BlockSize)
+	block.Encrypt(expected, key)
+	cipherText, err := EncryptECB(key, key)
+	require.NoError(t, err)
+	require.Equal(t, expected, cipherText[:BlockSize])
+
+	_, err = NewCipher(key[:15])
+	require.Equal(t, InvalidKeySizeError, err)
+	_, err = EncryptCBC(key, iv[:8], key)
+	require.Equal(t, InvalidIVSizeError, err)
+	_, err = DecryptCBC(key, iv, key[:15])
+	require.Equal(t, InvalidBlockSizeError, err)
+}
+
+func TestPKCS7(t *testing.T) {
+	padded := PKCS7Padding([]byte("abc"), BlockSize)
+	require.Equal(t, BlockSize, len(padded))
+	require.Equal(t, byte(13), padded[BlockSize-1])
+
+	data, err := PKCS7UnPadding(padded, BlockSize)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), data)
+
+	padded[BlockSize-2] = 12
+	_, err = PKCS7UnPadding(padded, BlockSize)
+	require.Equal(t, InvalidPaddingError, err)
+
+	padded[BlockSize-1] = 0
+	_, err = PKCS7UnPadding(padded, BlockSize)
+	require.Equal(t, InvalidPaddingError, err)
+}
@@ -2,6 +2,7 @@ package hash
 
 import (
 	"crypto/hmac"
+	"crypto/sha256"
 	"crypto/sha512"
 
 	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
@@ -19,6 +20,19 @@ func HashUsingSM3(data []byte) []byte {
 	return out
 }
 
+func HashUsingSha256(data []byte) []byte {
+	h := sha256.New()
+	h.Write(data)
+	out := h.Sum(nil)
+
+	return out
+}
+
+// DoubleSha256 计算两次SHA256，用于xuperchain格式的NIST地址与Ed25519地址的校验码
+func DoubleSha256(data []byte) []byte {
+	return HashUsingSha256(HashUsingSha256(data))
+}
+
 // Ripemd160，这种hash算法可以缩短长度
 func HashUsingRipemd160(data []byte) []byte {
 	h := ripemd160.New()
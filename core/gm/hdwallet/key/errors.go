@@ -7,4 +7,6 @@ import (
 var (
 	// 参数错误
 	ErrParam = errors.New("param is illegal")
+	// 解密失败
+	ErrDecrypt = errors.New("failed to decrypt")
 )
@@ -32,7 +32,7 @@ func GetBinaryEcdsaPrivateKeyFromFile(path string, password string) ([]byte, err
 	//	newPassword := hash.DoubleSha256([]byte(password))
 	newPassword := hash.HashUsingSM3([]byte(password))
 
-	originalContent, err := aesUtil.Decrypt(content, newPassword)
+	originalContent, err := decryptContent(content, newPassword)
 	if err != nil {
 		log.Printf("Decrypt private key file failed, the err is %v", err)
 		return nil, err
@@ -47,7 +47,7 @@ func GetBinaryEcdsaPrivateKeyFromString(encryptPrivateKey string, password strin
 	//	newPassword := hash.DoubleSha256([]byte(password))
 	newPassword := hash.HashUsingSM3([]byte(password))
 
-	originalContent, err := aesUtil.Decrypt([]byte(encryptPrivateKey), newPassword)
+	originalContent, err := decryptContent([]byte(encryptPrivateKey), newPassword)
 	if err != nil {
 		log.Printf("Decrypt private key string failed, the err is %v", err)
 		return nil, err
@@ -165,7 +165,7 @@ func DecryptByKey(cipherInfo string, key string) (string, error) {
 	newPassword := hash.HashUsingSM3([]byte(key))
 
 	// 解密cipherInfo
-	info, err := aesUtil.Decrypt([]byte(cipherInfo), newPassword)
+	info, err := decryptContent([]byte(cipherInfo), newPassword)
 	if err != nil {
 		return "", err
 	}
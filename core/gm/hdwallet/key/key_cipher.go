@@ -0,0 +1,86 @@
+package key
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm4"
+	"github.com/legendzhouwd/cu_crypto/core/gm/kdf"
+
+	accountUtil "github.com/legendzhouwd/cu_crypto/core/gm/account"
+	aesUtil "github.com/legendzhouwd/cu_crypto/core/gm/aes"
+)
+
+// 国密账户的私钥与助记词使用SM4-GCM加密保存，密文格式为 sm4Magic || salt(16字节) || nonce(12字节) || SM4-GCM密文，
+// SM4的密钥由PBKDF2-SM3使用每个文件随机生成的salt派生；其余账户仍然使用AES-CBC。
+// 解密时根据密文格式自动识别，之前使用AES保存的国密账户文件仍然可以读取。
+var sm4Magic = []byte("SM4\x01")
+
+// 加密账户信息，国密账户使用SM4，其余使用AES
+func encryptContent(content, key []byte, isGm bool) ([]byte, error) {
+	if !isGm {
+		return aesUtil.Encrypt(content, key)
+	}
+
+	salt := make([]byte, kdf.SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	aead, err := newSM4GCM(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	cipherInfo := append(append(append([]byte{}, sm4Magic...), salt...), nonce...)
+	return aead.Seal(cipherInfo, nonce, content, nil), nil
+}
+
+// 解密账户信息，SM4格式认证失败时按AES解密
+func decryptContent(cipherInfo, key []byte) ([]byte, error) {
+	if bytes.HasPrefix(cipherInfo, sm4Magic) && len(cipherInfo) >= len(sm4Magic)+kdf.SaltSize {
+		salt := cipherInfo[len(sm4Magic) : len(sm4Magic)+kdf.SaltSize]
+		body := cipherInfo[len(sm4Magic)+kdf.SaltSize:]
+		aead, err := newSM4GCM(key, salt)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) >= aead.NonceSize()+aead.Overhead() {
+			content, err := aead.Open(nil, body[:aead.NonceSize()], body[aead.NonceSize():], nil)
+			if err == nil {
+				return content, nil
+			}
+		}
+	}
+
+	// AES-CBC的密文长度必须是分组长度的整数倍
+	if len(cipherInfo) == 0 || len(cipherInfo)%aes.BlockSize != 0 {
+		return nil, ErrDecrypt
+	}
+	return aesUtil.Decrypt(cipherInfo, key)
+}
+
+// 判断json格式的私钥是否是国密账户
+func isGmPrivateKey(jsonPrivateKey string) bool {
+	privateKey := new(accountUtil.ECDSAPrivateKey)
+	if err := json.Unmarshal([]byte(jsonPrivateKey), privateKey); err != nil {
+		return false
+	}
+	return privateKey.Curvname == config.CurveGm
+}
+
+// 使用PBKDF2-SM3从密码与salt派生SM4密钥
+func newSM4GCM(key, salt []byte) (cipher.AEAD, error) {
+	sm4Key, err := kdf.PBKDF2(key, salt, kdf.DefaultPBKDF2Iterations, sm4.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return sm4.NewGCM(sm4Key)
+}
@@ -0,0 +1,66 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/kdf"
+
+	accountUtil "github.com/legendzhouwd/cu_crypto/core/gm/account"
+	aesUtil "github.com/legendzhouwd/cu_crypto/core/gm/aes"
+)
+
+func TestEncryptAccountWithSM4(t *testing.T) {
+	ecdsaAccount, err := accountUtil.CreateNewAccountWithMnemonic(1, 1, config.Gm)
+	require.NoError(t, err)
+	require.True(t, isGmPrivateKey(ecdsaAccount.JsonPrivateKey))
+
+	accountToCloud, err := EncryptAccount(ecdsaAccount, "123456")
+	require.NoError(t, err)
+	require.Equal(t, string(sm4Magic), accountToCloud.JsonEncryptedPrivateKey[:len(sm4Magic)])
+	require.Equal(t, string(sm4Magic), accountToCloud.EncryptedMnemonic[:len(sm4Magic)])
+
+	privateKey, err := GetBinaryEcdsaPrivateKeyFromString(accountToCloud.JsonEncryptedPrivateKey, "123456")
+	require.NoError(t, err)
+	require.Equal(t, ecdsaAccount.JsonPrivateKey, string(privateKey))
+
+	_, err = GetEcdsaPrivateKeyFromString(accountToCloud.JsonEncryptedPrivateKey, "654321")
+	require.Error(t, err)
+}
+
+func TestDecryptLegacyAES(t *testing.T) {
+	ecdsaAccount, err := accountUtil.CreateNewAccountWithMnemonic(1, 1, config.Gm)
+	require.NoError(t, err)
+
+	// 之前的版本使用AES保存国密账户
+	legacy, err := aesUtil.Encrypt([]byte(ecdsaAccount.JsonPrivateKey), hash.HashUsingSM3([]byte("123456")))
+	require.NoError(t, err)
+	privateKey, err := GetBinaryEcdsaPrivateKeyFromString(string(legacy), "123456")
+	require.NoError(t, err)
+	require.Equal(t, ecdsaAccount.JsonPrivateKey, string(privateKey))
+}
+
+func TestEncryptContentSalt(t *testing.T) {
+	key := hash.HashUsingSM3([]byte("123456"))
+	content := []byte("content")
+
+	// 每次加密使用不同的salt与nonce
+	first, err := encryptContent(content, key, true)
+	require.NoError(t, err)
+	second, err := encryptContent(content, key, true)
+	require.NoError(t, err)
+	require.NotEqual(t, first[len(sm4Magic):len(sm4Magic)+kdf.SaltSize], second[len(sm4Magic):len(sm4Magic)+kdf.SaltSize])
+	require.NotEqual(t, first, second)
+
+	plainText, err := decryptContent(first, key)
+	require.NoError(t, err)
+	require.Equal(t, content, plainText)
+
+	// salt被篡改时无法解密
+	first[len(sm4Magic)] ^= 1
+	_, err = decryptContent(first, key)
+	require.Error(t, err)
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/xuperchain/crypto/common/account"
 
 	accountUtil "github.com/legendzhouwd/cu_crypto/core/gm/account"
-	aesUtil "github.com/legendzhouwd/cu_crypto/core/gm/aes"
 )
 
 type AccountInfo struct {
@@ -53,9 +52,11 @@ func EncryptAccount(info *account.ECDSAAccount, password string) (*account.ECDSA
 	// 将aes对称加密的密钥扩展至32字节
 	//	newPassword := hash.DoubleSha256([]byte(password))
 	newPassword := hash.HashUsingSM3([]byte(password))
+	// 国密账户使用SM4加密
+	isGm := isGmPrivateKey(info.JsonPrivateKey)
 
 	// 加密私钥
-	encryptedPrivateKey, err := aesUtil.Encrypt([]byte(info.JsonPrivateKey), newPassword)
+	encryptedPrivateKey, err := encryptContent([]byte(info.JsonPrivateKey), newPassword, isGm)
 	if err != nil {
 		return nil, err
 	}
@@ -67,7 +68,7 @@ func EncryptAccount(info *account.ECDSAAccount, password string) (*account.ECDSA
 
 	// 加密助记词
 	if info.Mnemonic != "" {
-		encryptedMnemonic, err := aesUtil.Encrypt([]byte(info.Mnemonic), newPassword)
+		encryptedMnemonic, err := encryptContent([]byte(info.Mnemonic), newPassword, isGm)
 		if err != nil {
 			return nil, err
 		}
@@ -137,15 +138,15 @@ func savePrivateKey(path string, password string, ecdsaAccount *account.ECDSAAcc
 	//	newPassword := hash.DoubleSha256([]byte(password))
 	newPassword := hash.HashUsingSM3([]byte(password))
 
-	// 加密密钥文件
-	encryptContent, err := aesUtil.Encrypt([]byte(ecdsaAccount.JsonPrivateKey), newPassword)
+	// 加密密钥文件，国密账户使用SM4加密
+	encryptedContent, err := encryptContent([]byte(ecdsaAccount.JsonPrivateKey), newPassword, isGmPrivateKey(ecdsaAccount.JsonPrivateKey))
 	if err != nil {
 		log.Printf("encrypt private key failed, the err is %v", err)
 		return err
 	}
 
 	//	log.Printf("Export mnemonic file is successful, the path is %v", path+"mnemonic")
-	err = writeFileUsingFilename(path+"private.key", encryptContent)
+	err = writeFileUsingFilename(path+"private.key", encryptedContent)
 	if err != nil {
 		log.Printf("Export private key file failed, the err is %v", err)
 		return err
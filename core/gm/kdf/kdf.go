@@ -0,0 +1,87 @@
+package kdf
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 基于SM3的密钥派生：
+// HMAC-SM3:   RFC 2104的HMAC，哈希函数为SM3
+// HKDF-SM3:   RFC 5869的HKDF，用于从密钥协商得到的共享秘密派生密钥
+// PBKDF2-SM3: RFC 8018的PBKDF2，PRF为HMAC-SM3，用于从口令派生密钥，需要随机的盐与足够的迭代次数
+// SM2KDF:     GB/T 32918.4-2016 5.4.3的密钥派生函数，SM2加密与密钥交换使用
+
+const (
+	// DefaultPBKDF2Iterations 从口令派生密钥时的默认迭代次数
+	DefaultPBKDF2Iterations = 10000
+	// SaltSize 随机盐的推荐长度
+	SaltSize = 16
+)
+
+var (
+	InvalidKeyLengthError  = errors.New("The length of the derived key is invalid")
+	InvalidIterationsError = errors.New("The iteration count should be positive")
+)
+
+// NewHMAC 创建HMAC-SM3
+func NewHMAC(key []byte) hash.Hash {
+	return hmac.New(sm3.New, key)
+}
+
+// HMAC 计算HMAC-SM3
+func HMAC(key, data []byte) []byte {
+	mac := NewHMAC(key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// HKDF 使用HKDF-SM3从secret派生length字节的密钥，salt与info可以为nil
+func HKDF(secret, salt, info []byte, length int) ([]byte, error) {
+	// HKDF最多输出255个分组
+	if length <= 0 || length > 255*sm3.New().Size() {
+		return nil, InvalidKeyLengthError
+	}
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sm3.New, secret, salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// PBKDF2 使用PBKDF2-SM3从口令派生keyLen字节的密钥
+func PBKDF2(password, salt []byte, iter, keyLen int) ([]byte, error) {
+	if iter <= 0 {
+		return nil, InvalidIterationsError
+	}
+	if keyLen <= 0 {
+		return nil, InvalidKeyLengthError
+	}
+	return pbkdf2.Key(password, salt, iter, keyLen, sm3.New), nil
+}
+
+// SM2KDF GB/T 32918.4的密钥派生函数：K = SM3(Z || ct) || SM3(Z || ct+1) || ...，ct为从1开始的32位大端计数器，截取前length字节
+func SM2KDF(z []byte, length int) ([]byte, error) {
+	if length <= 0 || uint64(length) > uint64(1<<32-1)*32 {
+		return nil, InvalidKeyLengthError
+	}
+
+	key := make([]byte, 0, length+31)
+	var counter [4]byte
+	h := sm3.New()
+	for ct := uint32(1); len(key) < length; ct++ {
+		binary.BigEndian.PutUint32(counter[:], ct)
+		h.Reset()
+		h.Write(z)
+		h.Write(counter[:])
+		key = h.Sum(key)
+	}
+	return key[:length], nil
+}
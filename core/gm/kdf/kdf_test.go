@@ -0,0 +1,163 @@
+package kdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// hmacSM3 按RFC 2104直接计算 SM3((K ^ opad) || SM3((K ^ ipad) || m))，与HMAC的实现相互独立
+func hmacSM3(key, data []byte) []byte {
+	if len(key) > 64 {
+		key = sm3.Sm3Sum(key)
+	}
+	k := make([]byte, 64)
+	copy(k, key)
+	ipad := bytes.Repeat([]byte{0x36}, 64)
+	opad := bytes.Repeat([]byte{0x5c}, 64)
+	for i := range k {
+		ipad[i] ^= k[i]
+		opad[i] ^= k[i]
+	}
+	inner := sm3.Sm3Sum(append(ipad, data...))
+	return sm3.Sm3Sum(append(opad, inner...))
+}
+
+func TestSM3(t *testing.T) {
+	// GB/T 32905-2016 附录A.1
+	require.Equal(t, "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0",
+		hex.EncodeToString(sm3.Sm3Sum([]byte("abc"))))
+}
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231中测试用例1、2、6的输入，使用SM3计算，结果与OpenSSL的HMAC-SM3一致
+	for _, v := range []struct {
+		key, data []byte
+		mac       string
+	}{
+		{bytes.Repeat([]byte{0x0b}, 20), []byte("Hi There"),
+			"51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70"},
+		{[]byte("Jefe"), []byte("what do ya want for nothing?"),
+			"2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882"},
+		{bytes.Repeat([]byte{0xaa}, 131), []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+			"b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0"},
+	} {
+		require.Equal(t, v.mac, hex.EncodeToString(HMAC(v.key, v.data)))
+	}
+
+	for _, key := range [][]byte{
+		bytes.Repeat([]byte{0x0b}, 20),
+		[]byte("Jefe"),
+		bytes.Repeat([]byte{0xaa}, 131),
+	} {
+		data := []byte("what do ya want for nothing?")
+		require.Equal(t, hmacSM3(key, data), HMAC(key, data))
+
+		mac := NewHMAC(key)
+		mac.Write(data[:10])
+		mac.Write(data[10:])
+		require.Equal(t, hmacSM3(key, data), mac.Sum(nil))
+	}
+}
+
+func TestHKDF(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+
+	// RFC 5869 2.2与2.3：PRK = HMAC(salt, IKM)，T(i) = HMAC(PRK, T(i-1) || info || i)
+	prk := hmacSM3(salt, secret)
+	t1 := hmacSM3(prk, append(append([]byte{}, info...), 1))
+	t2 := hmacSM3(prk, append(append(append([]byte{}, t1...), info...), 2))
+
+	key, err := HKDF(secret, salt, info, 42)
+	require.NoError(t, err)
+	require.Equal(t, append(t1, t2...)[:42], key)
+	// RFC 5869测试用例1的输入，结果与OpenSSL的HKDF-SM3一致
+	require.Equal(t, "c69fe91b7aaee2dd5718d72dcaee0cce93f1b8e41f792da51261b6a517e68b36ed2c595572b01dfa359b",
+		hex.EncodeToString(key))
+
+	_, err = HKDF(secret, salt, info, 0)
+	require.Equal(t, InvalidKeyLengthError, err)
+	_, err = HKDF(secret, salt, info, 255*32+1)
+	require.Equal(t, InvalidKeyLengthError, err)
+}
+
+func TestPBKDF2(t *testing.T) {
+	password, salt := []byte("password"), []byte("salt")
+
+	// RFC 8018 5.2：T(i) = U1 ^ U2 ^ ... ^ Uc，U1 = PRF(P, S || INT(i))，Uj = PRF(P, U(j-1))
+	block := func(i uint32, iter int) []byte {
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], i)
+		u := hmacSM3(password, append(append([]byte{}, salt...), index[:]...))
+		t := append([]byte{}, u...)
+		for j := 1; j < iter; j++ {
+			u = hmacSM3(password, u)
+			for k := range t {
+				t[k] ^= u[k]
+			}
+		}
+		return t
+	}
+
+	for _, iter := range []int{1, 2, 100} {
+		key, err := PBKDF2(password, salt, iter, 40)
+		require.NoError(t, err)
+		require.Equal(t, append(block(1, iter), block(2, iter)...)[:40], key)
+	}
+
+	// 结果与OpenSSL的PBKDF2-HMAC-SM3一致
+	for _, v := range []struct {
+		iter int
+		key  string
+	}{
+		{1, "4612f922a1fdcefaf4312fc6f8f3322b489cbf24f2ea361b44c2bd8fa2c6dcb0e2bea084418ffb4f"},
+		{2, "fee723a2bc966e11dffb66133f4e8df577383c78ade30e3298edbd3e54ed85b7650006f9e15d3798"},
+		{4096, "b6e8f2074c87432b78f62e5ced980fdff89e86af2f693dab1638e2b3683045dd844438500eead50c"},
+	} {
+		key, err := PBKDF2(password, salt, v.iter, 40)
+		require.NoError(t, err)
+		require.Equal(t, v.key, hex.EncodeToString(key))
+	}
+
+	_, err := PBKDF2(password, salt, 0, 32)
+	require.Equal(t, InvalidIterationsError, err)
+	_, err = PBKDF2(password, salt, 1, 0)
+	require.Equal(t, InvalidKeyLengthError, err)
+}
+
+func TestSM2KDF(t *testing.T) {
+	z := []byte("shared secret x2 || y2")
+
+	// GB/T 32918.4 5.4.3：Ha(i) = SM3(Z || ct)
+	ha1 := sm3.Sm3Sum(append(append([]byte{}, z...), 0, 0, 0, 1))
+	ha2 := sm3.Sm3Sum(append(append([]byte{}, z...), 0, 0, 0, 2))
+	for _, length := range []int{1, 16, 32, 33, 64} {
+		key, err := SM2KDF(z, length)
+		require.NoError(t, err)
+		require.Equal(t, append(ha1, ha2...)[:length], key)
+	}
+
+	// GB/T 32918.4-2016 附录中加密示例的 t = KDF(x2 || y2, klen)，明文为"encryption standard"，
+	// C2 = M ^ t与标准一致
+	x2y2, _ := hex.DecodeString("64D20D27D0632957F8028C1E024F6B02EDF23102A566C932AE8BD613A8E865FE" +
+		"58D225ECA784AE300A81A2D48281A828E1CEDF11C4219099840265375077BF78")
+	message := []byte("encryption standard")
+	key, err := SM2KDF(x2y2, len(message))
+	require.NoError(t, err)
+	require.Equal(t, "006E30DAE231B071DFAD8AA379E90264491603", strings.ToUpper(hex.EncodeToString(key)))
+	for i := range key {
+		key[i] ^= message[i]
+	}
+	require.Equal(t, "650053A89B41C418B0C3AAD00D886C00286467", strings.ToUpper(hex.EncodeToString(key)))
+
+	_, err = SM2KDF(z, 0)
+	require.Equal(t, InvalidKeyLengthError, err)
+}
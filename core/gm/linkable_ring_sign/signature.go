@@ -0,0 +1,384 @@
+package linkable_ring_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 可链接环签名（LSAG，Liu-Wei-Wong），与schnorr_ring_sign一样隐藏真实的签名者，
+// 但签名中包含与私钥一一对应的KeyImage：I = x*Hp(P)，同一私钥产生的两个签名可以被链接，
+// 可以用于匿名投票、匿名领取等需要防止重复的场景。
+//
+// Hp为哈希到曲线，c(i+1) = H(Members || I || m || s(i)*G + c(i)*P(i) || s(i)*Hp(P(i)) + c(i)*I)
+//
+// 签名过程（r为签名者在环中的位置）：
+//  1. 随机选择k，c(r+1) = H(... || k*G || k*Hp(P(r)))
+//  2. 对其他成员i随机选择s(i)，依次计算c(i+1)
+//  3. s(r) = k - c(r)*x(r)，使得环闭合
+//  4. 输出 (Members, I, c(0), s(0), ..., s(R-1))
+//
+// Hp只依赖公钥，所以同一私钥在不同的环、不同的消息上产生的签名都可以被链接。
+
+const (
+	MinimumParticipant = 2
+
+	tagChallenge = "cu_crypto/lsag/challenge"
+	tagKeyImage  = "cu_crypto/lsag/key_image"
+)
+
+var (
+	InvalidInputParamsError        = errors.New("Invalid input params")
+	TooSmallNumOfkeysError         = errors.New("The total num of keys should be greater than one")
+	NotExactTheSameCurveInputError = errors.New("The curve is not same as curve of members")
+	UnsupportedCurveError          = errors.New("The curve is not supported yet")
+	DuplicateMemberError           = errors.New("The public key appears more than once in the ring")
+	KeyParamNotMatchError          = errors.New("The public keys do not match the members of the ring signature")
+	EmptyMessageError              = errors.New("Message to be sign should not be nil")
+	NotValidSignatureError         = errors.New("The linkable ring signature is invalid")
+)
+
+// Sign 使用privateKey生成可链接环签名，keys为环中其他成员的公钥，签名者会被随机插入到环中
+func Sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte) ([]byte, error) {
+	if privateKey == nil || privateKey.D == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	if len(keys)+1 < MinimumParticipant {
+		return nil, TooSmallNumOfkeysError
+	}
+	curve := privateKey.Curve
+	if err := checkCurve(curve); err != nil {
+		return nil, err
+	}
+
+	// 随机选择签名者在环中的位置
+	lenOfRing := len(keys) + 1
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(lenOfRing)))
+	if err != nil {
+		return nil, err
+	}
+	signerIndex := int(index.Int64())
+	members := make([]*ecdsa.PublicKey, 0, lenOfRing)
+	members = append(members, keys[:signerIndex]...)
+	members = append(members, &privateKey.PublicKey)
+	members = append(members, keys[signerIndex:]...)
+	if err := checkMembers(curve, members); err != nil {
+		return nil, err
+	}
+
+	hps, err := hashMembersToCurve(curve, members)
+	if err != nil {
+		return nil, err
+	}
+	n := curve.Params().N
+
+	// I = x*Hp(P)
+	keyImage := hps[signerIndex].ScalarMult(privateKey.D)
+	prefix := challengePrefix(curve, members, keyImage, message)
+
+	k, err := randomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	allOfC := make([]*big.Int, lenOfRing)
+	allOfS := make([]*big.Int, lenOfRing)
+
+	// c(r+1) = H(... || k*G || k*Hp(P(r)))
+	l := ecc.ScalarBaseMult(curve, k)
+	r := hps[signerIndex].ScalarMult(k)
+	allOfC[(signerIndex+1)%lenOfRing] = challenge(curve, prefix, l, r)
+
+	for i := (signerIndex + 1) % lenOfRing; i != signerIndex; i = (i + 1) % lenOfRing {
+		allOfS[i], err = randomScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		l, r := ringPoints(members[i], hps[i], keyImage, allOfC[i], allOfS[i])
+		allOfC[(i+1)%lenOfRing] = challenge(curve, prefix, l, r)
+	}
+
+	// s(r) = k - c(r)*x(r)
+	s := new(big.Int).Mul(allOfC[signerIndex], privateKey.D)
+	s.Sub(k, s)
+	allOfS[signerIndex] = s.Mod(s, n)
+
+	sig := &common.LinkableRingSignature{
+		CurveName: curve.Params().Name,
+		Members:   make([]*common.PublicKeyFactor, lenOfRing),
+		KeyImage:  &common.PublicKeyFactor{X: keyImage.X, Y: keyImage.Y},
+		C:         allOfC[0],
+		S:         allOfS,
+	}
+	for i, key := range members {
+		sig.Members[i] = &common.PublicKeyFactor{X: key.X, Y: key.Y}
+	}
+
+	sigContent, err := json.Marshal(sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// 组装超级签名
+	xuperSig := &common.XuperSignature{
+		SigType:    common.LinkableRing,
+		SigContent: sigContent,
+	}
+	return json.Marshal(xuperSig)
+}
+
+// Verify 验证可链接环签名，keys需要与签名中的环成员完全一致（顺序无关）
+func Verify(keys []*ecdsa.PublicKey, signature, message []byte) (bool, error) {
+	if len(keys) < MinimumParticipant {
+		return false, TooSmallNumOfkeysError
+	}
+	if len(message) == 0 || signature == nil {
+		return false, nil
+	}
+
+	sig := new(common.LinkableRingSignature)
+	if err := json.Unmarshal(signature, sig); err != nil {
+		return false, fmt.Errorf("Failed unmashalling linkable ring signature [%s]", err)
+	}
+
+	curve := keys[0].Curve
+	if err := checkCurve(curve); err != nil {
+		return false, err
+	}
+	if curve.Params().Name != sig.CurveName {
+		return false, NotExactTheSameCurveInputError
+	}
+	if err := checkMembers(curve, keys); err != nil {
+		return false, err
+	}
+
+	members, keyImage, err := parseSignature(curve, sig)
+	if err != nil {
+		return false, nil
+	}
+	if !membersMatch(keys, members) {
+		return false, KeyParamNotMatchError
+	}
+
+	hps, err := hashMembersToCurve(curve, members)
+	if err != nil {
+		return false, err
+	}
+	prefix := challengePrefix(curve, members, keyImage, message)
+
+	c := sig.C
+	for i := range members {
+		l, r := ringPoints(members[i], hps[i], keyImage, c, sig.S[i])
+		c = challenge(curve, prefix, l, r)
+	}
+
+	return c.Cmp(sig.C) == 0, nil
+}
+
+// Link 判断两个可链接环签名是否由同一私钥产生，只比较KeyImage，调用方需要先分别验证签名
+func Link(signature1, signature2 []byte) (bool, error) {
+	sig1, err := unmarshalLinkableRingSignature(signature1)
+	if err != nil {
+		return false, err
+	}
+	sig2, err := unmarshalLinkableRingSignature(signature2)
+	if err != nil {
+		return false, err
+	}
+	if sig1.CurveName != sig2.CurveName {
+		return false, nil
+	}
+	return sig1.KeyImage.X.Cmp(sig2.KeyImage.X) == 0 && sig1.KeyImage.Y.Cmp(sig2.KeyImage.Y) == 0, nil
+}
+
+// KeyImage 返回签名中的KeyImage（压缩格式），可以作为防重放的标识保存
+func KeyImage(signature []byte) ([]byte, error) {
+	sig, err := unmarshalLinkableRingSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	curve, err := ecc.CurveByName(sig.CurveName)
+	if err != nil {
+		return nil, err
+	}
+	return ecc.MarshalPoint(curve, sig.KeyImage.X, sig.KeyImage.Y, ecc.PointFormatCompressed)
+}
+
+// unmarshalLinkableRingSignature 解析超级签名格式的可链接环签名
+func unmarshalLinkableRingSignature(signature []byte) (*common.LinkableRingSignature, error) {
+	xuperSig := new(common.XuperSignature)
+	if err := json.Unmarshal(signature, xuperSig); err != nil {
+		return nil, fmt.Errorf("Failed unmashalling XuperSignature [%s]", err)
+	}
+	if xuperSig.SigType != common.LinkableRing {
+		return nil, fmt.Errorf("The XuperSignature type[%v] is not a linkable ring signature", xuperSig.SigType)
+	}
+
+	sig := new(common.LinkableRingSignature)
+	if err := json.Unmarshal(xuperSig.SigContent, sig); err != nil {
+		return nil, fmt.Errorf("Failed unmashalling linkable ring signature [%s]", err)
+	}
+	if sig.KeyImage == nil || sig.KeyImage.X == nil || sig.KeyImage.Y == nil {
+		return nil, NotValidSignatureError
+	}
+	return sig, nil
+}
+
+// parseSignature 检查签名中的各项数据，返回环成员与KeyImage
+func parseSignature(curve elliptic.Curve, sig *common.LinkableRingSignature) ([]*ecdsa.PublicKey, *ecc.Point, error) {
+	n := curve.Params().N
+	if len(sig.Members) < MinimumParticipant || len(sig.S) != len(sig.Members) || sig.C == nil || sig.C.Sign() < 0 || sig.C.Cmp(n) >= 0 {
+		return nil, nil, NotValidSignatureError
+	}
+	for _, s := range sig.S {
+		if s == nil || s.Sign() < 0 || s.Cmp(n) >= 0 {
+			return nil, nil, NotValidSignatureError
+		}
+	}
+
+	members := make([]*ecdsa.PublicKey, len(sig.Members))
+	for i, member := range sig.Members {
+		if member == nil || member.X == nil || member.Y == nil {
+			return nil, nil, NotValidSignatureError
+		}
+		members[i] = &ecdsa.PublicKey{Curve: curve, X: member.X, Y: member.Y}
+	}
+	if err := checkMembers(curve, members); err != nil {
+		return nil, nil, err
+	}
+
+	if sig.KeyImage == nil || sig.KeyImage.X == nil || sig.KeyImage.Y == nil {
+		return nil, nil, NotValidSignatureError
+	}
+	keyImage, err := ecc.NewPoint(curve, sig.KeyImage.X, sig.KeyImage.Y)
+	if err != nil {
+		return nil, nil, NotValidSignatureError
+	}
+	return members, keyImage, nil
+}
+
+// ringPoints 计算 L = s*G + c*P，R = s*Hp(P) + c*I
+func ringPoints(member *ecdsa.PublicKey, hp, keyImage *ecc.Point, c, s *big.Int) (*ecc.Point, *ecc.Point) {
+	curve := member.Curve
+	params := curve.Params()
+	g := &ecc.Point{Curve: curve, X: params.Gx, Y: params.Gy}
+	publicKey := &ecc.Point{Curve: curve, X: member.X, Y: member.Y}
+	l := ecc.MultiScalarMult(curve, []*big.Int{s, c}, []*ecc.Point{g, publicKey})
+	r := ecc.MultiScalarMult(curve, []*big.Int{s, c}, []*ecc.Point{hp, keyImage})
+	return l, r
+}
+
+// hashMembersToCurve 计算每个成员的Hp(P)
+func hashMembersToCurve(curve elliptic.Curve, members []*ecdsa.PublicKey) ([]*ecc.Point, error) {
+	hps := make([]*ecc.Point, len(members))
+	for i, member := range members {
+		data := append([]byte(tagKeyImage), elliptic.Marshal(curve, member.X, member.Y)...)
+		hp, err := ecc.HashToCurve(data, curve)
+		if err != nil {
+			return nil, err
+		}
+		hps[i] = hp
+	}
+	return hps, nil
+}
+
+// challengePrefix 挑战值中固定的部分：Members || I || m
+func challengePrefix(curve elliptic.Curve, members []*ecdsa.PublicKey, keyImage *ecc.Point, message []byte) []byte {
+	prefix := []byte(tagChallenge)
+	prefix = append(prefix, []byte(curve.Params().Name)...)
+	for _, member := range members {
+		prefix = append(prefix, elliptic.Marshal(curve, member.X, member.Y)...)
+	}
+	prefix = append(prefix, elliptic.Marshal(curve, keyImage.X, keyImage.Y)...)
+	return append(prefix, message...)
+}
+
+// challenge c = H(prefix || L || R) mod N，国密曲线使用SM3，NIST曲线使用SHA256
+func challenge(curve elliptic.Curve, prefix []byte, l, r *ecc.Point) *big.Int {
+	var h hash.Hash
+	if curve.Params().Name == config.CurveGm {
+		h = sm3.New()
+	} else {
+		h = sha256.New()
+	}
+	h.Write(prefix)
+	h.Write(elliptic.Marshal(curve, l.X, l.Y))
+	h.Write(elliptic.Marshal(curve, r.X, r.Y))
+
+	c := new(big.Int).SetBytes(h.Sum(nil))
+	return c.Mod(c, curve.Params().N)
+}
+
+// membersMatch 判断传入的公钥与环成员是否完全一致
+func membersMatch(keys, members []*ecdsa.PublicKey) bool {
+	if len(keys) != len(members) {
+		return false
+	}
+	set := make(map[string]bool)
+	for _, key := range keys {
+		set[string(elliptic.Marshal(key.Curve, key.X, key.Y))] = true
+	}
+	for _, member := range members {
+		if !set[string(elliptic.Marshal(member.Curve, member.X, member.Y))] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkMembers 所有成员使用同一条曲线、在曲线上且不重复
+func checkMembers(curve elliptic.Curve, members []*ecdsa.PublicKey) error {
+	seen := make(map[string]bool)
+	for _, member := range members {
+		if member == nil || member.X == nil || member.Y == nil {
+			return InvalidInputParamsError
+		}
+		if member.Curve.Params().Name != curve.Params().Name {
+			return NotExactTheSameCurveInputError
+		}
+		if !curve.IsOnCurve(member.X, member.Y) {
+			return InvalidInputParamsError
+		}
+		key := string(elliptic.Marshal(curve, member.X, member.Y))
+		if seen[key] {
+			return DuplicateMemberError
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func checkCurve(curve elliptic.Curve) error {
+	switch curve.Params().Name {
+	case config.CurveGm, config.CurveNist:
+		return nil
+	default:
+		return UnsupportedCurveError
+	}
+}
+
+func randomScalar(curve elliptic.Curve) (*big.Int, error) {
+	n := curve.Params().N
+	for {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package linkable_ring_sign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func generateRing(t *testing.T, curve elliptic.Curve, size int) ([]*ecdsa.PrivateKey, []*ecdsa.PublicKey) {
+	keys := make([]*ecdsa.PrivateKey, size)
+	publicKeys := make([]*ecdsa.PublicKey, size)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		publicKeys[i] = &key.PublicKey
+	}
+	return keys, publicKeys
+}
+
+// others 返回除index之外的成员公钥
+func others(publicKeys []*ecdsa.PublicKey, index int) []*ecdsa.PublicKey {
+	result := append([]*ecdsa.PublicKey{}, publicKeys[:index]...)
+	return append(result, publicKeys[index+1:]...)
+}
+
+func sigContent(t *testing.T, sig []byte) []byte {
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	require.Equal(t, common.LinkableRing, xuperSig.SigType)
+	return xuperSig.SigContent
+}
+
+func TestSignAndVerify(t *testing.T) {
+	message := []byte("anonymous vote")
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		keys, publicKeys := generateRing(t, curve, 4)
+
+		for i, key := range keys {
+			sig, err := Sign(others(publicKeys, i), key, message)
+			require.NoError(t, err)
+
+			ok, err := Verify(publicKeys, sigContent(t, sig), message)
+			require.NoError(t, err)
+			require.True(t, ok)
+
+			ok, err = Verify(publicKeys, sigContent(t, sig), []byte("another vote"))
+			require.NoError(t, err)
+			require.False(t, ok)
+		}
+
+		sig, err := Sign(publicKeys[1:], keys[0], message)
+		require.NoError(t, err)
+		_, err = Verify(publicKeys[:3], sigContent(t, sig), message)
+		require.Equal(t, KeyParamNotMatchError, err)
+
+		_, err = Sign(publicKeys, keys[0], message)
+		require.Equal(t, DuplicateMemberError, err)
+	}
+}
+
+func TestLink(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		keys, publicKeys := generateRing(t, curve, 4)
+
+		// 同一私钥在不同的环、不同的消息上的签名可以被链接
+		sig1, err := Sign(publicKeys[1:], keys[0], []byte("first claim"))
+		require.NoError(t, err)
+		sig2, err := Sign(publicKeys[1:3], keys[0], []byte("second claim"))
+		require.NoError(t, err)
+		linked, err := Link(sig1, sig2)
+		require.NoError(t, err)
+		require.True(t, linked)
+
+		image1, err := KeyImage(sig1)
+		require.NoError(t, err)
+		image2, err := KeyImage(sig2)
+		require.NoError(t, err)
+		require.Equal(t, image1, image2)
+
+		sig3, err := Sign(others(publicKeys, 1), keys[1], []byte("first claim"))
+		require.NoError(t, err)
+		linked, err = Link(sig1, sig3)
+		require.NoError(t, err)
+		require.False(t, linked)
+	}
+}
+
+func TestForgedKeyImage(t *testing.T) {
+	message := []byte("anonymous vote")
+	keys, publicKeys := generateRing(t, sm2.P256Sm2(), 3)
+	sig, err := Sign(publicKeys[1:], keys[0], message)
+	require.NoError(t, err)
+
+	// 替换KeyImage后签名无法通过验证，避免签名者逃避链接
+	content := new(common.LinkableRingSignature)
+	require.NoError(t, json.Unmarshal(sigContent(t, sig), content))
+	x, y := sm2.P256Sm2().ScalarBaseMult(big.NewInt(7).Bytes())
+	content.KeyImage = &common.PublicKeyFactor{X: x, Y: y}
+	forged, err := json.Marshal(content)
+	require.NoError(t, err)
+
+	ok, err := Verify(publicKeys, forged, message)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
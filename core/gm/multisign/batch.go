@@ -0,0 +1,239 @@
+package multisign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+// 批量验证多重签名与MuSig2签名
+//
+// 两种签名的验证等式都是 s*G = R + e*C，C为公共公钥（MuSig2为聚合公钥）。
+// 对n个签名随机选择128比特的系数a_i，只需验证一次多标量乘法：
+// (Σ a_i*s_i)*G - Σ a_i*R_i - Σ (a_i*e_i)*C_i == O
+// 任何一个签名无效时，等式成立的概率不超过2^-128。
+// 合并验证失败时，将签名分成两半分别递归验证，在无效签名较少时只需要O(k*log(n))次合并验证就能定位全部k个无效签名。
+
+// batchItem 一个签名的验证等式 s*G = R + e*C
+type batchItem struct {
+	curve elliptic.Curve
+	s     *big.Int
+	r     *ecc.Point
+	e     *big.Int
+	c     *ecc.Point
+}
+
+// BatchVerifyMultiSig 批量验证VerifyMultiSig格式的多重签名，keys[i]为第i个签名的全部参与者公钥，
+// 返回验证失败的下标，按升序排列；全部通过时返回nil
+func BatchVerifyMultiSig(keys [][]*ecdsa.PublicKey, signatures, messages [][]byte) ([]int, error) {
+	if len(keys) != len(signatures) || len(signatures) != len(messages) {
+		return nil, InvalidInputParamsError
+	}
+
+	items := make([]*batchItem, len(signatures))
+	for i := range signatures {
+		items[i] = parseMultiSigItem(keys[i], signatures[i], messages[i])
+	}
+	return batchVerify(items)
+}
+
+// BatchVerifyMuSig2 批量验证MuSig2签名，keys[i]为第i个签名的全部参与者公钥，
+// 返回验证失败的下标，按升序排列；全部通过时返回nil
+func BatchVerifyMuSig2(keys [][]*ecdsa.PublicKey, signatures, messages [][]byte) ([]int, error) {
+	if len(keys) != len(signatures) || len(signatures) != len(messages) {
+		return nil, InvalidInputParamsError
+	}
+
+	items := make([]*batchItem, len(signatures))
+	for i := range signatures {
+		items[i] = parseMuSig2Item(keys[i], signatures[i], messages[i])
+	}
+	return batchVerify(items)
+}
+
+// parseMultiSigItem 与VerifyMultiSig的检查一致，格式错误时返回nil
+func parseMultiSigItem(keys []*ecdsa.PublicKey, signature, message []byte) *batchItem {
+	if len(keys) < MinimumParticipant || len(message) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if key == nil {
+			return nil
+		}
+	}
+	sig := new(common.MultiSignature)
+	if err := json.Unmarshal(signature, sig); err != nil || len(sig.R) == 0 || len(sig.S) == 0 {
+		return nil
+	}
+
+	curve := keys[0].Curve
+	c, err := GetSharedPublicKeyForPublicKeys(keys)
+	if err != nil {
+		return nil
+	}
+	cx, cy := elliptic.Unmarshal(curve, c)
+	rx, ry := elliptic.Unmarshal(curve, sig.R)
+	if cx == nil || rx == nil {
+		return nil
+	}
+
+	// e = HASH(C, R, m)
+	e := new(big.Int).SetBytes(hash.HashUsingSM3(utils.BytesCombine(c, sig.R, message)))
+	return &batchItem{
+		curve: curve,
+		s:     new(big.Int).SetBytes(sig.S),
+		r:     &ecc.Point{Curve: curve, X: rx, Y: ry},
+		e:     e,
+		c:     &ecc.Point{Curve: curve, X: cx, Y: cy},
+	}
+}
+
+// parseMuSig2Item 与VerifyMuSig2的检查一致，格式错误时返回nil
+func parseMuSig2Item(keys []*ecdsa.PublicKey, signature, message []byte) *batchItem {
+	if len(message) == 0 {
+		return nil
+	}
+	keyAgg, err := MuSig2AggregatePublicKeys(keys)
+	if err != nil {
+		return nil
+	}
+	curve := keyAgg.Curve
+
+	sig := new(common.MultiSignature)
+	if err := json.Unmarshal(signature, sig); err != nil || len(sig.R) == 0 || len(sig.S) == 0 {
+		return nil
+	}
+	r, err := ecc.UnmarshalPoint(curve, sig.R)
+	if err != nil {
+		return nil
+	}
+	s := new(big.Int).SetBytes(sig.S)
+	if s.Cmp(curve.Params().N) >= 0 {
+		return nil
+	}
+
+	return &batchItem{
+		curve: curve,
+		s:     s,
+		r:     r,
+		e:     keyAgg.challenge(r, message),
+		c:     &ecc.Point{Curve: curve, X: keyAgg.AggregatedKey.X, Y: keyAgg.AggregatedKey.Y},
+	}
+}
+
+// batchVerify 按曲线分组后合并验证，格式错误的签名直接视为无效
+func batchVerify(items []*batchItem) ([]int, error) {
+	invalid := make([]bool, len(items))
+	groups := make(map[string][]int)
+	var curveNames []string
+	for i, item := range items {
+		if item == nil {
+			invalid[i] = true
+			continue
+		}
+		name := item.curve.Params().Name
+		if _, exist := groups[name]; !exist {
+			curveNames = append(curveNames, name)
+		}
+		groups[name] = append(groups[name], i)
+	}
+
+	for _, name := range curveNames {
+		bad, err := findInvalid(items, groups[name])
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range bad {
+			invalid[i] = true
+		}
+	}
+
+	var result []int
+	for i, bad := range invalid {
+		if bad {
+			result = append(result, i)
+		}
+	}
+	return result, nil
+}
+
+// findInvalid 合并验证失败时二分递归，返回indexes中无效签名的下标
+func findInvalid(items []*batchItem, indexes []int) ([]int, error) {
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+	ok, err := verifyCombined(items, indexes)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return nil, nil
+	}
+	if len(indexes) == 1 {
+		return indexes, nil
+	}
+
+	half := len(indexes) / 2
+	left, err := findInvalid(items, indexes[:half])
+	if err != nil {
+		return nil, err
+	}
+	right, err := findInvalid(items, indexes[half:])
+	if err != nil {
+		return nil, err
+	}
+	return append(left, right...), nil
+}
+
+// verifyCombined 验证 (Σ a_i*s_i)*G - Σ a_i*R_i - Σ (a_i*e_i)*C_i == O
+func verifyCombined(items []*batchItem, indexes []int) (bool, error) {
+	curve := items[indexes[0]].curve
+	params := curve.Params()
+	n := params.N
+
+	scalars := make([]*big.Int, 0, 2*len(indexes)+1)
+	points := make([]*ecc.Point, 0, 2*len(indexes)+1)
+	sumS := new(big.Int)
+	for _, i := range indexes {
+		item := items[i]
+		a, err := randomWeight()
+		if err != nil {
+			return false, err
+		}
+
+		sumS.Add(sumS, new(big.Int).Mul(a, item.s))
+
+		negA := new(big.Int).Sub(n, a)
+		scalars = append(scalars, negA)
+		points = append(points, item.r)
+
+		ae := new(big.Int).Mul(negA, item.e)
+		scalars = append(scalars, ae.Mod(ae, n))
+		points = append(points, item.c)
+	}
+	scalars = append(scalars, sumS.Mod(sumS, n))
+	points = append(points, &ecc.Point{Curve: curve, X: params.Gx, Y: params.Gy})
+
+	return ecc.MultiScalarMult(curve, scalars, points).IsInfinity(), nil
+}
+
+// randomWeight 生成[1, 2^128)内的随机系数
+func randomWeight() (*big.Int, error) {
+	for {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		a := new(big.Int).SetBytes(buf)
+		if a.Sign() != 0 {
+			return a, nil
+		}
+	}
+}
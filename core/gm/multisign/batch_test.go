@@ -0,0 +1,114 @@
+package multisign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func generateKeys(t *testing.T, curve elliptic.Curve, num int) ([]*ecdsa.PrivateKey, []*ecdsa.PublicKey) {
+	keys := make([]*ecdsa.PrivateKey, num)
+	publicKeys := make([]*ecdsa.PublicKey, num)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		publicKeys[i] = &key.PublicKey
+	}
+	return keys, publicKeys
+}
+
+func sigContent(t *testing.T, sig []byte) []byte {
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	return xuperSig.SigContent
+}
+
+func muSig2Sign(t *testing.T, keys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) []byte {
+	keyAgg, err := MuSig2AggregatePublicKeys(publicKeys)
+	require.NoError(t, err)
+
+	secretNonces := make([]*MuSig2SecretNonce, len(keys))
+	publicNonces := make([][]byte, len(keys))
+	for i, key := range keys {
+		secretNonces[i], publicNonces[i], err = MuSig2NonceGen(key)
+		require.NoError(t, err)
+	}
+	aggNonce, err := MuSig2NonceAgg(keyAgg.Curve, publicNonces)
+	require.NoError(t, err)
+
+	partialSigs := make([][]byte, len(keys))
+	for i, key := range keys {
+		partialSigs[i], err = MuSig2PartialSign(secretNonces[i], key, keyAgg, aggNonce, message)
+		require.NoError(t, err)
+	}
+	sig, err := MuSig2PartialSigAgg(keyAgg, aggNonce, message, partialSigs)
+	require.NoError(t, err)
+	return sigContent(t, sig)
+}
+
+func TestBatchVerifyMultiSig(t *testing.T) {
+	num := 20
+	keySets := make([][]*ecdsa.PublicKey, num)
+	sigs := make([][]byte, num)
+	messages := make([][]byte, num)
+	for i := 0; i < num; i++ {
+		keys, publicKeys := generateKeys(t, sm2.P256Sm2(), 2+i%3)
+		messages[i] = []byte(fmt.Sprintf("block %d", i))
+		sig, err := MultiSign(keys, messages[i])
+		require.NoError(t, err)
+		keySets[i] = publicKeys
+		sigs[i] = sigContent(t, sig)
+	}
+
+	invalid, err := BatchVerifyMultiSig(keySets, sigs, messages)
+	require.NoError(t, err)
+	require.Nil(t, invalid)
+
+	messages[3] = []byte("tampered")
+	messages[17] = []byte("tampered")
+	sigs[11] = []byte("not a signature")
+	invalid, err = BatchVerifyMultiSig(keySets, sigs, messages)
+	require.NoError(t, err)
+	require.Equal(t, []int{3, 11, 17}, invalid)
+
+	_, err = BatchVerifyMultiSig(keySets, sigs, messages[1:])
+	require.Equal(t, InvalidInputParamsError, err)
+}
+
+func TestBatchVerifyMuSig2(t *testing.T) {
+	num := 12
+	keySets := make([][]*ecdsa.PublicKey, num)
+	sigs := make([][]byte, num)
+	messages := make([][]byte, num)
+	for i := 0; i < num; i++ {
+		// SM2与NIST曲线的签名混合在一起
+		curve := sm2.P256Sm2()
+		if i%2 == 1 {
+			curve = elliptic.P256()
+		}
+		keys, publicKeys := generateKeys(t, curve, 2)
+		messages[i] = []byte(fmt.Sprintf("MuSig2 %d", i))
+		keySets[i] = publicKeys
+		sigs[i] = muSig2Sign(t, keys, publicKeys, messages[i])
+	}
+
+	invalid, err := BatchVerifyMuSig2(keySets, sigs, messages)
+	require.NoError(t, err)
+	require.Nil(t, invalid)
+
+	// 交换两个签名，使得两个签名分别无效
+	sigs[0], sigs[2] = sigs[2], sigs[0]
+	messages[5] = []byte("tampered")
+	invalid, err = BatchVerifyMuSig2(keySets, sigs, messages)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 2, 5}, invalid)
+}
@@ -0,0 +1,467 @@
+package multisign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// MuSig2多重签名，参考BIP-327，支持SM2-P-256与P-256两条曲线
+//
+// 直接相加的公共公钥C = P1 + ... + Pn存在rogue-key攻击：攻击者选择Pn = X - (P1 + ... + Pn-1)，即可独自签名。
+// MuSig2使用与全部公钥绑定的系数对公钥加权：
+// 1. L = H_list(P1, ..., Pn)，ai = H_coef(L, Pi)，聚合公钥 X = a1*P1 + ... + an*Pn
+// 2. 各方生成两个随机数(ki1, ki2)，广播 Ri1 = ki1*G、Ri2 = ki2*G
+// 3. 聚合随机数 R1 = sum(Ri1)，R2 = sum(Ri2)
+// 4. b = H_non(X, R1, R2, m)，R = R1 + b*R2，e = H_sig(X, R, m)
+// 5. 各方计算部分签名 si = ki1 + b*ki2 + e*ai*xi，可以逐一验证：si*G == Ri1 + b*Ri2 + e*ai*Pi
+// 6. 签名为 (R, s1 + ... + sn)，验证：s*G == R + e*X
+// 哈希算法：SM2曲线使用SM3，P-256使用SHA-256
+
+const (
+	muSig2TagKeyAggList = "MuSig/KeyAgg list"
+	muSig2TagKeyAggCoef = "MuSig/KeyAgg coefficient"
+	muSig2TagNonceCoef  = "MuSig/noncecoef"
+	muSig2TagChallenge  = "MuSig/challenge"
+)
+
+var (
+	UnsupportedCurveError   = errors.New("The curve is not supported by MuSig2")
+	InvalidPublicNonceError = errors.New("The public nonce is invalid")
+	InfinityNonceError      = errors.New("The aggregated nonce is the point at infinity")
+	SecretNonceUsedError    = errors.New("The secret nonce has already been used")
+	KeyNotInKeyAggError     = errors.New("The key is not one of the aggregated public keys")
+)
+
+// MuSig2KeyAggContext 公钥聚合的结果，签名与验证时使用相同的公钥顺序
+type MuSig2KeyAggContext struct {
+	Curve         elliptic.Curve
+	PublicKeys    []*ecdsa.PublicKey
+	AggregatedKey *ecdsa.PublicKey
+
+	coefficients []*big.Int
+}
+
+// MuSig2SecretNonce 签名者保存的秘密随机数，只能用于一次部分签名
+type MuSig2SecretNonce struct {
+	k1, k2 *big.Int
+	// 对应的公开随机数 Ri1 || Ri2
+	publicNonce []byte
+	used        bool
+}
+
+// MuSig2AggregatePublicKeys 计算聚合公钥 X = a1*P1 + ... + an*Pn
+func MuSig2AggregatePublicKeys(keys []*ecdsa.PublicKey) (*MuSig2KeyAggContext, error) {
+	if len(keys) < MinimumParticipant {
+		return nil, TooSmallNumOfkeysError
+	}
+	for _, key := range keys {
+		if key == nil || key.X == nil || key.Y == nil {
+			return nil, InvalidInputParamsError
+		}
+	}
+	if !checkCurveForPublicKeys(keys) {
+		return nil, NotExactTheSameCurveInputError
+	}
+	curve := keys[0].Curve
+	if err := checkMuSig2Curve(curve); err != nil {
+		return nil, err
+	}
+
+	encodedKeys := make([][]byte, len(keys))
+	for i, key := range keys {
+		encoded, err := ecc.MarshalPoint(curve, key.X, key.Y, ecc.PointFormatCompressed)
+		if err != nil {
+			return nil, err
+		}
+		encodedKeys[i] = encoded
+	}
+	l := muSig2TaggedHash(curve, muSig2TagKeyAggList, encodedKeys...)
+
+	n := curve.Params().N
+	coefficients := make([]*big.Int, len(keys))
+	x, y := new(big.Int), new(big.Int)
+	for i, key := range keys {
+		a := new(big.Int).SetBytes(muSig2TaggedHash(curve, muSig2TagKeyAggCoef, l, encodedKeys[i]))
+		a.Mod(a, n)
+		coefficients[i] = a
+
+		ax, ay := curve.ScalarMult(key.X, key.Y, a.Bytes())
+		x, y = curve.Add(x, y, ax, ay)
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, InvalidInputParamsError
+	}
+
+	ctx := &MuSig2KeyAggContext{
+		Curve:         curve,
+		PublicKeys:    keys,
+		AggregatedKey: &ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		coefficients:  coefficients,
+	}
+	return ctx, nil
+}
+
+// MuSig2NonceGen 生成秘密随机数以及需要广播的公开随机数 Ri1 || Ri2（压缩格式）
+func MuSig2NonceGen(key *ecdsa.PrivateKey) (*MuSig2SecretNonce, []byte, error) {
+	if key == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	if err := checkMuSig2Curve(key.Curve); err != nil {
+		return nil, nil, err
+	}
+
+	k1, err := muSig2RandomScalar(key.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	k2, err := muSig2RandomScalar(key.Curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	return newMuSig2SecretNonce(key.Curve, k1, k2)
+}
+
+// PublicNonce 返回秘密随机数对应的公开随机数
+func (nonce *MuSig2SecretNonce) PublicNonce() []byte {
+	return append([]byte{}, nonce.publicNonce...)
+}
+
+// MuSig2NonceAgg 聚合全部参与者的公开随机数，得到 R1 || R2
+func MuSig2NonceAgg(curve elliptic.Curve, publicNonces [][]byte) ([]byte, error) {
+	if err := checkMuSig2Curve(curve); err != nil {
+		return nil, err
+	}
+	if len(publicNonces) < MinimumParticipant {
+		return nil, TooSmallNumOfkeysError
+	}
+
+	x1, y1, x2, y2 := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for _, publicNonce := range publicNonces {
+		r1, r2, err := parseMuSig2Nonce(curve, publicNonce)
+		if err != nil {
+			return nil, err
+		}
+		x1, y1 = curve.Add(x1, y1, r1.X, r1.Y)
+		x2, y2 = curve.Add(x2, y2, r2.X, r2.Y)
+	}
+
+	return encodeMuSig2Nonce(curve, x1, y1, x2, y2)
+}
+
+// MuSig2PartialSign 计算部分签名 si = ki1 + b*ki2 + e*ai*xi，秘密随机数使用后立即清除
+func MuSig2PartialSign(secretNonce *MuSig2SecretNonce, key *ecdsa.PrivateKey, keyAgg *MuSig2KeyAggContext, aggNonce, message []byte) ([]byte, error) {
+	if secretNonce == nil || key == nil || keyAgg == nil {
+		return nil, InvalidInputParamsError
+	}
+	if secretNonce.used {
+		return nil, SecretNonceUsedError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	if key.Curve != keyAgg.Curve {
+		return nil, NotExactTheSameCurveInputError
+	}
+
+	a, err := keyAgg.coefficient(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	b, e, err := keyAgg.challenges(aggNonce, message)
+	if err != nil {
+		return nil, err
+	}
+
+	// 无论签名是否成功，随机数都不能再次使用
+	k1, k2 := secretNonce.k1, secretNonce.k2
+	secretNonce.used = true
+	secretNonce.k1, secretNonce.k2 = nil, nil
+
+	n := keyAgg.Curve.Params().N
+	s := new(big.Int).Mul(e, a)
+	s.Mul(s, key.D)
+	s.Add(s, new(big.Int).Mul(b, k2))
+	s.Add(s, k1)
+	s.Mod(s, n)
+
+	k1.SetInt64(0)
+	k2.SetInt64(0)
+
+	return fixedLengthScalar(keyAgg.Curve, s), nil
+}
+
+// MuSig2PartialVerify 验证部分签名：si*G == Ri1 + b*Ri2 + e*ai*Pi
+func MuSig2PartialVerify(partialSig, publicNonce []byte, publicKey *ecdsa.PublicKey, keyAgg *MuSig2KeyAggContext, aggNonce, message []byte) bool {
+	if publicKey == nil || keyAgg == nil || len(message) == 0 {
+		return false
+	}
+	curve := keyAgg.Curve
+	n := curve.Params().N
+
+	s := new(big.Int).SetBytes(partialSig)
+	if len(partialSig) == 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	a, err := keyAgg.coefficient(publicKey)
+	if err != nil {
+		return false
+	}
+	b, e, err := keyAgg.challenges(aggNonce, message)
+	if err != nil {
+		return false
+	}
+	r1, r2, err := parseMuSig2Nonce(curve, publicNonce)
+	if err != nil {
+		return false
+	}
+
+	lhsX, lhsY := curve.ScalarBaseMult(s.Bytes())
+
+	bx, by := curve.ScalarMult(r2.X, r2.Y, b.Bytes())
+	rhsX, rhsY := curve.Add(r1.X, r1.Y, bx, by)
+	ea := new(big.Int).Mul(e, a)
+	ea.Mod(ea, n)
+	px, py := curve.ScalarMult(publicKey.X, publicKey.Y, ea.Bytes())
+	rhsX, rhsY = curve.Add(rhsX, rhsY, px, py)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
+
+// MuSig2PartialSigAgg 聚合部分签名，生成SigType为MuSig2的超级签名
+func MuSig2PartialSigAgg(keyAgg *MuSig2KeyAggContext, aggNonce, message []byte, partialSigs [][]byte) ([]byte, error) {
+	if keyAgg == nil || len(partialSigs) != len(keyAgg.PublicKeys) {
+		return nil, InvalidInputParamsError
+	}
+	curve := keyAgg.Curve
+	n := curve.Params().N
+
+	r, _, err := keyAgg.finalNonce(aggNonce, message)
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int)
+	for _, partialSig := range partialSigs {
+		si := new(big.Int).SetBytes(partialSig)
+		if si.Cmp(n) >= 0 {
+			return nil, InvalidPartialSignatureError
+		}
+		s.Add(s, si)
+	}
+	s.Mod(s, n)
+
+	encodedR, err := ecc.MarshalPoint(curve, r.X, r.Y, ecc.PointFormatCompressed)
+	if err != nil {
+		return nil, err
+	}
+	muSig := &common.MultiSignature{
+		S: fixedLengthScalar(curve, s),
+		R: encodedR,
+	}
+	sigContent, err := json.Marshal(muSig)
+	if err != nil {
+		return nil, err
+	}
+
+	xuperSig := &common.XuperSignature{
+		SigType:    common.MuSig2,
+		SigContent: sigContent,
+	}
+	return json.Marshal(xuperSig)
+}
+
+// VerifyMuSig2 使用参与者的公钥验证MuSig2签名：s*G == R + e*X
+func VerifyMuSig2(keys []*ecdsa.PublicKey, signature []byte, message []byte) (bool, error) {
+	if len(message) == 0 {
+		return false, EmptyMessageError
+	}
+	keyAgg, err := MuSig2AggregatePublicKeys(keys)
+	if err != nil {
+		return false, err
+	}
+	curve := keyAgg.Curve
+	n := curve.Params().N
+
+	sig := new(common.MultiSignature)
+	if err := json.Unmarshal(signature, sig); err != nil {
+		return false, fmt.Errorf("Failed unmashalling MuSig2 signature [%s]", err)
+	}
+	if len(sig.R) == 0 || len(sig.S) == 0 {
+		return false, NotValidSignatureError
+	}
+	r, err := ecc.UnmarshalPoint(curve, sig.R)
+	if err != nil {
+		return false, NotValidSignatureError
+	}
+	s := new(big.Int).SetBytes(sig.S)
+	if s.Cmp(n) >= 0 {
+		return false, NotValidSignatureError
+	}
+
+	e := keyAgg.challenge(r, message)
+
+	lhsX, lhsY := curve.ScalarBaseMult(s.Bytes())
+	ex, ey := curve.ScalarMult(keyAgg.AggregatedKey.X, keyAgg.AggregatedKey.Y, e.Bytes())
+	rhsX, rhsY := curve.Add(r.X, r.Y, ex, ey)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0, nil
+}
+
+// coefficient 返回公钥对应的聚合系数ai
+func (ctx *MuSig2KeyAggContext) coefficient(key *ecdsa.PublicKey) (*big.Int, error) {
+	for i, pub := range ctx.PublicKeys {
+		if pub.X.Cmp(key.X) == 0 && pub.Y.Cmp(key.Y) == 0 {
+			return ctx.coefficients[i], nil
+		}
+	}
+	return nil, KeyNotInKeyAggError
+}
+
+// finalNonce 计算 b = H_non(X, R1, R2, m) 以及 R = R1 + b*R2
+func (ctx *MuSig2KeyAggContext) finalNonce(aggNonce, message []byte) (*ecc.Point, *big.Int, error) {
+	curve := ctx.Curve
+	r1, r2, err := parseMuSig2Nonce(curve, aggNonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := new(big.Int).SetBytes(muSig2TaggedHash(curve, muSig2TagNonceCoef, ctx.encodedKey(), aggNonce, message))
+	b.Mod(b, curve.Params().N)
+
+	bx, by := curve.ScalarMult(r2.X, r2.Y, b.Bytes())
+	x, y := curve.Add(r1.X, r1.Y, bx, by)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, nil, InfinityNonceError
+	}
+	return &ecc.Point{Curve: curve, X: x, Y: y}, b, nil
+}
+
+// challenges 返回随机数系数b以及挑战e
+func (ctx *MuSig2KeyAggContext) challenges(aggNonce, message []byte) (*big.Int, *big.Int, error) {
+	r, b, err := ctx.finalNonce(aggNonce, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, ctx.challenge(r, message), nil
+}
+
+// challenge 计算 e = H_sig(X, R, m)
+func (ctx *MuSig2KeyAggContext) challenge(r *ecc.Point, message []byte) *big.Int {
+	curve := ctx.Curve
+	encodedR, _ := ecc.MarshalPoint(curve, r.X, r.Y, ecc.PointFormatCompressed)
+
+	e := new(big.Int).SetBytes(muSig2TaggedHash(curve, muSig2TagChallenge, ctx.encodedKey(), encodedR, message))
+	return e.Mod(e, curve.Params().N)
+}
+
+func (ctx *MuSig2KeyAggContext) encodedKey() []byte {
+	encoded, _ := ecc.MarshalPoint(ctx.Curve, ctx.AggregatedKey.X, ctx.AggregatedKey.Y, ecc.PointFormatCompressed)
+	return encoded
+}
+
+func newMuSig2SecretNonce(curve elliptic.Curve, k1, k2 *big.Int) (*MuSig2SecretNonce, []byte, error) {
+	x1, y1 := curve.ScalarBaseMult(k1.Bytes())
+	x2, y2 := curve.ScalarBaseMult(k2.Bytes())
+	publicNonce, err := encodeMuSig2Nonce(curve, x1, y1, x2, y2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secretNonce := &MuSig2SecretNonce{
+		k1:          k1,
+		k2:          k2,
+		publicNonce: publicNonce,
+	}
+	return secretNonce, append([]byte{}, publicNonce...), nil
+}
+
+// encodeMuSig2Nonce 随机数编码为两个压缩格式的点，不允许无穷远点
+func encodeMuSig2Nonce(curve elliptic.Curve, x1, y1, x2, y2 *big.Int) ([]byte, error) {
+	if (x1.Sign() == 0 && y1.Sign() == 0) || (x2.Sign() == 0 && y2.Sign() == 0) {
+		return nil, InfinityNonceError
+	}
+	r1, err := ecc.MarshalPoint(curve, x1, y1, ecc.PointFormatCompressed)
+	if err != nil {
+		return nil, err
+	}
+	r2, err := ecc.MarshalPoint(curve, x2, y2, ecc.PointFormatCompressed)
+	if err != nil {
+		return nil, err
+	}
+	return append(r1, r2...), nil
+}
+
+func parseMuSig2Nonce(curve elliptic.Curve, nonce []byte) (*ecc.Point, *ecc.Point, error) {
+	pointLen := 1 + (curve.Params().BitSize+7)/8
+	if len(nonce) != 2*pointLen {
+		return nil, nil, InvalidPublicNonceError
+	}
+	r1, err := ecc.UnmarshalPoint(curve, nonce[:pointLen])
+	if err != nil {
+		return nil, nil, InvalidPublicNonceError
+	}
+	r2, err := ecc.UnmarshalPoint(curve, nonce[pointLen:])
+	if err != nil {
+		return nil, nil, InvalidPublicNonceError
+	}
+	return r1, r2, nil
+}
+
+// muSig2TaggedHash H_tag(x) = H(H(tag) || H(tag) || x)
+func muSig2TaggedHash(curve elliptic.Curve, tag string, data ...[]byte) []byte {
+	var h hash.Hash
+	if curve.Params().Name == config.CurveGm {
+		h = sm3.New()
+	} else {
+		h = sha256.New()
+	}
+
+	h.Write([]byte(tag))
+	tagHash := h.Sum(nil)
+	h.Reset()
+
+	h.Write(tagHash)
+	h.Write(tagHash)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func muSig2RandomScalar(curve elliptic.Curve) (*big.Int, error) {
+	n := curve.Params().N
+	for {
+		randomBytes, err := GetRandom32Bytes()
+		if err != nil {
+			return nil, err
+		}
+		k := new(big.Int).Mod(new(big.Int).SetBytes(randomBytes), n)
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func checkMuSig2Curve(curve elliptic.Curve) error {
+	switch curve.Params().Name {
+	case config.CurveGm, config.CurveNist:
+		return nil
+	default:
+		return UnsupportedCurveError
+	}
+}
+
+// fixedLengthScalar 标量编码为与曲线阶相同长度的大端字节
+func fixedLengthScalar(curve elliptic.Curve, s *big.Int) []byte {
+	return s.FillBytes(make([]byte, (curve.Params().N.BitLen()+7)/8))
+}
@@ -0,0 +1,223 @@
+package multisign
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+// MuSig2测试向量，私钥为H_tag("vector key", i)，随机数为H_tag("vector nonce", i || j)，均模N
+type muSig2Vector struct {
+	curve       elliptic.Curve
+	keys        []string
+	nonces      [][2]string
+	aggKey      string
+	aggNonce    string
+	partialSigs []string
+	r, s        string
+}
+
+var muSig2Message = []byte("MuSig2 test vector")
+
+var muSig2Vectors = []muSig2Vector{
+	{
+		curve: sm2.P256Sm2(),
+		keys: []string{
+			"31971bb6da0230f608d3b281afae6936885a63285a9a6a4528eba50d144b6e3f",
+			"a02c644574a9733c1cb5d358d9db6af8013a99ce9e5fb50ed3ee0606e63366d3",
+			"54ab4854ff36266f94874d96e80cd657db05291b273f97bcfefa541f32b0212b",
+		},
+		nonces: [][2]string{
+			{"cdd3843b1c09bcf09e0eb7ed025f1de460c5cf34284884aaa93f9e0b19f7da52", "6e6913b40112646892859158458fd33d91c567870a04c93fbb18fe9cec881870"},
+			{"7f9726991cb86d108248eefe494be5fe6fba095902bfe2487a3958387d91a02d", "63ddf2071c9360a86deed08e063eb93aef331ac10c88f67f86874ee33e9b547f"},
+			{"2bff217d5086128a4845b58afcd455578677ebcec811139009410f0ee69663a4", "c97aaf0be8ee4ab8ca39b63ecbf272783235aa11b845ea6e54f87f2b03c4c02b"},
+		},
+		aggKey:   "0205d6782a2d1f00883ca1ec15193e26376f25b29932611779e7ca6cfa8b690c8e",
+		aggNonce: "02566d777b21dcd1859edeb8a9004188c62824ec10fcac2502e6941fa19a7d7e2903f3c466bfb811e72c7e2deba20fd9cbe41b52011418e76a487b7e54670de121f9",
+		partialSigs: []string{
+			"dad9f2d6d0ed3fad22810e84ca2f55508060aa0547b9b14774c952fb01d96daf",
+			"c4b1e42f16609fa78de314bfcb53df46ba6f09a14235d24f9c690c595706c418",
+			"ac8767aab4169767347903f3aa3fe2330818703e05242669bcc89a6969945bf1",
+		},
+		r: "03d53ddcf2f84d05f02eb1c7c5355e12f85e2c8f8763fb1bc6d62e0289702b4145",
+		s: "4c133eb29b6476bbe4dd27383fc316cb5ee0650e4b879faa268311ab4eca0b72",
+	},
+	{
+		curve: elliptic.P256(),
+		keys: []string{
+			"75546ecf429eb4613ad78adf72bf5c06fd6c83e85a302c9d2a798da60ce08476",
+			"2adb51f2300f721ec20350ee328a9e0bb1db5347d9885843c643d59ac67bb34e",
+			"53bc955208ee1780b83e7307f6e5f0bc6e4dda2b64314da013b7d6f46f998d95",
+		},
+		nonces: [][2]string{
+			{"67b16960942c91502cd6287fef5bb2a11f418eb87678261b6757ba5dc1dc7d43", "77debb9284e524b73cd62639b55d9588fb5690484a20b3fc7734cc9ef8e20634"},
+			{"edf0bda3b6b18e142f5cbe8cbc59073ae9fb214ebd8ee2c69ca712a4b3e89d5e", "6e5fa74d99b864f0d7dfbfded94428eb01afdbd6fc953e836e54a4796e2fb068"},
+			{"86c4f2ce2cd959965212e8cfa03c7e8643a52d04cfe100832c59adb99daad08d", "63caa48bfa9c13f97cd43f14fd7f04b69b80b1eff3c8ef49d760d86e46ab28ed"},
+		},
+		aggKey:   "032470734f7e82b2d1dfe1cb91f591d05509c0c4b7cdab828783db79cf257adfd1",
+		aggNonce: "02c28e329fb89090a6c394e6f67ab98bb708fafab98982823653abb645307dc93e027ba39c9f33fc7aca319b738d033c13ffd34c23653aa3b9a6e80301aad75443e3",
+		partialSigs: []string{
+			"e0d4732b51236c8114bf973f617a39ab3b23a51f3b4b60adad963c4f953ada15",
+			"fed1d648d4acfb7a8f4fc2a9fec4a24cb46fae689ec01ceb9f6b6d322074035a",
+			"fb643a7f8bc81ebaaab715cb2ea063ed145c0abf1e0221880ad7642a76ab64f9",
+		},
+		r: "02b39595fa91fd08e0f195aefec218ab48fb06b1eaaf184cd7617754ffccee9f9c",
+		s: "db0a83f5b19886b44ec66fb48edf3fe58a2168eba9de6217706578263393f7c6",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return data
+}
+
+func privateKeyFromHex(t *testing.T, curve elliptic.Curve, s string) *ecdsa.PrivateKey {
+	d := new(big.Int).SetBytes(decodeHex(t, s))
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	return &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y}, D: d}
+}
+
+func TestMuSig2Vectors(t *testing.T) {
+	for _, v := range muSig2Vectors {
+		curve := v.curve
+		keys := make([]*ecdsa.PrivateKey, len(v.keys))
+		publicKeys := make([]*ecdsa.PublicKey, len(v.keys))
+		for i, k := range v.keys {
+			keys[i] = privateKeyFromHex(t, curve, k)
+			publicKeys[i] = &keys[i].PublicKey
+		}
+
+		keyAgg, err := MuSig2AggregatePublicKeys(publicKeys)
+		require.NoError(t, err)
+		aggKey, err := ecc.MarshalPoint(curve, keyAgg.AggregatedKey.X, keyAgg.AggregatedKey.Y, ecc.PointFormatCompressed)
+		require.NoError(t, err)
+		require.Equal(t, v.aggKey, hex.EncodeToString(aggKey))
+
+		secretNonces := make([]*MuSig2SecretNonce, len(v.nonces))
+		publicNonces := make([][]byte, len(v.nonces))
+		for i, nonce := range v.nonces {
+			k1 := new(big.Int).SetBytes(decodeHex(t, nonce[0]))
+			k2 := new(big.Int).SetBytes(decodeHex(t, nonce[1]))
+			secretNonces[i], publicNonces[i], err = newMuSig2SecretNonce(curve, k1, k2)
+			require.NoError(t, err)
+		}
+		aggNonce, err := MuSig2NonceAgg(curve, publicNonces)
+		require.NoError(t, err)
+		require.Equal(t, v.aggNonce, hex.EncodeToString(aggNonce))
+
+		partialSigs := make([][]byte, len(keys))
+		for i, key := range keys {
+			partialSigs[i], err = MuSig2PartialSign(secretNonces[i], key, keyAgg, aggNonce, muSig2Message)
+			require.NoError(t, err)
+			require.Equal(t, v.partialSigs[i], hex.EncodeToString(partialSigs[i]))
+			require.True(t, MuSig2PartialVerify(partialSigs[i], publicNonces[i], publicKeys[i], keyAgg, aggNonce, muSig2Message))
+		}
+
+		sig, err := MuSig2PartialSigAgg(keyAgg, aggNonce, muSig2Message, partialSigs)
+		require.NoError(t, err)
+		xuperSig := new(common.XuperSignature)
+		require.NoError(t, json.Unmarshal(sig, xuperSig))
+		require.Equal(t, common.MuSig2, xuperSig.SigType)
+		muSig := new(common.MultiSignature)
+		require.NoError(t, json.Unmarshal(xuperSig.SigContent, muSig))
+		require.Equal(t, v.r, hex.EncodeToString(muSig.R))
+		require.Equal(t, v.s, hex.EncodeToString(muSig.S))
+
+		ok, err := VerifyMuSig2(publicKeys, xuperSig.SigContent, muSig2Message)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestMuSig2(t *testing.T) {
+	for _, curve := range []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()} {
+		keys := make([]*ecdsa.PrivateKey, 3)
+		publicKeys := make([]*ecdsa.PublicKey, 3)
+		for i := range keys {
+			key, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+			keys[i] = key
+			publicKeys[i] = &key.PublicKey
+		}
+		keyAgg, err := MuSig2AggregatePublicKeys(publicKeys)
+		require.NoError(t, err)
+
+		// 第一轮：交换公开随机数
+		secretNonces := make([]*MuSig2SecretNonce, len(keys))
+		publicNonces := make([][]byte, len(keys))
+		for i, key := range keys {
+			secretNonces[i], publicNonces[i], err = MuSig2NonceGen(key)
+			require.NoError(t, err)
+			require.Equal(t, publicNonces[i], secretNonces[i].PublicNonce())
+		}
+		aggNonce, err := MuSig2NonceAgg(curve, publicNonces)
+		require.NoError(t, err)
+
+		// 第二轮：交换部分签名
+		partialSigs := make([][]byte, len(keys))
+		for i, key := range keys {
+			partialSigs[i], err = MuSig2PartialSign(secretNonces[i], key, keyAgg, aggNonce, muSig2Message)
+			require.NoError(t, err)
+
+			_, err = MuSig2PartialSign(secretNonces[i], key, keyAgg, aggNonce, muSig2Message)
+			require.Equal(t, SecretNonceUsedError, err)
+		}
+
+		// 部分签名与公开随机数、公钥一一对应
+		require.False(t, MuSig2PartialVerify(partialSigs[0], publicNonces[1], publicKeys[0], keyAgg, aggNonce, muSig2Message))
+		require.False(t, MuSig2PartialVerify(partialSigs[0], publicNonces[0], publicKeys[1], keyAgg, aggNonce, muSig2Message))
+
+		sig, err := MuSig2PartialSigAgg(keyAgg, aggNonce, muSig2Message, partialSigs)
+		require.NoError(t, err)
+		xuperSig := new(common.XuperSignature)
+		require.NoError(t, json.Unmarshal(sig, xuperSig))
+
+		ok, err := VerifyMuSig2(publicKeys, xuperSig.SigContent, muSig2Message)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = VerifyMuSig2(publicKeys, xuperSig.SigContent, []byte("another message"))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// 公钥顺序不同，聚合公钥也不同
+		reordered := []*ecdsa.PublicKey{publicKeys[1], publicKeys[0], publicKeys[2]}
+		ok, err = VerifyMuSig2(reordered, xuperSig.SigContent, muSig2Message)
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestMuSig2RogueKey(t *testing.T) {
+	curve := sm2.P256Sm2()
+	honest, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+	attacker, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+
+	// 攻击者公布 P' = X - P1，直接相加的公共公钥等于攻击者独自掌握私钥的X
+	negY := new(big.Int).Sub(curve.Params().P, honest.PublicKey.Y)
+	rx, ry := curve.Add(attacker.PublicKey.X, attacker.PublicKey.Y, honest.PublicKey.X, negY)
+	rogue := &ecdsa.PublicKey{Curve: curve, X: rx, Y: ry}
+	publicKeys := []*ecdsa.PublicKey{&honest.PublicKey, rogue}
+
+	naive, err := GetSharedPublicKeyForPublicKeys(publicKeys)
+	require.NoError(t, err)
+	require.Equal(t, elliptic.Marshal(curve, attacker.PublicKey.X, attacker.PublicKey.Y), naive)
+
+	// MuSig2的聚合公钥不再等于攻击者的公钥
+	keyAgg, err := MuSig2AggregatePublicKeys(publicKeys)
+	require.NoError(t, err)
+	require.False(t, keyAgg.AggregatedKey.X.Cmp(attacker.PublicKey.X) == 0 && keyAgg.AggregatedKey.Y.Cmp(attacker.PublicKey.Y) == 0)
+}
@@ -0,0 +1,324 @@
+package multisign
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+var (
+	PrivateKeyNotInParticipantsError = errors.New("The private key does not belong to any of the participants")
+	DuplicateParticipantError        = errors.New("The participants contain duplicate public keys")
+	UnknownParticipantError          = errors.New("The index does not refer to any participant")
+	SessionStateError                = errors.New("The operation is not allowed in the current round of the session")
+	DuplicateMessageError            = errors.New("The participant has already sent a different message in this round")
+	CommitmentMismatchError          = errors.New("The nonce does not match the commitment of the participant")
+	NonceAlreadyUsedError            = errors.New("The nonce of the session has already been used")
+	InvalidNonceError                = errors.New("The nonce is not a valid point on the curve")
+	InvalidPartialSignatureError     = errors.New("The partial signature is invalid")
+)
+
+// 会话的轮次
+const (
+	// 交换随机数的承诺 H(C, m, Ri)
+	roundCommitment = iota
+	// 交换随机数 Ri = Ki*G
+	roundNonce
+	// 交换部分签名 si = Ki + HASH(C,R,m) * xi
+	roundPartialSignature
+	// 签名已生成
+	roundDone
+)
+
+// SignerSession 交互式多重签名中单个签名者的会话状态
+// 每个签名者只持有自己的私钥，其它参与者只通过公钥出现。流程如下：
+// 1. 各方创建会话，生成随机数Ki，广播承诺ti = SM3(C, m, Ri) --- Commitment()
+// 2. 收集所有承诺后才能公开自己的 Ri = Ki*G --- AddCommitment()、Nonce()
+// 3. 收集所有Ri并校验承诺，计算 R = sum(Ri) --- AddNonce()
+// 4. 计算部分签名 si = Ki + HASH(C,R,m) * xi，Ki随后被清除，不能再次使用 --- PartialSign()
+// 5. 收集并逐一校验 si*G == Ri + HASH(C,R,m)*Pi，生成多重签名 --- AddPartialSignature()、Signature()
+// 承诺阶段保证任何一方都无法在看到其它人的Ri之后再选择自己的Ri
+type SignerSession struct {
+	curve      elliptic.Curve
+	key        *ecdsa.PrivateKey
+	publicKeys []*ecdsa.PublicKey
+	index      int
+	message    []byte
+
+	// 公共公钥C
+	sharedPublicKey []byte
+
+	k           *big.Int
+	commitments [][]byte
+	nonces      [][]byte
+	partialSigs [][]byte
+
+	// 聚合后的R以及挑战e = HASH(C,R,m)
+	r []byte
+	e *big.Int
+
+	round     int
+	nonceUsed bool
+}
+
+// NewSignerSession 创建签名者的会话，publicKeys为全部参与者的公钥，所有参与者需要使用相同的顺序
+func NewSignerSession(key *ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) (*SignerSession, error) {
+	if key == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(publicKeys) < MinimumParticipant {
+		return nil, TooSmallNumOfkeysError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	for _, pub := range publicKeys {
+		if pub == nil {
+			return nil, InvalidInputParamsError
+		}
+	}
+	if !checkCurveForPublicKeys(publicKeys) || publicKeys[0].Curve != key.Curve {
+		return nil, NotExactTheSameCurveInputError
+	}
+
+	index := -1
+	for i, pub := range publicKeys {
+		for j := 0; j < i; j++ {
+			if pub.X.Cmp(publicKeys[j].X) == 0 && pub.Y.Cmp(publicKeys[j].Y) == 0 {
+				return nil, DuplicateParticipantError
+			}
+		}
+		if pub.X.Cmp(key.PublicKey.X) == 0 && pub.Y.Cmp(key.PublicKey.Y) == 0 {
+			index = i
+		}
+	}
+	if index < 0 {
+		return nil, PrivateKeyNotInParticipantsError
+	}
+
+	c, err := GetSharedPublicKeyForPublicKeys(publicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	num := len(publicKeys)
+	session := &SignerSession{
+		curve:           key.Curve,
+		key:             key,
+		publicKeys:      publicKeys,
+		index:           index,
+		message:         append([]byte{}, message...),
+		sharedPublicKey: c,
+		commitments:     make([][]byte, num),
+		nonces:          make([][]byte, num),
+		partialSigs:     make([][]byte, num),
+		round:           roundCommitment,
+	}
+
+	// 生成随机数Ki，取值范围为[1, N)
+	n := session.curve.Params().N
+	for {
+		randomBytes, err := GetRandom32Bytes()
+		if err != nil {
+			return nil, err
+		}
+		k := new(big.Int).Mod(new(big.Int).SetBytes(randomBytes), n)
+		if k.Sign() != 0 {
+			session.k = k
+			break
+		}
+	}
+
+	ri := GetRiUsingRandomBytes(&key.PublicKey, session.k.Bytes())
+	session.nonces[index] = ri
+	session.commitments[index] = session.commit(ri)
+
+	return session, nil
+}
+
+// Index 返回本签名者在参与者列表中的序号
+func (s *SignerSession) Index() int {
+	return s.index
+}
+
+// Commitment 返回需要广播给其它参与者的随机数承诺
+func (s *SignerSession) Commitment() []byte {
+	return append([]byte{}, s.commitments[s.index]...)
+}
+
+// AddCommitment 记录其它参与者的随机数承诺
+func (s *SignerSession) AddCommitment(index int, commitment []byte) error {
+	if s.round != roundCommitment {
+		return SessionStateError
+	}
+	if err := s.checkIndex(index); err != nil {
+		return err
+	}
+	if len(commitment) == 0 {
+		return InvalidInputParamsError
+	}
+	if s.commitments[index] != nil {
+		if bytes.Equal(s.commitments[index], commitment) {
+			return nil
+		}
+		return DuplicateMessageError
+	}
+
+	s.commitments[index] = append([]byte{}, commitment...)
+	if allCollected(s.commitments) {
+		s.round = roundNonce
+	}
+	return nil
+}
+
+// Nonce 收集到全部承诺之后，返回需要广播的Ri
+func (s *SignerSession) Nonce() ([]byte, error) {
+	if s.round < roundNonce || s.nonceUsed {
+		return nil, SessionStateError
+	}
+	return append([]byte{}, s.nonces[s.index]...), nil
+}
+
+// AddNonce 记录其它参与者公开的Ri，并校验其与承诺一致
+func (s *SignerSession) AddNonce(index int, ri []byte) error {
+	if s.round != roundNonce {
+		return SessionStateError
+	}
+	if err := s.checkIndex(index); err != nil {
+		return err
+	}
+	if s.nonces[index] != nil {
+		if bytes.Equal(s.nonces[index], ri) {
+			return nil
+		}
+		return DuplicateMessageError
+	}
+
+	x, _ := elliptic.Unmarshal(s.curve, ri)
+	if x == nil {
+		return InvalidNonceError
+	}
+	if !bytes.Equal(s.commit(ri), s.commitments[index]) {
+		return CommitmentMismatchError
+	}
+
+	s.nonces[index] = append([]byte{}, ri...)
+	if allCollected(s.nonces) {
+		s.r = GetRUsingAllRi(&s.key.PublicKey, s.nonces)
+		hashBytes := hash.HashUsingSM3(utils.BytesCombine(s.sharedPublicKey, s.r, s.message))
+		s.e = new(big.Int).Mod(new(big.Int).SetBytes(hashBytes), s.curve.Params().N)
+		s.round = roundPartialSignature
+	}
+	return nil
+}
+
+// PartialSign 计算部分签名 si = Ki + HASH(C,R,m) * xi
+// 每个会话只能签名一次，签名后随机数Ki被清除
+func (s *SignerSession) PartialSign() ([]byte, error) {
+	if s.nonceUsed {
+		return nil, NonceAlreadyUsedError
+	}
+	if s.round != roundPartialSignature {
+		return nil, SessionStateError
+	}
+
+	n := s.curve.Params().N
+	si := new(big.Int).Mul(s.e, s.key.D)
+	si.Add(si, s.k)
+	si.Mod(si, n)
+
+	s.k.SetInt64(0)
+	s.k = nil
+	s.nonceUsed = true
+
+	s.partialSigs[s.index] = si.Bytes()
+	if allCollected(s.partialSigs) {
+		s.round = roundDone
+	}
+	return si.Bytes(), nil
+}
+
+// AddPartialSignature 校验并记录其它参与者的部分签名：si*G == Ri + HASH(C,R,m)*Pi
+func (s *SignerSession) AddPartialSignature(index int, si []byte) error {
+	if s.round != roundPartialSignature {
+		return SessionStateError
+	}
+	if err := s.checkIndex(index); err != nil {
+		return err
+	}
+	if s.partialSigs[index] != nil {
+		if bytes.Equal(s.partialSigs[index], si) {
+			return nil
+		}
+		return DuplicateMessageError
+	}
+	if !s.verifyPartialSignature(index, si) {
+		return InvalidPartialSignatureError
+	}
+
+	s.partialSigs[index] = append([]byte{}, si...)
+	if allCollected(s.partialSigs) && s.nonceUsed {
+		s.round = roundDone
+	}
+	return nil
+}
+
+// Signature 收集全部部分签名后生成多重签名：(s1 + s2 + ... + sn, R)
+func (s *SignerSession) Signature() ([]byte, error) {
+	if s.round != roundDone {
+		return nil, SessionStateError
+	}
+
+	sum := new(big.Int).SetBytes(GetSUsingAllSi(s.partialSigs))
+	sum.Mod(sum, s.curve.Params().N)
+
+	return GenerateMultiSignSignature(sum.Bytes(), s.r)
+}
+
+func (s *SignerSession) verifyPartialSignature(index int, si []byte) bool {
+	n := s.curve.Params().N
+	sInt := new(big.Int).SetBytes(si)
+	if len(si) == 0 || sInt.Cmp(n) >= 0 {
+		return false
+	}
+
+	// 计算si*G
+	lhsX, lhsY := s.curve.ScalarBaseMult(sInt.Bytes())
+
+	// 计算Ri + e*Pi
+	pub := s.publicKeys[index]
+	rx, ry := elliptic.Unmarshal(s.curve, s.nonces[index])
+	ex, ey := s.curve.ScalarMult(pub.X, pub.Y, s.e.Bytes())
+	rhsX, rhsY := s.curve.Add(rx, ry, ex, ey)
+
+	return lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
+}
+
+// commit 随机数承诺绑定公共公钥和待签名消息，不能被其它会话重放
+func (s *SignerSession) commit(ri []byte) []byte {
+	return hash.HashUsingSM3(utils.BytesCombine(s.sharedPublicKey, s.message, ri))
+}
+
+func (s *SignerSession) checkIndex(index int) error {
+	if index < 0 || index >= len(s.publicKeys) {
+		return UnknownParticipantError
+	}
+	if index == s.index {
+		return DuplicateMessageError
+	}
+	return nil
+}
+
+func allCollected(items [][]byte) bool {
+	for _, item := range items {
+		if item == nil {
+			return false
+		}
+	}
+	return true
+}
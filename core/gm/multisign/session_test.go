@@ -0,0 +1,137 @@
+package multisign
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func newTestSessions(t *testing.T, num int, message []byte) ([]*SignerSession, []*ecdsa.PublicKey) {
+	keys := make([]*ecdsa.PrivateKey, num)
+	publicKeys := make([]*ecdsa.PublicKey, num)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		publicKeys[i] = &key.PublicKey
+	}
+
+	sessions := make([]*SignerSession, num)
+	for i, key := range keys {
+		session, err := NewSignerSession(key, publicKeys, message)
+		require.NoError(t, err)
+		require.Equal(t, i, session.Index())
+		sessions[i] = session
+	}
+	return sessions, publicKeys
+}
+
+func exchangeCommitments(t *testing.T, sessions []*SignerSession) {
+	for _, receiver := range sessions {
+		for _, sender := range sessions {
+			if sender == receiver {
+				continue
+			}
+			require.NoError(t, receiver.AddCommitment(sender.Index(), sender.Commitment()))
+		}
+	}
+}
+
+func exchangeNonces(t *testing.T, sessions []*SignerSession) {
+	for _, sender := range sessions {
+		nonce, err := sender.Nonce()
+		require.NoError(t, err)
+		for _, receiver := range sessions {
+			if sender == receiver {
+				continue
+			}
+			require.NoError(t, receiver.AddNonce(sender.Index(), nonce))
+		}
+	}
+}
+
+func TestSignerSession(t *testing.T) {
+	message := []byte("interactive multi-signature")
+	sessions, publicKeys := newTestSessions(t, 3, message)
+
+	// 收集全部承诺之前不能公开随机数
+	_, err := sessions[0].Nonce()
+	require.Equal(t, SessionStateError, err)
+
+	exchangeCommitments(t, sessions)
+	exchangeNonces(t, sessions)
+
+	partialSigs := make([][]byte, len(sessions))
+	for i, session := range sessions {
+		partialSigs[i], err = session.PartialSign()
+		require.NoError(t, err)
+
+		// 随机数只能使用一次
+		_, err = session.PartialSign()
+		require.Equal(t, NonceAlreadyUsedError, err)
+	}
+
+	for _, receiver := range sessions {
+		for i, si := range partialSigs {
+			if i == receiver.Index() {
+				continue
+			}
+			require.NoError(t, receiver.AddPartialSignature(i, si))
+		}
+	}
+
+	sig, err := sessions[1].Signature()
+	require.NoError(t, err)
+
+	xuperSig := new(common.XuperSignature)
+	require.NoError(t, json.Unmarshal(sig, xuperSig))
+	require.Equal(t, common.MultiSig, xuperSig.SigType)
+
+	ok, err := VerifyMultiSig(publicKeys, xuperSig.SigContent, message)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = VerifyMultiSig(publicKeys, xuperSig.SigContent, []byte("another message"))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSignerSessionRejectsMisbehaviour(t *testing.T) {
+	message := []byte("interactive multi-signature")
+	sessions, publicKeys := newTestSessions(t, 2, message)
+
+	// 不属于参与者的私钥
+	outsider, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	_, err = NewSignerSession(outsider, publicKeys, message)
+	require.Equal(t, PrivateKeyNotInParticipantsError, err)
+
+	// 重复的公钥
+	_, err = NewSignerSession(outsider, []*ecdsa.PublicKey{&outsider.PublicKey, &outsider.PublicKey}, message)
+	require.Equal(t, DuplicateParticipantError, err)
+
+	require.Equal(t, UnknownParticipantError, sessions[0].AddCommitment(5, sessions[1].Commitment()))
+	exchangeCommitments(t, sessions)
+
+	// 公开的随机数与承诺不一致
+	other, _ := newTestSessions(t, 2, message)
+	otherNonce := other[1].nonces[1]
+	require.Equal(t, CommitmentMismatchError, sessions[0].AddNonce(1, otherNonce))
+
+	exchangeNonces(t, sessions)
+
+	si, err := sessions[1].PartialSign()
+	require.NoError(t, err)
+	si[len(si)-1] ^= 1
+	require.Equal(t, InvalidPartialSignatureError, sessions[0].AddPartialSignature(1, si))
+
+	// 部分签名不完整时不能生成多重签名
+	_, err = sessions[0].Signature()
+	require.Equal(t, SessionStateError, err)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/legendzhouwd/cu_crypto/core/gm/common"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
 	"github.com/xuperchain/crypto/common/utils"
 )
 
@@ -36,8 +37,21 @@ const (
 // 5. 各方计算：si = ki + HASH(C,R,m) * xi
 // 6. 生成多重签名：(s1 + s2 + ... + sn, R)
 // MultiSign生成对特定消息的多重签名，所有参与签名的私钥必须使用同一条椭圆曲线
+// MultiSign需要所有私钥位于同一进程中，各方分别持有私钥时请使用SignerSession
 // func MultiSign(keys []*ecdsa.PrivateKey, message []byte) (*MultiSignature, error) {
 func MultiSign(keys []*ecdsa.PrivateKey, message []byte) ([]byte, error) {
+	return multiSign(keys, message, nonce.ModeRandom)
+}
+
+// MultiSignWithNonceMode 与MultiSign相同，临时随机数ki按mode生成：
+// nonce.ModeDeterministic与nonce.ModeHedged对每个私钥使用RFC 6979构造，摘要为HASH(C || m)。
+// 只适用于全部私钥位于同一进程的MultiSign；SignerSession与MuSig2中其它参与者可以改变R，
+// 确定性的ki会使同一个ki用于不同的HASH(C,R,m)从而泄露私钥，因此这两种交互流程始终使用随机数。
+func MultiSignWithNonceMode(keys []*ecdsa.PrivateKey, message []byte, mode nonce.Mode) ([]byte, error) {
+	return multiSign(keys, message, mode)
+}
+
+func multiSign(keys []*ecdsa.PrivateKey, message []byte, mode nonce.Mode) ([]byte, error) {
 	if len(keys) < MinimumParticipant {
 		return nil, TooSmallNumOfkeysError
 	}
@@ -54,9 +68,20 @@ func MultiSign(keys []*ecdsa.PrivateKey, message []byte) ([]byte, error) {
 		return nil, NotExactTheSameCurveInputError
 	}
 
+	// 4. 计算公共公钥：C = P1 + P2 + ... + Pn
+	c, err := getSharedPublicKeyForPrivateKeys(keys)
+	if err != nil {
+		return nil, err
+	}
+
 	// 2. 生成临时随机数的数组(k1, k2, ..., kn)
 	num := len(keys)
-	arrayOfK, err := getRandomBytesArray(num)
+	var arrayOfK [][]byte
+	if mode == nonce.ModeRandom {
+		arrayOfK, err = getRandomBytesArray(num)
+	} else {
+		arrayOfK, err = getNonceBytesArray(keys, c, message, mode)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -64,12 +89,6 @@ func MultiSign(keys []*ecdsa.PrivateKey, message []byte) ([]byte, error) {
 	// 3. 计算：R = k1*G + k2*G + ... + kn*G
 	r := getRUsingRandomBytesArray(keys, arrayOfK)
 
-	// 4. 计算公共公钥：C = P1 + P2 + ... + Pn
-	c, err := getSharedPublicKeyForPrivateKeys(keys)
-	if err != nil {
-		return nil, err
-	}
-
 	// 5. 各方计算：S = sum(si)
 	// si = ki + HASH(C,R,m) * xi
 	s := getS(keys, arrayOfK, c, r, message)
@@ -160,6 +179,26 @@ func getRandomBytesArray(num int) ([][]byte, error) {
 	return randomBytesArray, nil
 }
 
+// 按mode为每个私钥生成临时随机数(k1, k2, ..., kn)
+func getNonceBytesArray(keys []*ecdsa.PrivateKey, c []byte, message []byte, mode nonce.Mode) ([][]byte, error) {
+	curve := keys[0].Curve
+	digest := nonce.Digest(curve, c, message)
+	arrayOfK := make([][]byte, len(keys))
+	for i, key := range keys {
+		g, err := nonce.NewGenerator(curve, key.D, digest, mode)
+		if err != nil {
+			return nil, err
+		}
+		k, err := g.Next()
+		if err != nil {
+			return nil, err
+		}
+		arrayOfK[i] = k.Bytes()
+	}
+
+	return arrayOfK, nil
+}
+
 // 计算公共公钥：C = P1 + P2 + ... + Pn
 func getSharedPublicKeyForPrivateKeys(keys []*ecdsa.PrivateKey) ([]byte, error) {
 	num := len(keys)
@@ -0,0 +1,171 @@
+package nonce
+
+import (
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 签名随机数k的生成方式
+//
+// 签名的安全性依赖于k的随机性：同一个k被使用两次，或者k可以被预测时，私钥会被泄露。
+// 默认的ModeRandom与原有实现一致，直接使用随机数发生器；随机数发生器不可靠时可以选择：
+//
+// ModeDeterministic: RFC 6979的HMAC-DRBG，k由私钥与消息摘要唯一确定，不依赖随机数发生器。
+// NIST曲线使用HMAC-SHA256，SM2曲线使用相同的构造，哈希函数替换为SM3（HMAC-SM3）。
+//
+// ModeHedged: 在RFC 6979的基础上加入新鲜的随机数（RFC 6979 3.6节的额外数据k'），
+// 随机数发生器正常时k不可预测，随机数发生器失效时仍然具有确定性模式的安全性，
+// 同时避免确定性签名在故障注入攻击下的风险。
+type Mode int
+
+const (
+	ModeRandom Mode = iota
+	ModeDeterministic
+	ModeHedged
+)
+
+const hedgedEntropyLength = 32
+
+var (
+	InvalidInputParamsError = errors.New("Invalid input params")
+	InvalidModeError        = errors.New("The nonce mode is not supported")
+)
+
+// Generator 签名随机数发生器，签名算法拒绝某个k时（例如r = 0）调用Next得到下一个候选值
+type Generator struct {
+	curve elliptic.Curve
+	mode  Mode
+
+	newHash func() hash.Hash
+	k, v    []byte
+	started bool
+}
+
+// NewGenerator 根据私钥x与消息摘要digest创建随机数发生器
+func NewGenerator(curve elliptic.Curve, x *big.Int, digest []byte, mode Mode) (*Generator, error) {
+	if curve == nil || x == nil {
+		return nil, InvalidInputParamsError
+	}
+	g := &Generator{curve: curve, mode: mode, newHash: HashFunc(curve)}
+
+	switch mode {
+	case ModeRandom:
+		return g, nil
+	case ModeDeterministic:
+		g.init(x, digest, nil)
+		return g, nil
+	case ModeHedged:
+		entropy := make([]byte, hedgedEntropyLength)
+		if _, err := rand.Read(entropy); err != nil {
+			return nil, err
+		}
+		g.init(x, digest, entropy)
+		return g, nil
+	default:
+		return nil, InvalidModeError
+	}
+}
+
+// Next 返回[1, N)内的下一个随机数
+func (g *Generator) Next() (*big.Int, error) {
+	n := g.curve.Params().N
+	if g.mode == ModeRandom {
+		for {
+			k, err := rand.Int(rand.Reader, n)
+			if err != nil {
+				return nil, err
+			}
+			if k.Sign() != 0 {
+				return k, nil
+			}
+		}
+	}
+
+	qlen := n.BitLen()
+	for {
+		// 上一个候选值被拒绝：K = HMAC_K(V || 0x00)，V = HMAC_K(V)
+		if g.started {
+			g.k = g.mac(g.k, g.v, []byte{0x00})
+			g.v = g.mac(g.k, g.v)
+		}
+		g.started = true
+
+		var t []byte
+		for len(t)*8 < qlen {
+			g.v = g.mac(g.k, g.v)
+			t = append(t, g.v...)
+		}
+		k := bits2int(t, qlen)
+		if k.Sign() > 0 && k.Cmp(n) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// HashFunc 随机数发生器使用的哈希函数，SM2曲线使用SM3，其它曲线使用SHA256
+func HashFunc(curve elliptic.Curve) func() hash.Hash {
+	if curve.Params().Name == config.CurveGm {
+		return sm3.New
+	}
+	return sha256.New
+}
+
+// Digest 使用HashFunc(curve)计算data的摘要，用于签名算法本身不对消息做哈希的场景
+func Digest(curve elliptic.Curve, data ...[]byte) []byte {
+	h := HashFunc(curve)()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// init RFC 6979 3.2节的b ~ g步
+func (g *Generator) init(x *big.Int, digest, extra []byte) {
+	n := g.curve.Params().N
+	qlen := n.BitLen()
+	rlen := (qlen + 7) / 8
+	hlen := g.newHash().Size()
+
+	// int2octets(x)
+	xBytes := new(big.Int).Mod(x, n).FillBytes(make([]byte, rlen))
+	// bits2octets(h1) = int2octets(bits2int(h1) mod q)
+	z := bits2int(digest, qlen)
+	z.Mod(z, n)
+	hBytes := z.FillBytes(make([]byte, rlen))
+
+	g.v = make([]byte, hlen)
+	for i := range g.v {
+		g.v[i] = 0x01
+	}
+	g.k = make([]byte, hlen)
+
+	g.k = g.mac(g.k, g.v, []byte{0x00}, xBytes, hBytes, extra)
+	g.v = g.mac(g.k, g.v)
+	g.k = g.mac(g.k, g.v, []byte{0x01}, xBytes, hBytes, extra)
+	g.v = g.mac(g.k, g.v)
+}
+
+func (g *Generator) mac(key []byte, data ...[]byte) []byte {
+	m := hmac.New(g.newHash, key)
+	for _, d := range data {
+		m.Write(d)
+	}
+	return m.Sum(nil)
+}
+
+// bits2int 取前qlen比特转换为整数
+func bits2int(data []byte, qlen int) *big.Int {
+	v := new(big.Int).SetBytes(data)
+	if blen := len(data) * 8; blen > qlen {
+		v.Rsh(v, uint(blen-qlen))
+	}
+	return v
+}
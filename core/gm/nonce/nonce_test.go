@@ -0,0 +1,95 @@
+package nonce
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func hexInt(t *testing.T, s string) *big.Int {
+	v, ok := new(big.Int).SetString(s, 16)
+	require.True(t, ok)
+	return v
+}
+
+// RFC 6979 A.2.5，P-256与SHA-256
+func TestRFC6979P256(t *testing.T) {
+	curve := elliptic.P256()
+	n := curve.Params().N
+	x := hexInt(t, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+
+	vectors := []struct {
+		message string
+		k, r, s string
+	}{
+		{
+			"sample",
+			"A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+		},
+		{
+			"test",
+			"D16B6AE827F17175E040871A1C7EC3500192C4C92677336EC2537ACAEE0008E0",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083",
+		},
+	}
+
+	for _, v := range vectors {
+		digest := sha256.Sum256([]byte(v.message))
+		g, err := NewGenerator(curve, x, digest[:], ModeDeterministic)
+		require.NoError(t, err)
+		k, err := g.Next()
+		require.NoError(t, err)
+		require.Equal(t, hexInt(t, v.k), k)
+
+		// r = (k*G).x mod n，s = k^-1 * (h + r*x) mod n
+		r, _ := curve.ScalarBaseMult(k.Bytes())
+		r.Mod(r, n)
+		s := new(big.Int).Mul(r, x)
+		s.Add(s, new(big.Int).SetBytes(digest[:]))
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		require.Equal(t, hexInt(t, v.r), r)
+		require.Equal(t, hexInt(t, v.s), s)
+	}
+}
+
+func TestGeneratorModes(t *testing.T) {
+	curve := elliptic.P256()
+	x := hexInt(t, "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721")
+	digest := Digest(curve, []byte("sample"))
+
+	next := func(mode Mode) []*big.Int {
+		g, err := NewGenerator(curve, x, digest, mode)
+		require.NoError(t, err)
+		ks := make([]*big.Int, 3)
+		for i := range ks {
+			ks[i], err = g.Next()
+			require.NoError(t, err)
+			require.True(t, ks[i].Sign() > 0 && ks[i].Cmp(curve.Params().N) < 0)
+		}
+		return ks
+	}
+
+	// 确定性模式：相同输入得到相同的候选序列，候选值互不相同
+	first, second := next(ModeDeterministic), next(ModeDeterministic)
+	require.Equal(t, first, second)
+	require.NotEqual(t, first[0], first[1])
+	require.NotEqual(t, first[1], first[2])
+
+	// 混合模式与随机模式：每次的结果都不同
+	for _, mode := range []Mode{ModeHedged, ModeRandom} {
+		require.NotEqual(t, next(mode)[0], next(mode)[0])
+		require.NotEqual(t, first[0], next(mode)[0])
+	}
+
+	_, err := NewGenerator(curve, x, digest, Mode(100))
+	require.Equal(t, InvalidModeError, err)
+	_, err = NewGenerator(curve, nil, digest, ModeDeterministic)
+	require.Equal(t, InvalidInputParamsError, err)
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/legendzhouwd/cu_crypto/core/gm/common"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
 	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
 	"github.com/xuperchain/crypto/common/utils"
 )
@@ -76,6 +77,17 @@ const (
 // It is impossible for us to know who signed the signature, as everyone can use his private key
 // to fulfill the gap and close the ring.
 func Sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte) ([]byte, error) {
+	return sign(keys, privateKey, message, nonce.ModeRandom)
+}
+
+// SignWithNonceMode 与Sign相同，k与其它成员的s(i)按mode生成：
+// nonce.ModeDeterministic与nonce.ModeHedged使用RFC 6979构造，摘要覆盖消息以及排列后的全部成员公钥，
+// 因此签名者位置不同时k也不同。签名者位置r仍然随机选择，确定性模式下同一输入的签名不保证相同。
+func SignWithNonceMode(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte, mode nonce.Mode) ([]byte, error) {
+	return sign(keys, privateKey, message, mode)
+}
+
+func sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte, mode nonce.Mode) ([]byte, error) {
 	// params check
 	err := checkRingSignParams(keys, privateKey, message)
 	if err != nil {
@@ -97,8 +109,13 @@ func Sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte)
 	keys = append(keys[:signerIndex], &privateKey.PublicKey)
 	keys = append(keys, temp...)
 
+	nextScalar, err := scalarSource(keys, privateKey, message, mode)
+	if err != nil {
+		return nil, err
+	}
+
 	// 1. Signer(index r) choose a random number k within [1:N-1]
-	k, err := rand.GenerateSeedWithStrengthAndKeyLen(rand.KeyStrengthHard, rand.KeyLengthInt32)
+	k, err := nextScalar()
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +135,7 @@ func Sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte)
 	// for i:=(r+1)%R; i!=r; i++%R
 	for i := (signerIndex + 1) % lenOfRing; i != signerIndex; i = (i + 1) % lenOfRing {
 		// Choose a random number s((r+1)%R), i.e. s(i) within [1:N-1]
-		s, err := rand.GenerateSeedWithStrengthAndKeyLen(rand.KeyStrengthHard, rand.KeyLengthInt32)
+		s, err := nextScalar()
 		if err != nil {
 			return nil, err
 		}
@@ -192,6 +209,32 @@ func Sign(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte)
 	return sig, nil
 }
 
+// scalarSource 返回生成k与s(i)的函数，keys为已经插入签名者公钥后的全部成员
+func scalarSource(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte, mode nonce.Mode) (func() ([]byte, error), error) {
+	if mode == nonce.ModeRandom {
+		return func() ([]byte, error) {
+			return rand.GenerateSeedWithStrengthAndKeyLen(rand.KeyStrengthHard, rand.KeyLengthInt32)
+		}, nil
+	}
+
+	curve := privateKey.Curve
+	data := [][]byte{message}
+	for _, key := range keys {
+		data = append(data, elliptic.Marshal(curve, key.X, key.Y))
+	}
+	g, err := nonce.NewGenerator(curve, privateKey.D, nonce.Digest(curve, data...), mode)
+	if err != nil {
+		return nil, err
+	}
+	return func() ([]byte, error) {
+		v, err := g.Next()
+		if err != nil {
+			return nil, err
+		}
+		return v.Bytes(), nil
+	}, nil
+}
+
 func checkRingSignParams(keys []*ecdsa.PublicKey, privateKey *ecdsa.PrivateKey, message []byte) error {
 	if privateKey == nil {
 		return fmt.Errorf("Invalid privateKey. PrivateKey must not be nil.")
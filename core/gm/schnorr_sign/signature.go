@@ -16,12 +16,14 @@ import (
 
 	"github.com/legendzhouwd/cu_crypto/core/gm/common"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
 	"github.com/xuperchain/crypto/common/utils"
 )
 
 var (
-	GenerateSignatureError = errors.New("Failed to generate the schnorr signature, s = 0 happened.")
-	EmptyMessageError      = errors.New("The message to be signed should not be empty")
+	GenerateSignatureError  = errors.New("Failed to generate the schnorr signature, s = 0 happened.")
+	EmptyMessageError       = errors.New("The message to be signed should not be empty")
+	InvalidInputParamsError = errors.New("Invalid input params")
 )
 
 // Schnorr signatures use a particular function, defined as:
@@ -83,15 +85,49 @@ func Sign(privateKey *ecdsa.PrivateKey, message []byte) (schnorrSignature []byte
 	//	k := hash.HashUsingSha256(append(message, privateKey.D.Bytes()...))
 	k := hash.HashUsingSM3(utils.BytesCombine(message, privateKey.D.Bytes()))
 
+	return signWithNonce(privateKey, message, new(big.Int).SetBytes(k))
+}
+
+// SignWithNonceMode 与Sign相同，随机数k按mode生成：
+// nonce.ModeRandom保持Sign原有的k = H(m || x)；nonce.ModeDeterministic使用RFC 6979构造
+// （SM2曲线为HMAC-SM3，NIST曲线为HMAC-SHA256，消息摘要使用对应的哈希函数）；
+// nonce.ModeHedged在确定性构造中混入新鲜的随机数
+func SignWithNonceMode(privateKey *ecdsa.PrivateKey, message []byte, mode nonce.Mode) ([]byte, error) {
+	if privateKey == nil {
+		return nil, fmt.Errorf("Invalid privateKey. PrivateKey must not be nil.")
+	}
+	if mode == nonce.ModeRandom {
+		return Sign(privateKey, message)
+	}
+
+	g, err := nonce.NewGenerator(privateKey.Curve, privateKey.D, nonce.Digest(privateKey.Curve, message), mode)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		k, err := g.Next()
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signWithNonce(privateKey, message, k)
+		if err == GenerateSignatureError {
+			// s = 0，换下一个k
+			continue
+		}
+		return sig, err
+	}
+}
+
+// signWithNonce 使用给定的k计算Schnorr签名
+func signWithNonce(privateKey *ecdsa.PrivateKey, message []byte, intK *big.Int) ([]byte, error) {
 	// 2. Compute e = H(m || k * G)
 	// 2.1 compute k * G
 	curve := privateKey.Curve
-	x, y := curve.ScalarBaseMult(k)
+	x, y := curve.ScalarBaseMult(intK.Bytes())
 	// 2.2 compute H(m || k * G)
 	e := hash.HashUsingSM3(utils.BytesCombine(message, elliptic.Marshal(curve, x, y)))
 
 	// 3. k = s + e * x, so we can compute s = k - e * x
-	intK := new(big.Int).SetBytes(k)
 	intE := new(big.Int).SetBytes(e)
 
 	intS, err := ComputeSByKEX(curve, intK, intE, privateKey.D)
@@ -159,6 +195,9 @@ func Verify(publicKey *ecdsa.PublicKey, sig []byte, message []byte) (valid bool,
 	if err != nil {
 		return false, fmt.Errorf("Failed unmashalling schnorr signature [%s]", err)
 	}
+	if signature.E == nil || signature.S == nil {
+		return false, nil
+	}
 
 	// 1. compute h(m|| s * g + e * p)
 	// 1.1 compute s * g
@@ -182,3 +221,21 @@ func Verify(publicKey *ecdsa.PublicKey, sig []byte, message []byte) (valid bool,
 	}
 	return true, nil
 }
+
+// BatchVerify 批量验证Schnorr签名，返回验证失败的下标。
+// (e, s)格式的签名不包含R，必须先对每个签名计算 s*G + e*P 再比较哈希，
+// 无法使用随机线性组合合并验证等式，因此这里并行地逐个验证。
+func BatchVerify(publicKeys []*ecdsa.PublicKey, sigs, messages [][]byte) ([]int, error) {
+	if len(publicKeys) != len(sigs) || len(sigs) != len(messages) {
+		return nil, InvalidInputParamsError
+	}
+
+	invalid := common.ParallelVerify(len(sigs), func(i int) bool {
+		if publicKeys[i] == nil {
+			return false
+		}
+		ok, err := Verify(publicKeys[i], sigs[i], messages[i])
+		return err == nil && ok
+	})
+	return invalid, nil
+}
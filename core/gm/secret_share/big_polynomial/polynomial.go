@@ -12,6 +12,7 @@ import (
 	"math/big"
 	//	"fmt"
 
+	"github.com/legendzhouwd/cu_crypto/common/math/polynomial"
 	"github.com/legendzhouwd/cu_crypto/core/gm/hdwallet/rand"
 )
 
@@ -22,6 +23,7 @@ const (
 
 var (
 	prime, _ = big.NewInt(0).SetString(PrimeStr, 10)
+	field, _ = polynomial.NewField(prime)
 )
 
 // make a random polynomials F(x) of Degree [degree], and the const(X-Intercept) is [intercept]
@@ -91,107 +93,31 @@ func Evaluate(polynomialCoefficients []*big.Int, specifiedValue *big.Int) *big.I
 
 // 对2个多项式进行加法操作
 func Add(a []*big.Int, b []*big.Int) []*big.Int {
-	degree := len(a)
-	c := make([]*big.Int, degree)
-
-	// 初始化big int数组
-	for i, _ := range c {
-		c[i] = big.NewInt(0)
-	}
-
-	for i := 0; i < degree; i++ {
-		c[i] = a[i].Add(a[i], b[i])
-
-		// 域运算
-		c[i] = big.NewInt(0).Mod(c[i], prime)
+	length := len(a)
+	if len(b) > length {
+		length = len(b)
 	}
 
-	return c
+	return padding(field.Add(a, b), length)
 }
 
 // 对2个多项式进行乘法操作
 func Multiply(a []*big.Int, b []*big.Int) []*big.Int {
-	degA := len(a)
-	degB := len(b)
-	result := make([]*big.Int, degA+degB-1)
-
-	// 初始化big int数组
-	for i, _ := range result {
-		result[i] = big.NewInt(0)
-	}
-
-	for i := 0; i < degA; i++ {
-		for j := 0; j < degB; j++ {
-			temp := a[i].Mul(a[i], b[j])
-			//			log.Printf("temp is %v", temp)
-			//			log.Printf("result[i+j] is %v", result[i+j])
-			result[i+j] = result[i+j].Add(result[i+j], temp)
-		}
+	if len(a) == 0 || len(b) == 0 {
+		return []*big.Int{}
 	}
 
-	return result
+	return padding(field.Mul(a, b), len(a)+len(b)-1)
 }
 
 // 将1个多项式与指定系数k进行乘法操作
 func Scale(a []*big.Int, k *big.Int) []*big.Int {
-	b := make([]*big.Int, len(a))
-
-	for i := 0; i < len(a); i++ {
-		b[i] = a[i].Mul(a[i], k)
-
-		// 域运算
-		b[i] = big.NewInt(0).Mod(b[i], prime)
-	}
-
-	return b
-}
-
-// 获取拉格朗日基本多项式（插值基函数）
-func getLagrangeBasePolynomial(xs []*big.Int, xpos int) []*big.Int {
-	var poly []*big.Int
-	poly = append(poly, big.NewInt(1))
-
-	// 分母
-	denominator := big.NewInt(1)
-
-	for i := 0; i < len(xs); i++ {
-		if i != xpos {
-			currentTerm := make([]*big.Int, 2)
-			currentTerm[0] = big.NewInt(1)
-			//			currentTerm[1] = -xs[i]
-			currentTerm[1] = big.NewInt(0).Sub(big.NewInt(0), xs[i])
-			//			denominator *= xs[xpos] - xs[i]
-			denominator = denominator.Mul(denominator, big.NewInt(0).Sub(xs[xpos], xs[i]))
-			poly = Multiply(poly, currentTerm)
-		}
-	}
-
-	//	log.Printf("getLagrangeBasePolynomial poly is: %v and denominator is %v", poly, denominator)
-	//	return Scale(poly, 1/float64(denominator))
-	//	inverser := getInverse(denominator)
-	inverser := big.NewInt(0).ModInverse(denominator, prime)
-	//	log.Printf("scale factor is: %v and denominator is: %v", inverser, denominator)
-
-	// 校验是否在有限域上的取逆操作是正确的
-	//	tmp := big.NewInt(0).Mul(denominator, inverser)
-	//	tmp = big.NewInt(0).Mod(tmp, prime)
-	//	log.Printf("inverser check result is: %v", tmp)
-
-	return Scale(poly, inverser)
+	return padding(field.Scale(a, k), len(a))
 }
 
 // 利用Lagrange Polynomial Interpolation Formula，通过给定坐标点集合来计算多项式
-// func GetPolynomialByPoints(points map[int]int) []float64 {
+// 返回的系数按降序排列，最后一项为常数项
 func GetPolynomialByPoints(points map[int]*big.Int) []*big.Int {
-	degree := len(points)
-	bases := make([][]*big.Int, degree)
-	result := make([]*big.Int, degree)
-
-	// 初始化big int数组
-	for i, _ := range result {
-		result[i] = big.NewInt(0)
-	}
-
 	var xs []*big.Int
 	var ys []*big.Int
 
@@ -200,14 +126,31 @@ func GetPolynomialByPoints(points map[int]*big.Int) []*big.Int {
 		ys = append(ys, v)
 	}
 
-	for i := 0; i < degree; i++ {
-		bases[i] = getLagrangeBasePolynomial(xs, i)
+	result, err := field.Interpolate(xs, ys)
+	if err != nil {
+		return nil
 	}
 
-	for i := 0; i < degree; i++ {
-		//		log.Printf("The coefficients of the lagrange base polynomial[%d] is: %v", i, bases[i])
-		result = Add(result, Scale(bases[i], ys[i]))
+	return reverse(padding(result, len(points)))
+}
+
+// 高次补0，保持与原有接口一致的长度
+func padding(a polynomial.Polynomial, length int) []*big.Int {
+	result := make([]*big.Int, length)
+	for i := range result {
+		if i < len(a) {
+			result[i] = a[i]
+		} else {
+			result[i] = big.NewInt(0)
+		}
 	}
+	return result
+}
 
+func reverse(a []*big.Int) []*big.Int {
+	result := make([]*big.Int, len(a))
+	for i, c := range a {
+		result[len(a)-1-i] = c
+	}
 	return result
 }
@@ -0,0 +1,43 @@
+package sign
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+)
+
+var InvalidInputParamsError = errors.New("Invalid input params")
+
+// BatchVerifyECDSA 并行验证多个ASN.1编码的SM2或ECDSA签名，返回验证失败的下标。
+// 签名的r只包含R点的x坐标，无法在不恢复R的情况下合并验证等式，因此逐个并行验证。
+func BatchVerifyECDSA(keys []*ecdsa.PublicKey, sigs, msgs [][]byte) ([]int, error) {
+	if len(keys) != len(sigs) || len(sigs) != len(msgs) {
+		return nil, InvalidInputParamsError
+	}
+
+	invalid := common.ParallelVerify(len(sigs), func(i int) bool {
+		if keys[i] == nil {
+			return false
+		}
+		ok, err := VerifyECDSA(keys[i], sigs[i], msgs[i])
+		return err == nil && ok
+	})
+	return invalid, nil
+}
+
+// BatchVerifyV2ECDSA 并行验证多个超级签名格式中的ECDSA签名内容，返回验证失败的下标
+func BatchVerifyV2ECDSA(keys []*ecdsa.PublicKey, sigs, msgs [][]byte) ([]int, error) {
+	if len(keys) != len(sigs) || len(sigs) != len(msgs) {
+		return nil, InvalidInputParamsError
+	}
+
+	invalid := common.ParallelVerify(len(sigs), func(i int) bool {
+		if keys[i] == nil {
+			return false
+		}
+		ok, err := VerifyV2ECDSA(keys[i], sigs[i], msgs[i])
+		return err == nil && ok
+	})
+	return invalid, nil
+}
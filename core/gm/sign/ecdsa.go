@@ -3,9 +3,9 @@ package sign
 import (
 	"crypto/ecdsa"
 	"crypto/rand"
-	"encoding/asn1"
 	"encoding/json"
 	"fmt"
+	"math/big"
 
 	"github.com/legendzhouwd/cu_crypto/core/gm/common"
 	"github.com/legendzhouwd/cu_crypto/core/gm/config"
@@ -13,15 +13,31 @@ import (
 )
 
 func SignECDSA(k *ecdsa.PrivateKey, msg []byte) (signature []byte, err error) {
-	// 判断是否是国密标准的私钥
-	isGmCurve := checkKeyCurve(&k.PublicKey)
-	if isGmCurve == false {
+	// 判断是否是支持的椭圆曲线
+	if !checkKeyCurve(&k.PublicKey) {
 		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
 	}
 	if k.D == nil {
 		return nil, fmt.Errorf("Param D cannot be nil.")
 	}
 
+	r, s, err := signRS(k, msg)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalECDSASignature(r, s)
+}
+
+// signRS 根据私钥的曲线选择签名算法：国密曲线使用SM2，NIST曲线使用标准ECDSA，msg为待签名的摘要
+func signRS(k *ecdsa.PrivateKey, msg []byte) (*big.Int, *big.Int, error) {
+	if k.Params().Name == config.CurveNist {
+		r, s, err := ecdsa.Sign(rand.Reader, k, msg)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to sign the msg [%s]", err)
+		}
+		return r, s, nil
+	}
+
 	key := new(sm2.PrivateKey)
 	//	key := &sm2.PrivateKey{}
 	key.PublicKey.Curve = sm2.P256Sm2() // elliptic.P256()
@@ -29,14 +45,28 @@ func SignECDSA(k *ecdsa.PrivateKey, msg []byte) (signature []byte, err error) {
 	key.Y = k.Y
 	key.D = k.D
 
-	signature, err = key.Sign(rand.Reader, msg, nil)
+	r, s, err := sm2.Sign(key, msg)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to sign the msg [%s]", err)
+		return nil, nil, fmt.Errorf("Failed to sign the msg [%s]", err)
+	}
+	return r, s, nil
+}
+
+// verifyRS 根据公钥的曲线选择验证算法
+func verifyRS(k *ecdsa.PublicKey, msg []byte, r, s *big.Int) bool {
+	if k.Params().Name == config.CurveNist {
+		return ecdsa.Verify(k, msg, r, s)
 	}
-	return signature, nil
+
+	key := new(sm2.PublicKey)
+	key.Curve = sm2.P256Sm2() // elliptic.P256()
+	key.X = k.X
+	key.Y = k.Y
+
+	return sm2.Verify(key, msg, r, s)
 }
 
-// 判断是否是国密标准的公钥
+// 判断是否是支持的公钥：国密标准或NIST标准
 func checkKeyCurve(k *ecdsa.PublicKey) bool {
 	if k.X == nil || k.Y == nil {
 		return false
@@ -44,7 +74,7 @@ func checkKeyCurve(k *ecdsa.PublicKey) bool {
 
 	switch k.Params().Name {
 	case config.CurveNist: // NIST
-		return false
+		return true
 	case config.CurveGm: // 国密
 		return true
 	default: // 不支持的密码学类型
@@ -53,32 +83,21 @@ func checkKeyCurve(k *ecdsa.PublicKey) bool {
 }
 
 func SignV2ECDSA(k *ecdsa.PrivateKey, msg []byte) (signature []byte, err error) {
-	// 判断是否是国密标准的私钥
-	isGmCurve := checkKeyCurve(&k.PublicKey)
-	if isGmCurve == false {
+	// 判断是否是支持的椭圆曲线
+	if !checkKeyCurve(&k.PublicKey) {
 		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
 	}
 	if k.D == nil {
 		return nil, fmt.Errorf("Param D cannot be nil.")
 	}
 
-	key := new(sm2.PrivateKey)
-	//	key := &sm2.PrivateKey{}
-	key.PublicKey.Curve = sm2.P256Sm2() // elliptic.P256()
-	key.X = k.X
-	key.Y = k.Y
-	key.D = k.D
-
-	sign, err := key.Sign(rand.Reader, msg, nil)
+	r, s, err := signRS(k, msg)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to sign the msg [%s]", err)
+		return nil, err
 	}
 
 	// 生成ECDSA签名：(sum(S), R)
-	var ecdsaSig common.ECDSASignature
-	if _, err := asn1.Unmarshal(sign, &ecdsaSig); err != nil {
-		return nil, err
-	}
+	ecdsaSig := common.ECDSASignature{R: r, S: s}
 
 	// 生成超级签名
 	// 转换json
@@ -101,9 +120,8 @@ func SignV2ECDSA(k *ecdsa.PrivateKey, msg []byte) (signature []byte, err error)
 }
 
 func VerifyV2ECDSA(k *ecdsa.PublicKey, sig, msg []byte) (valid bool, err error) {
-	// 判断是否是国密标准的公钥
-	isGmCurve := checkKeyCurve(k)
-	if isGmCurve == false {
+	// 判断是否是支持的公钥
+	if !checkKeyCurve(k) {
 		return false, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
 	}
 
@@ -112,19 +130,16 @@ func VerifyV2ECDSA(k *ecdsa.PublicKey, sig, msg []byte) (valid bool, err error)
 	if err != nil {
 		return false, fmt.Errorf("Failed to unmarshal the ecdsa signature [%s]", err)
 	}
+	if signature.R == nil || signature.S == nil {
+		return false, nil
+	}
 
-	key := new(sm2.PublicKey)
-	key.Curve = sm2.P256Sm2() // elliptic.P256()
-	key.X = k.X
-	key.Y = k.Y
-
-	return sm2.Verify(key, msg, signature.R, signature.S), nil
+	return verifyRS(k, msg, signature.R, signature.S), nil
 }
 
 func VerifyECDSA(k *ecdsa.PublicKey, sig, msg []byte) (valid bool, err error) {
-	// 判断是否是国密标准的公钥
-	isGmCurve := checkKeyCurve(k)
-	if isGmCurve == false {
+	// 判断是否是支持的公钥
+	if !checkKeyCurve(k) {
 		return false, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
 	}
 
@@ -133,10 +148,5 @@ func VerifyECDSA(k *ecdsa.PublicKey, sig, msg []byte) (valid bool, err error) {
 		return false, fmt.Errorf("Failed to unmarshal the ecdsa signature [%s]", err)
 	}
 
-	key := new(sm2.PublicKey)
-	key.Curve = sm2.P256Sm2() // elliptic.P256()
-	key.X = k.X
-	key.Y = k.Y
-
-	return sm2.Verify(key, msg, r, s), nil
+	return verifyRS(k, msg, r, s), nil
 }
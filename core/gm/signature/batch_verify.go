@@ -0,0 +1,128 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"sort"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/multisign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
+)
+
+// batchGroup 同一种签名算法的一组待验证签名，indexes为其在原始输入中的下标
+type batchGroup struct {
+	indexes  []int
+	keys     [][]*ecdsa.PublicKey
+	sigs     [][]byte
+	messages [][]byte
+}
+
+func (g *batchGroup) add(index int, keys []*ecdsa.PublicKey, sig, message []byte) {
+	g.indexes = append(g.indexes, index)
+	g.keys = append(g.keys, keys)
+	g.sigs = append(g.sigs, sig)
+	g.messages = append(g.messages, message)
+}
+
+// firstKeys 单签名算法只使用每组公钥中的第一个
+func (g *batchGroup) firstKeys() []*ecdsa.PublicKey {
+	keys := make([]*ecdsa.PublicKey, len(g.keys))
+	for i, k := range g.keys {
+		keys[i] = k[0]
+	}
+	return keys
+}
+
+// XuperSigBatchVerify 批量验证超级签名，keys[i]、signatures[i]、messages[i]与XuperSigVerify的参数一致。
+// 返回验证失败（XuperSigVerify返回false或错误）的下标，按升序排列；全部通过时返回nil。
+// 多重签名与MuSig2签名使用随机线性组合合并验证，ECDSA与Schnorr签名并行验证，其余签名类型并行调用XuperSigVerify。
+func XuperSigBatchVerify(keys [][]*ecdsa.PublicKey, signatures, messages [][]byte) ([]int, error) {
+	if len(keys) != len(signatures) || len(signatures) != len(messages) {
+		return nil, InvalidInputParamsError
+	}
+
+	var invalid []int
+	rawECDSA, ecdsaGroup, schnorrGroup := &batchGroup{}, &batchGroup{}, &batchGroup{}
+	multiSigGroup, muSig2Group, others := &batchGroup{}, &batchGroup{}, &batchGroup{}
+	for i := range signatures {
+		if len(keys[i]) == 0 || keys[i][0] == nil {
+			invalid = append(invalid, i)
+			continue
+		}
+		curveName := keys[i][0].Params().Name
+		supported := curveName == config.CurveGm || curveName == config.CurveNist
+
+		xuperSig, err := parseXuperSignature(signatures[i])
+		if err != nil {
+			invalid = append(invalid, i)
+			continue
+		}
+		if xuperSig == nil {
+			// 不是超级签名的格式，按ASN.1编码的ECDSA签名处理
+			if !supported {
+				invalid = append(invalid, i)
+				continue
+			}
+			rawECDSA.add(i, keys[i], signatures[i], messages[i])
+			continue
+		}
+
+		switch {
+		case xuperSig.SigType == common.ECDSA && supported:
+			ecdsaGroup.add(i, keys[i], xuperSig.SigContent, messages[i])
+		case xuperSig.SigType == common.Schnorr && supported:
+			schnorrGroup.add(i, keys[i], xuperSig.SigContent, messages[i])
+		case xuperSig.SigType == common.MultiSig && supported:
+			multiSigGroup.add(i, keys[i], xuperSig.SigContent, messages[i])
+		case xuperSig.SigType == common.MuSig2 && supported:
+			muSig2Group.add(i, keys[i], xuperSig.SigContent, messages[i])
+		default:
+			others.add(i, keys[i], signatures[i], messages[i])
+		}
+	}
+
+	verifiers := []struct {
+		group  *batchGroup
+		verify func(g *batchGroup) ([]int, error)
+	}{
+		{rawECDSA, func(g *batchGroup) ([]int, error) {
+			return sign.BatchVerifyECDSA(g.firstKeys(), g.sigs, g.messages)
+		}},
+		{ecdsaGroup, func(g *batchGroup) ([]int, error) {
+			return sign.BatchVerifyV2ECDSA(g.firstKeys(), g.sigs, g.messages)
+		}},
+		{schnorrGroup, func(g *batchGroup) ([]int, error) {
+			return schnorr_sign.BatchVerify(g.firstKeys(), g.sigs, g.messages)
+		}},
+		{multiSigGroup, func(g *batchGroup) ([]int, error) {
+			return multisign.BatchVerifyMultiSig(g.keys, g.sigs, g.messages)
+		}},
+		{muSig2Group, func(g *batchGroup) ([]int, error) {
+			return multisign.BatchVerifyMuSig2(g.keys, g.sigs, g.messages)
+		}},
+		{others, func(g *batchGroup) ([]int, error) {
+			return common.ParallelVerify(len(g.sigs), func(i int) bool {
+				ok, err := XuperSigVerify(g.keys[i], g.sigs[i], g.messages[i])
+				return err == nil && ok
+			}), nil
+		}},
+	}
+
+	for _, v := range verifiers {
+		if len(v.group.indexes) == 0 {
+			continue
+		}
+		bad, err := v.verify(v.group)
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range bad {
+			invalid = append(invalid, v.group.indexes[j])
+		}
+	}
+
+	sort.Ints(invalid)
+	return invalid, nil
+}
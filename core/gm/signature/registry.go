@@ -0,0 +1,227 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/linkable_ring_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/multisign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_ring_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
+)
+
+// 签名算法注册表，按(SigType, 曲线名称)查找签名与验签算法。
+// 本包在初始化时注册了内置的ECDSA、Schnorr、Schnorr环签名、可链接环签名、多重签名与MuSig2算法，
+// 其它包可以通过RegisterSigner/RegisterVerifier为新的签名类型或曲线注册实现，XuperSign与XuperSigVerify会自动使用。
+
+var (
+	UnsupportedSigTypeError         = errors.New("The XuperSignature type is not supported")
+	UnsupportedCurveError           = errors.New("The curve is not supported by this XuperSignature type")
+	AlgorithmAlreadyRegisteredError = errors.New("The algorithm has already been registered")
+)
+
+// Signer 签名算法，返回统一超级签名格式的签名。
+// privateKeys为签名者的私钥，单签名与环签名只有一个，多重签名为所有签名者的私钥；
+// publicKeys为环签名中除签名者之外的成员公钥，其它签名类型为空
+type Signer interface {
+	Sign(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error)
+}
+
+// Verifier 验签算法，sigContent为超级签名中的SigContent，keys与XuperSigVerify的参数一致
+type Verifier interface {
+	Verify(keys []*ecdsa.PublicKey, sigContent, message []byte) (bool, error)
+}
+
+// SignerFunc 将普通函数适配为Signer
+type SignerFunc func(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error)
+
+func (f SignerFunc) Sign(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+	return f(privateKeys, publicKeys, message)
+}
+
+// VerifierFunc 将普通函数适配为Verifier
+type VerifierFunc func(keys []*ecdsa.PublicKey, sigContent, message []byte) (bool, error)
+
+func (f VerifierFunc) Verify(keys []*ecdsa.PublicKey, sigContent, message []byte) (bool, error) {
+	return f(keys, sigContent, message)
+}
+
+var (
+	registryLock sync.RWMutex
+	signers      = make(map[string]map[string]Signer)
+	verifiers    = make(map[string]map[string]Verifier)
+)
+
+// RegisterSigner 为(sigType, curveName)注册签名算法，重复注册返回错误
+func RegisterSigner(sigType, curveName string, signer Signer) error {
+	if sigType == "" || curveName == "" || signer == nil {
+		return InvalidInputParamsError
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if signers[sigType] == nil {
+		signers[sigType] = make(map[string]Signer)
+	}
+	if _, ok := signers[sigType][curveName]; ok {
+		return AlgorithmAlreadyRegisteredError
+	}
+	signers[sigType][curveName] = signer
+	return nil
+}
+
+// RegisterVerifier 为(sigType, curveName)注册验签算法，重复注册返回错误
+func RegisterVerifier(sigType, curveName string, verifier Verifier) error {
+	if sigType == "" || curveName == "" || verifier == nil {
+		return InvalidInputParamsError
+	}
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if verifiers[sigType] == nil {
+		verifiers[sigType] = make(map[string]Verifier)
+	}
+	if _, ok := verifiers[sigType][curveName]; ok {
+		return AlgorithmAlreadyRegisteredError
+	}
+	verifiers[sigType][curveName] = verifier
+	return nil
+}
+
+// GetSigner 查找(sigType, curveName)的签名算法。
+// 没有该签名类型时返回UnsupportedSigTypeError，签名类型不支持该曲线时返回UnsupportedCurveError
+func GetSigner(sigType, curveName string) (Signer, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	curves, ok := signers[sigType]
+	if !ok {
+		return nil, UnsupportedSigTypeError
+	}
+	signer, ok := curves[curveName]
+	if !ok {
+		return nil, UnsupportedCurveError
+	}
+	return signer, nil
+}
+
+// GetVerifier 查找(sigType, curveName)的验签算法，错误与GetSigner一致
+func GetVerifier(sigType, curveName string) (Verifier, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	curves, ok := verifiers[sigType]
+	if !ok {
+		return nil, UnsupportedSigTypeError
+	}
+	verifier, ok := curves[curveName]
+	if !ok {
+		return nil, UnsupportedCurveError
+	}
+	return verifier, nil
+}
+
+// XuperSign 使用注册表中的签名算法生成超级签名，参数与Signer.Sign一致，曲线由私钥决定
+func XuperSign(sigType string, privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+	if len(privateKeys) == 0 {
+		return nil, InvalidInputParamsError
+	}
+	if len(message) == 0 {
+		return nil, EmptyMessageError
+	}
+	for _, key := range privateKeys {
+		if key == nil || key.D == nil {
+			return nil, InvalidInputParamsError
+		}
+	}
+	curveName := privateKeys[0].Params().Name
+	for _, key := range privateKeys[1:] {
+		if key.Params().Name != curveName {
+			return nil, NotExactTheSameCurveInputError
+		}
+	}
+
+	signer, err := GetSigner(sigType, curveName)
+	if err != nil {
+		return nil, err
+	}
+	return signer.Sign(privateKeys, publicKeys, message)
+}
+
+// singleSigner 只使用一个私钥、不需要其它公钥的签名算法
+func singleSigner(f func(*ecdsa.PrivateKey, []byte) ([]byte, error)) SignerFunc {
+	return func(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+		if len(privateKeys) != 1 || len(publicKeys) != 0 {
+			return nil, InvalidInputParamsError
+		}
+		return f(privateKeys[0], message)
+	}
+}
+
+// singleVerifier 只使用第一个公钥的验签算法
+func singleVerifier(f func(*ecdsa.PublicKey, []byte, []byte) (bool, error)) VerifierFunc {
+	return func(keys []*ecdsa.PublicKey, sigContent, message []byte) (bool, error) {
+		return f(keys[0], sigContent, message)
+	}
+}
+
+// ringSigner 环签名算法，publicKeys为除签名者之外的成员公钥
+func ringSigner(f func([]*ecdsa.PublicKey, *ecdsa.PrivateKey, []byte) ([]byte, error)) SignerFunc {
+	return func(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+		if len(privateKeys) != 1 || len(publicKeys) == 0 {
+			return nil, InvalidInputParamsError
+		}
+		// 环签名会修改传入的公钥数组
+		others := append([]*ecdsa.PublicKey{}, publicKeys...)
+		return f(others, privateKeys[0], message)
+	}
+}
+
+// multiSigner 所有签名者共同生成的多重签名
+func multiSigner(f func([]*ecdsa.PrivateKey, []byte) ([]byte, error)) SignerFunc {
+	return func(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+		if len(privateKeys) < 2 {
+			return nil, TooSmallNumOfkeysError
+		}
+		if len(publicKeys) != 0 {
+			return nil, InvalidInputParamsError
+		}
+		return f(privateKeys, message)
+	}
+}
+
+func init() {
+	builtinSigners := map[string]Signer{
+		common.ECDSA:        singleSigner(sign.SignV2ECDSA),
+		common.Schnorr:      singleSigner(schnorr_sign.Sign),
+		common.SchnorrRing:  ringSigner(schnorr_ring_sign.Sign),
+		common.LinkableRing: ringSigner(linkable_ring_sign.Sign),
+		common.MultiSig:     multiSigner(multisign.MultiSign),
+	}
+	// MuSig2需要多轮交互，只注册验签算法
+	builtinVerifiers := map[string]Verifier{
+		common.ECDSA:        singleVerifier(sign.VerifyV2ECDSA),
+		common.Schnorr:      singleVerifier(schnorr_sign.Verify),
+		common.SchnorrRing:  VerifierFunc(schnorr_ring_sign.Verify),
+		common.LinkableRing: VerifierFunc(linkable_ring_sign.Verify),
+		common.MultiSig:     VerifierFunc(multisign.VerifyMultiSig),
+		common.MuSig2:       VerifierFunc(multisign.VerifyMuSig2),
+	}
+
+	for _, curveName := range []string{config.CurveGm, config.CurveNist} {
+		for sigType, signer := range builtinSigners {
+			if err := RegisterSigner(sigType, curveName, signer); err != nil {
+				panic(err)
+			}
+		}
+		for sigType, verifier := range builtinVerifiers {
+			if err := RegisterVerifier(sigType, curveName, verifier); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package signature
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+func TestXuperSign(t *testing.T) {
+	message := []byte("XuperSign with registry")
+	digest := sha256.Sum256(message)
+	for _, curve := range testCurves {
+		keys, publicKeys := generateKeys(t, curve, 3)
+		for _, c := range xuperSignCases(t, keys, publicKeys, message, digest[:]) {
+			sig, err := XuperSign(c.sigType, c.privateKeys, c.publicKeys, c.message)
+			require.NoError(t, err, c.sigType)
+			xuperSig := new(common.XuperSignature)
+			require.NoError(t, json.Unmarshal(sig, xuperSig))
+			require.Equal(t, c.sigType, xuperSig.SigType)
+
+			ok, err := XuperSigVerify(c.verifyKeys(sig), sig, c.message)
+			require.NoError(t, err, c.sigType)
+			require.True(t, ok, c.sigType)
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	nistKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = XuperSign(common.MuSig2, []*ecdsa.PrivateKey{key, key}, nil, message)
+	require.Equal(t, UnsupportedSigTypeError, err)
+	_, err = XuperSign(common.MultiSig, []*ecdsa.PrivateKey{key, nistKey}, nil, message)
+	require.Equal(t, NotExactTheSameCurveInputError, err)
+	_, err = XuperSign(common.MultiSig, []*ecdsa.PrivateKey{key}, nil, message)
+	require.Equal(t, TooSmallNumOfkeysError, err)
+	_, err = XuperSign(common.Schnorr, []*ecdsa.PrivateKey{key}, []*ecdsa.PublicKey{&nistKey.PublicKey}, message)
+	require.Equal(t, InvalidInputParamsError, err)
+	_, err = XuperSign(common.Schnorr, []*ecdsa.PrivateKey{key}, nil, nil)
+	require.Equal(t, EmptyMessageError, err)
+}
+
+// xuperSignCase XuperSign支持的签名类型及验证时使用的参数
+type xuperSignCase struct {
+	sigType     string
+	privateKeys []*ecdsa.PrivateKey
+	publicKeys  []*ecdsa.PublicKey
+	message     []byte
+	verifyKeys  func(sig []byte) []*ecdsa.PublicKey
+}
+
+// xuperSignCases 使用3个密钥构造每种签名类型的测试用例，ECDSA对摘要签名
+func xuperSignCases(t *testing.T, keys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message, digest []byte) []xuperSignCase {
+	return []xuperSignCase{
+		{common.ECDSA, keys[:1], nil, digest, fixedKeys(publicKeys[:1])},
+		{common.Schnorr, keys[:1], nil, message, fixedKeys(publicKeys[:1])},
+		{common.SchnorrRing, keys[:1], publicKeys[1:], message, ringMembers(t)},
+		{common.LinkableRing, keys[:1], publicKeys[1:], message, fixedKeys(publicKeys)},
+		{common.MultiSig, keys, nil, message, fixedKeys(publicKeys)},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	const sigType = "TestKeyedHash"
+	curveName := config.CurveGm
+
+	// 仅用于测试的签名算法：签名为公钥坐标与消息的摘要
+	tag := func(key *ecdsa.PublicKey, message []byte) []byte {
+		sum := sha256.Sum256(append(elliptic.Marshal(key.Curve, key.X, key.Y), message...))
+		return sum[:]
+	}
+	signer := SignerFunc(func(privateKeys []*ecdsa.PrivateKey, publicKeys []*ecdsa.PublicKey, message []byte) ([]byte, error) {
+		return json.Marshal(&common.XuperSignature{
+			SigType:    sigType,
+			SigContent: tag(&privateKeys[0].PublicKey, message),
+		})
+	})
+	verifier := VerifierFunc(func(keys []*ecdsa.PublicKey, sigContent, message []byte) (bool, error) {
+		return bytes.Equal(tag(keys[0], message), sigContent), nil
+	})
+
+	key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	message := []byte("custom algorithm")
+
+	_, err = XuperSign(sigType, []*ecdsa.PrivateKey{key}, nil, message)
+	require.Equal(t, UnsupportedSigTypeError, err)
+
+	require.NoError(t, RegisterSigner(sigType, curveName, signer))
+	require.NoError(t, RegisterVerifier(sigType, curveName, verifier))
+	require.Equal(t, AlgorithmAlreadyRegisteredError, RegisterSigner(sigType, curveName, signer))
+	require.Equal(t, AlgorithmAlreadyRegisteredError, RegisterVerifier(sigType, curveName, verifier))
+	require.Equal(t, AlgorithmAlreadyRegisteredError, RegisterVerifier(common.Schnorr, config.CurveGm, verifier))
+	require.Equal(t, InvalidInputParamsError, RegisterVerifier("", curveName, verifier))
+
+	sig, err := XuperSign(sigType, []*ecdsa.PrivateKey{key}, nil, message)
+	require.NoError(t, err)
+	ok, err := XuperSigVerify([]*ecdsa.PublicKey{&key.PublicKey}, sig, message)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = XuperSigVerify([]*ecdsa.PublicKey{&key.PublicKey}, sig, []byte("tampered"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// 只为国密曲线注册
+	nistKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = XuperSign(sigType, []*ecdsa.PrivateKey{nistKey}, nil, message)
+	require.Equal(t, UnsupportedCurveError, err)
+	_, err = XuperSigVerify([]*ecdsa.PublicKey{&nistKey.PublicKey}, sig, message)
+	require.Equal(t, UnsupportedCurveError, err)
+
+	_, err = XuperSigVerify(nil, sig, message)
+	require.Equal(t, InvalidInputParamsError, err)
+}
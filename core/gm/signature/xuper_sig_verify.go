@@ -2,16 +2,15 @@ package signature
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/asn1"
 	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/legendzhouwd/cu_crypto/core/gm/bls_sign"
 	"github.com/legendzhouwd/cu_crypto/core/gm/common"
-	"github.com/legendzhouwd/cu_crypto/core/gm/config"
-	"github.com/legendzhouwd/cu_crypto/core/gm/multisign"
-	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_ring_sign"
-	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/ed25519_sign"
 	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
 )
 
@@ -21,81 +20,92 @@ var (
 	TooSmallNumOfkeysError         = errors.New("The total num of keys should be greater than one")
 	EmptyMessageError              = errors.New("Message to be sign should not be nil")
 	InValidSignatureError          = errors.New("XuperSignature is invalid")
+	BLSPublicKeyRequiredError      = errors.New("BLS signature should be verified with BLS public keys by XuperSigVerifyBLS")
+	Ed25519PublicKeyRequiredError  = errors.New("Ed25519 signature should be verified with Ed25519 public key by XuperSigVerifyEd25519")
 )
 
+// XuperSigVerify 验证超级签名，按签名类型与keys[0]的曲线从注册表中查找验签算法。
+// 超级签名可以是二进制或JSON格式，两者都不是时按ASN.1编码的ECDSA签名处理。
 // 代码这么写的目的是为了支持NIST/国密的混合使用
 func XuperSigVerify(keys []*ecdsa.PublicKey, signature, message []byte) (bool, error) {
-	//	xuperSig, err := unmarshalXuperSignature(signature)
-	xuperSig := new(common.XuperSignature)
-	err := json.Unmarshal(signature, xuperSig)
+	if len(keys) == 0 || keys[0] == nil {
+		return false, InvalidInputParamsError
+	}
+	curveName := keys[0].Params().Name
 
-	// 说明不是统一超级签名的格式
+	xuperSig, err := parseXuperSignature(signature)
 	if err != nil {
-		switch keys[0].Params().Name {
-		case config.CurveNist: // NIST
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		case config.CurveGm: // 国密
-			verifyResult, err := sign.VerifyECDSA(keys[0], signature, message)
-			return verifyResult, err
-		default: // 不支持的密码学类型
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
+		return false, err
+	}
+
+	// 说明不是统一超级签名的格式
+	if xuperSig == nil {
+		if _, err := GetVerifier(common.ECDSA, curveName); err != nil {
+			return false, err
 		}
+		return sign.VerifyECDSA(keys[0], signature, message)
+	}
 
+	// BLS签名的公钥不是椭圆曲线公钥，需要使用XuperSigVerifyBLS
+	if xuperSig.SigType == common.BLS {
+		return false, BLSPublicKeyRequiredError
+	}
+	// Ed25519签名同样需要使用XuperSigVerifyEd25519
+	if xuperSig.SigType == common.Ed25519 {
+		return false, Ed25519PublicKeyRequiredError
+	}
+
+	verifier, err := GetVerifier(xuperSig.SigType, curveName)
+	if err != nil {
 		return false, err
 	}
+	return verifier.Verify(keys, xuperSig.SigContent, message)
+}
 
-	switch xuperSig.SigType {
-	// ECDSA签名
-	case common.ECDSA:
-		switch keys[0].Params().Name {
-		case config.CurveNist: // NIST
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		case config.CurveGm: // 国密
-			verifyResult, err := sign.VerifyV2ECDSA(keys[0], xuperSig.SigContent, message)
-			return verifyResult, err
-		default: // 不支持的密码学类型
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		}
-	// Schnorr签名
-	case common.Schnorr:
-		switch keys[0].Params().Name {
-		case config.CurveNist: // NIST
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		case config.CurveGm: // 国密
-			verifyResult, err := schnorr_sign.Verify(keys[0], xuperSig.SigContent, message)
-			return verifyResult, err
-		default: // 不支持的密码学类型
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		}
-	// Schnorr环签名
-	case common.SchnorrRing:
-		switch keys[0].Params().Name {
-		case config.CurveNist: // NIST
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		case config.CurveGm: // 国密
-			verifyResult, err := schnorr_ring_sign.Verify(keys, xuperSig.SigContent, message)
-			return verifyResult, err
-		default: // 不支持的密码学类型
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		}
-	// 多重签名
-	case common.MultiSig:
-		switch keys[0].Params().Name {
-		case config.CurveNist: // NIST
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		case config.CurveGm: // 国密
-			verifyResult, err := multisign.VerifyMultiSig(keys, xuperSig.SigContent, message)
-			return verifyResult, err
-		default: // 不支持的密码学类型
-			return false, fmt.Errorf("This cryptography[%v] has not been supported yet.", keys[0].Params().Name)
-		}
-	// 不支持的签名类型
-	default:
-		err = fmt.Errorf("This XuperSignature type[%v] is not supported in this version.", xuperSig.SigType)
+// XuperSigVerifyBLS 验证BLS超级签名，keys为压缩格式的BLS公钥。
+// 只有一个公钥时验证普通签名，多个公钥时按同一条消息上的聚合签名验证，这些公钥必须已经通过所有权证明的验证
+func XuperSigVerifyBLS(keys [][]byte, signature, message []byte) (bool, error) {
+	if len(keys) == 0 {
+		return false, InvalidInputParamsError
+	}
+	xuperSig, err := parseXuperSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	if xuperSig == nil {
+		return false, InValidSignatureError
+	}
+	if xuperSig.SigType != common.BLS {
+		return false, fmt.Errorf("This XuperSignature type[%v] is not a BLS signature.", xuperSig.SigType)
+	}
+	return bls_sign.FastAggregateVerify(keys, xuperSig.SigContent, message)
+}
+
+// XuperSigVerifyEd25519 验证Ed25519超级签名
+func XuperSigVerifyEd25519(publicKey ed25519.PublicKey, signature, message []byte) (bool, error) {
+	xuperSig, err := parseXuperSignature(signature)
+	if err != nil {
 		return false, err
 	}
+	if xuperSig == nil {
+		return false, InValidSignatureError
+	}
+	if xuperSig.SigType != common.Ed25519 {
+		return false, fmt.Errorf("This XuperSignature type[%v] is not an Ed25519 signature.", xuperSig.SigType)
+	}
+	return ed25519_sign.Verify(publicKey, xuperSig.SigContent, message)
+}
 
-	return false, nil
+// parseXuperSignature 解析二进制或JSON格式的超级签名，两者都不是时返回nil，由调用方按ASN.1编码的ECDSA签名处理
+func parseXuperSignature(signature []byte) (*common.XuperSignature, error) {
+	if common.IsBinaryXuperSignature(signature) {
+		return common.UnmarshalXuperSignatureBinary(signature)
+	}
+	xuperSig := new(common.XuperSignature)
+	if err := json.Unmarshal(signature, xuperSig); err != nil {
+		return nil, nil
+	}
+	return xuperSig, nil
 }
 
 func unmarshalXuperSignature(rawSig []byte) (*common.XuperSignature, error) {
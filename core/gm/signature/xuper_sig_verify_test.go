@@ -0,0 +1,387 @@
+package signature
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/bls_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/common"
+	"github.com/legendzhouwd/cu_crypto/core/gm/ed25519_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/linkable_ring_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/multisign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/nonce"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_ring_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/schnorr_sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
+)
+
+// testCurves 同时支持国密与NIST P-256的签名算法在两条曲线上测试
+var testCurves = []elliptic.Curve{sm2.P256Sm2(), elliptic.P256()}
+
+// generateKeys 在指定曲线上生成n个私钥及对应的公钥
+func generateKeys(t *testing.T, curve elliptic.Curve, n int) ([]*ecdsa.PrivateKey, []*ecdsa.PublicKey) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	publicKeys := make([]*ecdsa.PublicKey, n)
+	for i := range keys {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+		keys[i] = key
+		publicKeys[i] = &key.PublicKey
+	}
+	return keys, publicKeys
+}
+
+func TestXuperSigVerifyMuSig2(t *testing.T) {
+	message := []byte("MuSig2 with XuperSigVerify")
+	for _, curve := range testCurves {
+		keys, publicKeys := generateKeys(t, curve, 2)
+		keyAgg, err := multisign.MuSig2AggregatePublicKeys(publicKeys)
+		require.NoError(t, err)
+
+		secretNonces := make([]*multisign.MuSig2SecretNonce, len(keys))
+		publicNonces := make([][]byte, len(keys))
+		for i, key := range keys {
+			secretNonces[i], publicNonces[i], err = multisign.MuSig2NonceGen(key)
+			require.NoError(t, err)
+		}
+		aggNonce, err := multisign.MuSig2NonceAgg(curve, publicNonces)
+		require.NoError(t, err)
+
+		partialSigs := make([][]byte, len(keys))
+		for i, key := range keys {
+			partialSigs[i], err = multisign.MuSig2PartialSign(secretNonces[i], key, keyAgg, aggNonce, message)
+			require.NoError(t, err)
+		}
+		sig, err := multisign.MuSig2PartialSigAgg(keyAgg, aggNonce, message, partialSigs)
+		require.NoError(t, err)
+
+		ok, err := XuperSigVerify(publicKeys, sig, message)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = XuperSigVerify(publicKeys, sig, []byte("tampered"))
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestXuperSigVerifyLinkableRing(t *testing.T) {
+	message := []byte("LSAG with XuperSigVerify")
+	for _, curve := range testCurves {
+		keys, publicKeys := generateKeys(t, curve, 3)
+
+		sig, err := linkable_ring_sign.Sign(publicKeys[1:], keys[0], message)
+		require.NoError(t, err)
+
+		ok, err := XuperSigVerify(publicKeys, sig, message)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		ok, err = XuperSigVerify(publicKeys, sig, []byte("tampered"))
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestXuperSigBatchVerify(t *testing.T) {
+	var keys [][]*ecdsa.PublicKey
+	var sigs, messages [][]byte
+	add := func(publicKeys []*ecdsa.PublicKey, sig, message []byte) {
+		keys = append(keys, publicKeys)
+		sigs = append(sigs, sig)
+		messages = append(messages, message)
+	}
+
+	for i := 0; i < 4; i++ {
+		message := []byte(fmt.Sprintf("transaction %d", i))
+		key, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+		require.NoError(t, err)
+		other, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+		require.NoError(t, err)
+		publicKeys := []*ecdsa.PublicKey{&key.PublicKey, &other.PublicKey}
+
+		sig, err := sign.SignECDSA(key, message)
+		require.NoError(t, err)
+		add(publicKeys[:1], sig, message)
+
+		sig, err = sign.SignV2ECDSA(key, message)
+		require.NoError(t, err)
+		add(publicKeys[:1], sig, message)
+
+		sig, err = schnorr_sign.Sign(key, message)
+		require.NoError(t, err)
+		add(publicKeys[:1], sig, message)
+
+		sig, err = multisign.MultiSign([]*ecdsa.PrivateKey{key, other}, message)
+		require.NoError(t, err)
+		add(publicKeys, sig, message)
+
+		sig, err = linkable_ring_sign.Sign(publicKeys[1:], key, message)
+		require.NoError(t, err)
+		add(publicKeys, sig, message)
+	}
+
+	invalid, err := XuperSigBatchVerify(keys, sigs, messages)
+	require.NoError(t, err)
+	require.Nil(t, invalid)
+
+	// 每种签名各篡改一个
+	expected := []int{1, 5, 12, 13, 19}
+	for _, i := range expected {
+		messages[i] = []byte("tampered")
+	}
+	invalid, err = XuperSigBatchVerify(keys, sigs, messages)
+	require.NoError(t, err)
+	require.Equal(t, expected, invalid)
+	for i := range sigs {
+		ok, _ := XuperSigVerify(keys[i], sigs[i], messages[i])
+		require.Equal(t, !contains(expected, i), ok)
+	}
+
+	_, err = XuperSigBatchVerify(keys, sigs, messages[1:])
+	require.Equal(t, InvalidInputParamsError, err)
+}
+
+func contains(indexes []int, index int) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+	return false
+}
+
+func TestXuperSigVerifyNonceMode(t *testing.T) {
+	message := []byte("deterministic nonce")
+	keys, publicKeys := generateKeys(t, sm2.P256Sm2(), 3)
+
+	signers := []struct {
+		name string
+		keys func(sig []byte) []*ecdsa.PublicKey
+		sign func(mode nonce.Mode) ([]byte, error)
+		// 环签名的签名者位置随机，确定性模式下签名也不相同
+		repeatable bool
+	}{
+		{"Schnorr", fixedKeys(publicKeys[:1]), func(mode nonce.Mode) ([]byte, error) {
+			return schnorr_sign.SignWithNonceMode(keys[0], message, mode)
+		}, true},
+		{"SchnorrRing", ringMembers(t), func(mode nonce.Mode) ([]byte, error) {
+			others := append([]*ecdsa.PublicKey{}, publicKeys[1:]...)
+			return schnorr_ring_sign.SignWithNonceMode(others, keys[0], message, mode)
+		}, false},
+		{"MultiSig", fixedKeys(publicKeys), func(mode nonce.Mode) ([]byte, error) {
+			return multisign.MultiSignWithNonceMode(keys, message, mode)
+		}, true},
+	}
+
+	for _, s := range signers {
+		var sigs [][]byte
+		for _, mode := range []nonce.Mode{nonce.ModeRandom, nonce.ModeDeterministic, nonce.ModeDeterministic, nonce.ModeHedged} {
+			sig, err := s.sign(mode)
+			require.NoError(t, err, s.name)
+			ok, err := XuperSigVerify(s.keys(sig), sig, message)
+			require.NoError(t, err, s.name)
+			require.True(t, ok, s.name)
+			sigs = append(sigs, sig)
+		}
+		if s.repeatable {
+			require.Equal(t, sigs[1], sigs[2], s.name)
+		}
+		require.NotEqual(t, sigs[1], sigs[3], s.name)
+	}
+}
+
+func fixedKeys(keys []*ecdsa.PublicKey) func([]byte) []*ecdsa.PublicKey {
+	return func([]byte) []*ecdsa.PublicKey {
+		return keys
+	}
+}
+
+// ringMembers 按环签名中的成员顺序返回公钥
+func ringMembers(t *testing.T) func([]byte) []*ecdsa.PublicKey {
+	return func(sig []byte) []*ecdsa.PublicKey {
+		xuperSig := new(common.XuperSignature)
+		require.NoError(t, json.Unmarshal(sig, xuperSig))
+		ringSig := new(common.RingSignature)
+		require.NoError(t, json.Unmarshal(xuperSig.SigContent, ringSig))
+		curve, err := ecc.CurveByName(ringSig.CurveName)
+		require.NoError(t, err)
+		keys := make([]*ecdsa.PublicKey, len(ringSig.Members))
+		for i, member := range ringSig.Members {
+			keys[i] = &ecdsa.PublicKey{Curve: curve, X: member.X, Y: member.Y}
+		}
+		return keys
+	}
+}
+
+func TestXuperSigVerifyNist(t *testing.T) {
+	message := []byte("NIST P-256 with XuperSigVerify")
+	digest := sha256.Sum256(message)
+	for _, curve := range testCurves {
+		keys, publicKeys := generateKeys(t, curve, 3)
+		name := curve.Params().Name
+
+		signers := []struct {
+			name    string
+			keys    func(sig []byte) []*ecdsa.PublicKey
+			message []byte
+			sign    func() ([]byte, error)
+		}{
+			{"RawECDSA", fixedKeys(publicKeys[:1]), digest[:], func() ([]byte, error) {
+				return sign.SignECDSA(keys[0], digest[:])
+			}},
+			{"ECDSA", fixedKeys(publicKeys[:1]), digest[:], func() ([]byte, error) {
+				return sign.SignV2ECDSA(keys[0], digest[:])
+			}},
+			{"Schnorr", fixedKeys(publicKeys[:1]), message, func() ([]byte, error) {
+				return schnorr_sign.Sign(keys[0], message)
+			}},
+			{"SchnorrRing", ringMembers(t), message, func() ([]byte, error) {
+				others := append([]*ecdsa.PublicKey{}, publicKeys[1:]...)
+				return schnorr_ring_sign.Sign(others, keys[0], message)
+			}},
+			{"MultiSig", fixedKeys(publicKeys), message, func() ([]byte, error) {
+				return multisign.MultiSign(keys, message)
+			}},
+		}
+
+		for _, s := range signers {
+			sig, err := s.sign()
+			require.NoError(t, err, name, s.name)
+			ok, err := XuperSigVerify(s.keys(sig), sig, s.message)
+			require.NoError(t, err, name, s.name)
+			require.True(t, ok, name, s.name)
+
+			tampered := append([]byte{}, s.message...)
+			tampered[0] ^= 1
+			ok, _ = XuperSigVerify(s.keys(sig), sig, tampered)
+			require.False(t, ok, name, s.name)
+		}
+	}
+
+	// P-256的ECDSA签名与标准库互通
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	sig, err := sign.SignECDSA(key, digest[:])
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig))
+	sig, err = ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	ok, err := XuperSigVerify([]*ecdsa.PublicKey{&key.PublicKey}, sig, digest[:])
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestXuperSigVerifyBLS(t *testing.T) {
+	message := []byte("BLS with XuperSigVerify")
+	for _, variant := range []string{bls_sign.MinPk, bls_sign.MinSig} {
+		publicKeys := make([][]byte, 3)
+		sigs := make([][]byte, 3)
+		for i := range publicKeys {
+			key, err := bls_sign.GenerateKey(variant)
+			require.NoError(t, err)
+			publicKeys[i] = key.PublicKey()
+			sigs[i], err = bls_sign.Sign(key, message)
+			require.NoError(t, err)
+		}
+
+		ok, err := XuperSigVerifyBLS(publicKeys[:1], sigs[0], message)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		aggregated, err := bls_sign.AggregateSignatures(sigs)
+		require.NoError(t, err)
+		ok, err = XuperSigVerifyBLS(publicKeys, aggregated, message)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = XuperSigVerifyBLS(publicKeys, aggregated, []byte("tampered"))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// BLS公钥无法以椭圆曲线公钥的形式传入
+		ecdsaKey, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+		require.NoError(t, err)
+		_, err = XuperSigVerify([]*ecdsa.PublicKey{&ecdsaKey.PublicKey}, aggregated, message)
+		require.Equal(t, BLSPublicKeyRequiredError, err)
+	}
+}
+
+func TestXuperSigVerifyBinary(t *testing.T) {
+	message := []byte("binary XuperSignature")
+	digest := sha256.Sum256(message)
+	for _, curve := range testCurves {
+		keys, publicKeys := generateKeys(t, curve, 3)
+		var batchKeys [][]*ecdsa.PublicKey
+		var batchSigs, batchMessages [][]byte
+		for _, c := range xuperSignCases(t, keys, publicKeys, message, digest[:]) {
+			sig, err := XuperSign(c.sigType, c.privateKeys, c.publicKeys, c.message)
+			require.NoError(t, err, c.sigType)
+			binary, err := common.ConvertXuperSignatureToBinary(sig)
+			require.NoError(t, err, c.sigType)
+			require.Less(t, len(binary), len(sig), c.sigType)
+
+			// 新旧两种格式都可以验证
+			for _, s := range [][]byte{sig, binary} {
+				ok, err := XuperSigVerify(c.verifyKeys(sig), s, c.message)
+				require.NoError(t, err, c.sigType)
+				require.True(t, ok, c.sigType)
+			}
+			ok, err := XuperSigVerify(c.verifyKeys(sig), binary, []byte("tampered"))
+			require.NoError(t, err, c.sigType)
+			require.False(t, ok, c.sigType)
+
+			batchKeys = append(batchKeys, c.verifyKeys(sig), c.verifyKeys(sig))
+			batchSigs = append(batchSigs, sig, binary)
+			batchMessages = append(batchMessages, c.message, c.message)
+		}
+
+		invalid, err := XuperSigBatchVerify(batchKeys, batchSigs, batchMessages)
+		require.NoError(t, err)
+		require.Nil(t, invalid)
+
+		// 非规范编码的签名被拒绝
+		last := len(batchSigs) - 1
+		batchSigs[last] = append(append([]byte{}, batchSigs[last]...), 0x00)
+		_, err = XuperSigVerify(batchKeys[last], batchSigs[last], batchMessages[last])
+		require.Equal(t, common.NonCanonicalSignatureError, err)
+		invalid, err = XuperSigBatchVerify(batchKeys, batchSigs, batchMessages)
+		require.NoError(t, err)
+		require.Equal(t, []int{last}, invalid)
+	}
+}
+
+func TestXuperSigVerifyEd25519(t *testing.T) {
+	message := []byte("Ed25519 with XuperSigVerify")
+	privateKey, err := ed25519_sign.GenerateKey()
+	require.NoError(t, err)
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+
+	sig, err := ed25519_sign.Sign(privateKey, message)
+	require.NoError(t, err)
+	binary, err := common.ConvertXuperSignatureToBinary(sig)
+	require.NoError(t, err)
+	for _, s := range [][]byte{sig, binary} {
+		ok, err := XuperSigVerifyEd25519(publicKey, s, message)
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = XuperSigVerifyEd25519(publicKey, s, []byte("tampered"))
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	// Ed25519公钥无法以椭圆曲线公钥的形式传入
+	ecdsaKey, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.NoError(t, err)
+	_, err = XuperSigVerify([]*ecdsa.PublicKey{&ecdsaKey.PublicKey}, sig, message)
+	require.Equal(t, Ed25519PublicKeyRequiredError, err)
+}
@@ -0,0 +1,300 @@
+package social_recovery
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	nistEcies "github.com/legendzhouwd/cu_crypto/core/ecies"
+	"github.com/legendzhouwd/cu_crypto/core/gm/account"
+	"github.com/legendzhouwd/cu_crypto/core/gm/config"
+	gmEcies "github.com/legendzhouwd/cu_crypto/core/gm/ecies"
+	"github.com/legendzhouwd/cu_crypto/core/gm/hash"
+	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
+	"github.com/legendzhouwd/cu_crypto/core/gm/signature"
+
+	"github.com/xuperchain/crypto/common/utils"
+)
+
+// 恢复策略的版本号
+const PolicyVersion = 1
+
+var (
+	InvalidInputParamsError   = errors.New("Invalid input params")
+	InvalidBundleError        = errors.New("Recovery bundle signature is invalid")
+	CustodianNotFoundError    = errors.New("Custodian is not found in the recovery bundle")
+	NotEnoughSharesError      = errors.New("Number of valid shares is less than the threshold")
+	RecoveredKeyMismatchError = errors.New("Recovered private key does not match the owner address")
+	DuplicateCustodianError   = errors.New("Custodian appears more than once")
+)
+
+// RecoveryPolicy 记录私钥分存的策略，由账户所有者签名
+type RecoveryPolicy struct {
+	Version            int
+	OwnerAddress       string
+	OwnerPublicKey     string
+	TotalShareNumber   int
+	MinimumShareNumber int
+	Custodians         []string
+	CreateTime         int64
+}
+
+// EncryptedShare 使用托管人公钥加密后的私钥片段
+type EncryptedShare struct {
+	Custodian string
+	// 明文片段的SM3摘要，用于在恢复时剔除错误的片段
+	ShareDigest []byte
+	CipherText  []byte
+}
+
+// RecoveryBundle 私钥分存包：策略、加密片段以及所有者对二者的签名
+type RecoveryBundle struct {
+	Policy    *RecoveryPolicy
+	Shares    []*EncryptedShare
+	Signature []byte
+}
+
+// CreateRecoveryBundle 将私钥分割后，把每个片段用对应托管人的公钥加密，并对整个分存包签名
+func CreateRecoveryBundle(ownerKey *ecdsa.PrivateKey, custodians []*ecdsa.PublicKey, minimumShareNumber int) (*RecoveryBundle, error) {
+	totalShareNumber := len(custodians)
+	if ownerKey == nil || minimumShareNumber < 1 || totalShareNumber < minimumShareNumber {
+		return nil, InvalidInputParamsError
+	}
+
+	ownerAddress, err := account.GetAddressFromPublicKey(&ownerKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	ownerPublicKey, err := account.GetEcdsaPublicKeyJsonFormat(ownerKey)
+	if err != nil {
+		return nil, err
+	}
+
+	custodianAddresses := make([]string, totalShareNumber)
+	seen := make(map[string]bool, totalShareNumber)
+	for i, custodian := range custodians {
+		address, err := account.GetAddressFromPublicKey(custodian)
+		if err != nil {
+			return nil, err
+		}
+		if seen[address] {
+			return nil, DuplicateCustodianError
+		}
+		seen[address] = true
+		custodianAddresses[i] = address
+	}
+
+	jsonPrivateKey, err := account.GetEcdsaPrivateKeyJsonFormat(ownerKey)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := account.SplitPrivateKey(jsonPrivateKey, totalShareNumber, minimumShareNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) != totalShareNumber {
+		return nil, fmt.Errorf("SplitPrivateKey returns %d shares, %d expected", len(shares), totalShareNumber)
+	}
+
+	encryptedShares := make([]*EncryptedShare, totalShareNumber)
+	for i, custodian := range custodians {
+		cipherText, err := encryptShare(custodian, []byte(shares[i]))
+		if err != nil {
+			return nil, err
+		}
+		encryptedShares[i] = &EncryptedShare{
+			Custodian:   custodianAddresses[i],
+			ShareDigest: hash.HashUsingSM3([]byte(shares[i])),
+			CipherText:  cipherText,
+		}
+	}
+
+	bundle := &RecoveryBundle{
+		Policy: &RecoveryPolicy{
+			Version:            PolicyVersion,
+			OwnerAddress:       ownerAddress,
+			OwnerPublicKey:     ownerPublicKey,
+			TotalShareNumber:   totalShareNumber,
+			MinimumShareNumber: minimumShareNumber,
+			Custodians:         custodianAddresses,
+			CreateTime:         time.Now().Unix(),
+		},
+		Shares: encryptedShares,
+	}
+
+	digest, err := bundleDigest(bundle)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Signature, err = sign.SignV2ECDSA(ownerKey, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// VerifyRecoveryBundle 校验分存包的签名以及策略的完整性
+func VerifyRecoveryBundle(bundle *RecoveryBundle) (bool, error) {
+	if bundle == nil || bundle.Policy == nil {
+		return false, InvalidInputParamsError
+	}
+	policy := bundle.Policy
+	if policy.MinimumShareNumber < 1 || policy.TotalShareNumber < policy.MinimumShareNumber ||
+		len(policy.Custodians) != policy.TotalShareNumber || len(bundle.Shares) != policy.TotalShareNumber {
+		return false, InvalidBundleError
+	}
+	for i, share := range bundle.Shares {
+		if share == nil || share.Custodian != policy.Custodians[i] {
+			return false, InvalidBundleError
+		}
+	}
+
+	ownerPublicKey, err := account.GetEcdsaPublicKeyFromJson([]byte(policy.OwnerPublicKey))
+	if err != nil {
+		return false, err
+	}
+	// 策略中的地址必须由策略中的公钥推导而来
+	isMatch, _ := account.VerifyAddressUsingPublicKey(policy.OwnerAddress, ownerPublicKey)
+	if !isMatch {
+		return false, InvalidBundleError
+	}
+
+	digest, err := bundleDigest(bundle)
+	if err != nil {
+		return false, err
+	}
+	return signature.XuperSigVerify([]*ecdsa.PublicKey{ownerPublicKey}, bundle.Signature, digest)
+}
+
+// DecryptShare 托管人使用自己的私钥解密属于自己的私钥片段
+func DecryptShare(bundle *RecoveryBundle, custodianKey *ecdsa.PrivateKey) (string, error) {
+	if custodianKey == nil {
+		return "", InvalidInputParamsError
+	}
+	isValid, err := VerifyRecoveryBundle(bundle)
+	if err != nil {
+		return "", err
+	}
+	if !isValid {
+		return "", InvalidBundleError
+	}
+
+	address, err := account.GetAddressFromPublicKey(&custodianKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	for _, share := range bundle.Shares {
+		if share.Custodian != address {
+			continue
+		}
+		plainShare, err := decryptShare(custodianKey, share.CipherText)
+		if err != nil {
+			return "", err
+		}
+		if !utils.BytesCompare(hash.HashUsingSM3(plainShare), share.ShareDigest) {
+			return "", InvalidBundleError
+		}
+		return string(plainShare), nil
+	}
+
+	return "", CustodianNotFoundError
+}
+
+// RecoverPrivateKey 收集至少门限数量的解密片段，恢复账户私钥
+// 与分存包中记录的摘要不一致的片段会被忽略，恢复出的私钥必须与策略中的地址一致
+func RecoverPrivateKey(bundle *RecoveryBundle, shares []string) (*ecdsa.PrivateKey, error) {
+	isValid, err := VerifyRecoveryBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+	if !isValid {
+		return nil, InvalidBundleError
+	}
+
+	digests := make(map[string]bool, len(bundle.Shares))
+	for _, share := range bundle.Shares {
+		digests[string(share.ShareDigest)] = true
+	}
+
+	validShares := []string{}
+	for _, share := range shares {
+		digest := string(hash.HashUsingSM3([]byte(share)))
+		if !digests[digest] {
+			continue
+		}
+		// 同一片段只使用一次
+		delete(digests, digest)
+		validShares = append(validShares, share)
+	}
+	if len(validShares) < bundle.Policy.MinimumShareNumber {
+		return nil, NotEnoughSharesError
+	}
+
+	jsonPrivateKey, err := account.RetrievePrivateKeyByShares(validShares)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := account.GetEcdsaPrivateKeyFromJson([]byte(jsonPrivateKey))
+	if err != nil {
+		return nil, RecoveredKeyMismatchError
+	}
+	isMatch, _ := account.VerifyAddressUsingPublicKey(bundle.Policy.OwnerAddress, &privateKey.PublicKey)
+	if !isMatch {
+		return nil, RecoveredKeyMismatchError
+	}
+
+	return privateKey, nil
+}
+
+// MarshalRecoveryBundle 将分存包序列化为json
+func MarshalRecoveryBundle(bundle *RecoveryBundle) ([]byte, error) {
+	return json.Marshal(bundle)
+}
+
+// UnmarshalRecoveryBundle 从json中解析分存包
+func UnmarshalRecoveryBundle(content []byte) (*RecoveryBundle, error) {
+	bundle := new(RecoveryBundle)
+	err := json.Unmarshal(content, bundle)
+	if err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// bundleDigest 计算分存包中需要签名部分的SM3摘要
+func bundleDigest(bundle *RecoveryBundle) ([]byte, error) {
+	content, err := json.Marshal(struct {
+		Policy *RecoveryPolicy
+		Shares []*EncryptedShare
+	}{bundle.Policy, bundle.Shares})
+	if err != nil {
+		return nil, err
+	}
+	return hash.HashUsingSM3(content), nil
+}
+
+// encryptShare 根据托管人公钥的曲线选择对应的ECIES实现
+func encryptShare(k *ecdsa.PublicKey, share []byte) ([]byte, error) {
+	switch k.Params().Name {
+	case config.CurveNist: // NIST
+		return nistEcies.Encrypt(k, share)
+	case config.CurveGm: // 国密
+		return gmEcies.Encrypt(k, share)
+	default: // 不支持的密码学类型
+		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
+	}
+}
+
+func decryptShare(k *ecdsa.PrivateKey, cipherText []byte) ([]byte, error) {
+	switch k.Params().Name {
+	case config.CurveNist: // NIST
+		return nistEcies.Decrypt(k, cipherText)
+	case config.CurveGm: // 国密
+		return gmEcies.Decrypt(k, cipherText)
+	default: // 不支持的密码学类型
+		return nil, fmt.Errorf("This cryptography curve[%s] has not been supported yet.", k.Params().Name)
+	}
+}
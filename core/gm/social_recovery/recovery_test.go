@@ -0,0 +1,73 @@
+package social_recovery
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocialRecovery(t *testing.T) {
+	ownerKey, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.Nil(t, err)
+
+	// 国密和NIST托管人混合使用
+	custodianKeys := []*ecdsa.PrivateKey{}
+	custodians := []*ecdsa.PublicKey{}
+	for i := 0; i < 4; i++ {
+		curve := sm2.P256Sm2()
+		if i%2 == 1 {
+			curve = elliptic.P256()
+		}
+		k, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.Nil(t, err)
+		custodianKeys = append(custodianKeys, k)
+		custodians = append(custodians, &k.PublicKey)
+	}
+
+	bundle, err := CreateRecoveryBundle(ownerKey, custodians, 3)
+	require.Nil(t, err)
+
+	content, err := MarshalRecoveryBundle(bundle)
+	require.Nil(t, err)
+	bundle, err = UnmarshalRecoveryBundle(content)
+	require.Nil(t, err)
+
+	isValid, err := VerifyRecoveryBundle(bundle)
+	require.Nil(t, err)
+	require.True(t, isValid)
+
+	shares := []string{}
+	for _, k := range custodianKeys {
+		share, err := DecryptShare(bundle, k)
+		require.Nil(t, err)
+		shares = append(shares, share)
+	}
+
+	_, err = RecoverPrivateKey(bundle, shares[:2])
+	require.Equal(t, NotEnoughSharesError, err)
+
+	// 重复或伪造的片段不计入门限
+	_, err = RecoverPrivateKey(bundle, []string{shares[0], shares[0], "00", shares[1]})
+	require.Equal(t, NotEnoughSharesError, err)
+
+	recovered, err := RecoverPrivateKey(bundle, shares[1:])
+	require.Nil(t, err)
+	require.Equal(t, ownerKey.D, recovered.D)
+	require.Equal(t, ownerKey.X, recovered.X)
+
+	stranger, err := ecdsa.GenerateKey(sm2.P256Sm2(), rand.Reader)
+	require.Nil(t, err)
+	_, err = DecryptShare(bundle, stranger)
+	require.Equal(t, CustodianNotFoundError, err)
+
+	// 篡改策略后签名校验失败
+	bundle.Policy.MinimumShareNumber = 1
+	isValid, _ = VerifyRecoveryBundle(bundle)
+	require.False(t, isValid)
+	_, err = RecoverPrivateKey(bundle, shares[:1])
+	require.NotNil(t, err)
+}
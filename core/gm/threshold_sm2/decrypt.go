@@ -0,0 +1,174 @@
+package threshold_sm2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/dkg"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+// 门限SM2解密，用于数据托管等场景：sm2.Encrypt或gm/ecies.Encrypt使用群公钥加密的密文，
+// 需要至少t个私钥份额持有者协作才能解密。
+//
+// 1. 密钥生成：使用NewKeyGenParticipant在SM2曲线上运行dkg，参与者i得到私钥份额x_i
+// 2. 部分解密：参与者i返回 D_i = x_i*C1，以及DLEQ证明 log_G(Y_i) == log_C1(D_i)，其中Y_i为验证公钥
+// 3. 合并：验证每个部分解密后计算 d*C1 = Σ λ_i*D_i，再由sm2.DecryptWithSharedPoint完成KDF与C3的校验
+//
+// 部分解密不会泄露私钥份额，但持有d*C1的合并者可以解密这条密文，合并者需要是明文的接收方。
+
+const contextPartialDecrypt = "cu_crypto/threshold_sm2/decrypt/"
+
+var (
+	InvalidCiphertextC1Error      = errors.New("The C1 point of the ciphertext is invalid")
+	InvalidDecryptionShareError   = errors.New("The decryption share is invalid")
+	NotEnoughDecryptionShareError = errors.New("The number of decryption shares is less than the threshold")
+)
+
+// DecryptionShare 参与者对密文C1的部分解密
+type DecryptionShare struct {
+	Identifier int
+	// D_i = x_i*C1，压缩格式
+	Share []byte
+	Proof []byte
+}
+
+// NewKeyGenParticipant 在SM2曲线上创建门限密钥生成的参与者，协议流程见dkg包
+func NewKeyGenParticipant(identifier, threshold, total int, context []byte) (*dkg.Participant, error) {
+	return dkg.NewParticipant(sm2.P256Sm2(), identifier, threshold, total, context)
+}
+
+// PartialDecrypt 计算 D_i = x_i*C1 并附带DLEQ证明
+func PartialDecrypt(keyShare *dkg.KeyShare, ciphertext []byte) (*DecryptionShare, error) {
+	if keyShare == nil || keyShare.PublicKeyPackage == nil || keyShare.SecretShare == nil {
+		return nil, InvalidInputParamsError
+	}
+	curve := keyShare.Curve
+	if curve.Params().Name != sm2.P256Sm2().Params().Name {
+		return nil, UnsupportedCurveError
+	}
+	c1, err := cipherC1(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := ecc.ProveDLEQ(curve, keyShare.SecretShare, ecc.BasePoint(curve), c1, partialDecryptContext(keyShare.Identifier))
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	share, err := c1.ScalarMult(keyShare.SecretShare).MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecryptionShare{
+		Identifier: keyShare.Identifier,
+		Share:      share,
+		Proof:      proofBytes,
+	}, nil
+}
+
+// VerifyDecryptionShare 使用参与者的验证公钥验证部分解密的DLEQ证明
+func VerifyDecryptionShare(pkg *dkg.PublicKeyPackage, ciphertext []byte, share *DecryptionShare) error {
+	_, err := verifyDecryptionShare(pkg, ciphertext, share)
+	return err
+}
+
+// CombineDecryptionShares 验证并合并至少t个部分解密，返回明文
+func CombineDecryptionShares(pkg *dkg.PublicKeyPackage, ciphertext []byte, shares []*DecryptionShare) ([]byte, error) {
+	if pkg == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(shares) < pkg.Threshold {
+		return nil, NotEnoughDecryptionShareError
+	}
+
+	identifiers := make([]int, len(shares))
+	points := make([]*ecc.Point, len(shares))
+	for i, share := range shares {
+		point, err := verifyDecryptionShare(pkg, ciphertext, share)
+		if err != nil {
+			return nil, err
+		}
+		identifiers[i] = share.Identifier
+		points[i] = point
+	}
+	if _, err := pkg.SortedIdentifiers(identifiers); err != nil {
+		return nil, err
+	}
+
+	curve := pkg.Curve
+	lambdas := make([]*big.Int, len(shares))
+	for i, id := range identifiers {
+		lambda, err := dkg.LagrangeCoefficient(curve, identifiers, id)
+		if err != nil {
+			return nil, err
+		}
+		lambdas[i] = lambda
+	}
+
+	// d*C1 = Σ λ_i*D_i
+	shared := ecc.MultiScalarMult(curve, lambdas, points)
+	if shared.IsInfinity() {
+		return nil, InvalidDecryptionShareError
+	}
+	return sm2.DecryptWithSharedPoint(ciphertext, shared.X, shared.Y)
+}
+
+func verifyDecryptionShare(pkg *dkg.PublicKeyPackage, ciphertext []byte, share *DecryptionShare) (*ecc.Point, error) {
+	if pkg == nil || share == nil {
+		return nil, InvalidInputParamsError
+	}
+	curve := pkg.Curve
+	if curve.Params().Name != sm2.P256Sm2().Params().Name {
+		return nil, UnsupportedCurveError
+	}
+	verificationShare, exist := pkg.VerificationShares[share.Identifier]
+	if !exist {
+		return nil, UnknownSignerError
+	}
+	c1, err := cipherC1(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	point, err := ecc.UnmarshalPoint(curve, share.Share)
+	if err != nil {
+		return nil, fmt.Errorf("%v: participant %d", InvalidDecryptionShareError, share.Identifier)
+	}
+	proof, err := ecc.UnmarshalDLEQProof(curve, share.Proof)
+	if err != nil {
+		return nil, fmt.Errorf("%v: participant %d", InvalidDecryptionShareError, share.Identifier)
+	}
+	statement := &ecc.DLEQStatement{
+		G1: ecc.BasePoint(curve),
+		H1: &ecc.Point{Curve: curve, X: verificationShare.X, Y: verificationShare.Y},
+		G2: c1,
+		H2: point,
+	}
+	if !ecc.VerifyDLEQ(statement, proof, partialDecryptContext(share.Identifier)) {
+		return nil, fmt.Errorf("%v: participant %d", InvalidDecryptionShareError, share.Identifier)
+	}
+	return point, nil
+}
+
+// cipherC1 解析密文中的C1，C1必须是曲线上的非无穷远点，避免部分解密泄露私钥份额
+func cipherC1(ciphertext []byte) (*ecc.Point, error) {
+	curve := sm2.P256Sm2()
+	x, y, err := sm2.CipherC1(curve, ciphertext)
+	if err != nil {
+		return nil, InvalidCiphertextC1Error
+	}
+	return &ecc.Point{Curve: curve, X: x, Y: y}, nil
+}
+
+func partialDecryptContext(identifier int) []byte {
+	return []byte(contextPartialDecrypt + strconv.Itoa(identifier))
+}
@@ -0,0 +1,536 @@
+package threshold_sm2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/common/math/homomorphism/paillier"
+	"github.com/legendzhouwd/cu_crypto/core/gm/dkg"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// t-of-n门限SM2签名，私钥份额来自dkg包在SM2曲线上生成的KeyShare
+//
+// 设参与签名的集合为S，λ_i为拉格朗日系数，令 u_i = λ_i*x_i（S中标识最小的参与者再加1），则 Σu_i = 1 + d。
+// SM2签名 s = (1 + d)^-1 * (k + r) - r，引入随机数 γ = Σγ_i 后：
+// (1 + d)^-1 * (k + r) = (γ*k + r*γ) / (γ*(1 + d))
+//
+// Round 1: 参与者i随机选择k_i、γ_i，广播 K_i = k_i*G 的承诺、自己的Paillier公钥以及 Enc_i(γ_i)
+// Round 2: 参与者i对每个j使用Paillier同态计算MtA（乘法转加法）：
+// Enc_j(γ_j*u_i + β')、Enc_j(γ_j*k_i + ν')，自己保留 -β'、-ν'，同时公开K_i以及对k_i的知识证明
+// Round 3: 解密后得到 γ*(1 + d) 与 γ*k 的加法份额 δ_i、τ_i，广播δ_i
+// Round 4: δ = Σδ_i，R = ΣK_i，r = (R.x + e) mod n，广播 s_i = δ^-1 * (τ_i + r*γ_i)
+// Finalize: s = Σs_i - r，使用群公钥验证后输出标准的SM2签名
+//
+// 本实现的MtA不包含范围证明，只能抵抗半诚实的参与者：恶意参与者可以使签名失败，
+// Finalize会验证最终签名，失败时返回错误，但无法定位作恶的参与者。
+
+const (
+	contextThresholdNonce = "cu_crypto/threshold_sm2/tss/nonce/"
+	tagNonceCommitment    = "cu_crypto/threshold_sm2/tss/commitment"
+
+	// mtaMaskBits MtA中掩码β'、ν'比乘积多出的比特数，用于统计隐藏乘积
+	mtaMaskBits = 80
+)
+
+// 签名者的轮次
+const (
+	signRoundOne = iota
+	signRoundTwo
+	signRoundThree
+	signRoundFour
+	signRoundFinalize
+	signRoundDone
+)
+
+var (
+	UnsupportedCurveError      = errors.New("The threshold SM2 signature only supports the SM2 curve")
+	NotInSignersError          = errors.New("The key share owner is not in the signers")
+	PaillierKeyTooSmallError   = errors.New("The Paillier modulus is too small for the MtA protocol")
+	RoundStateError            = errors.New("The operation is not allowed in the current round")
+	MissingMessageError        = errors.New("The messages of some signers are missing")
+	DuplicateMessageError      = errors.New("The signer has sent more than one message")
+	UnknownSignerError         = errors.New("The message is not sent by one of the signers")
+	NonceCommitmentError       = errors.New("The nonce does not match its commitment")
+	InvalidCiphertextError     = errors.New("The Paillier ciphertext is invalid")
+	InvalidSignatureShareError = errors.New("The signature share is out of range")
+)
+
+// SignRound1Message 第一轮广播的消息
+type SignRound1Message struct {
+	From int
+	// SM3(K_i || blinding)
+	Commitment        []byte
+	PaillierPublicKey *paillier.PublicKey
+	// Enc_i(γ_i)
+	EncryptedGamma []byte
+}
+
+// SignRound2Message 第二轮发送给参与者To的消息
+type SignRound2Message struct {
+	From int
+	To   int
+	// K_i及其承诺的随机数，以及对k_i的知识证明
+	Nonce      []byte
+	Blinding   []byte
+	NonceProof []byte
+	// Enc_To(γ_To*u_From + β')、Enc_To(γ_To*k_From + ν')
+	EncryptedGammaU []byte
+	EncryptedGammaK []byte
+}
+
+// SignRound3Message 第三轮广播的δ_i
+type SignRound3Message struct {
+	From  int
+	Delta []byte
+}
+
+// SignRound4Message 第四轮广播的部分签名s_i
+type SignRound4Message struct {
+	From     int
+	PartialS []byte
+}
+
+// ThresholdSigner 门限签名中单个签名者的状态，每个实例只能完成一次签名
+type ThresholdSigner struct {
+	keyShare    *dkg.KeyShare
+	paillierKey *paillier.PrivateKey
+	signers     []int
+	digest      []byte
+
+	u, k, gamma *big.Int
+	nonce       *ecc.Point
+	blinding    []byte
+
+	commitments    map[int][]byte
+	peerPaillier   map[int]*paillier.PublicKey
+	peerEncGamma   map[int]*big.Int
+	betas, nus     map[int]*big.Int
+	delta, tau     *big.Int
+	r              *big.Int
+	aggregateNonce *ecc.Point
+	round          int
+}
+
+// NewThresholdSigner 创建门限签名者，signers为参与本次签名的全部标识（数量不少于门限），
+// digest为待签名的摘要，通常由MessageDigest使用群公钥计算
+func NewThresholdSigner(keyShare *dkg.KeyShare, paillierKey *paillier.PrivateKey, signers []int, digest []byte) (*ThresholdSigner, error) {
+	if keyShare == nil || keyShare.PublicKeyPackage == nil || keyShare.SecretShare == nil || paillierKey == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(digest) == 0 {
+		return nil, InvalidDigestError
+	}
+	curve := keyShare.Curve
+	if curve.Params().Name != sm2.P256Sm2().Params().Name {
+		return nil, UnsupportedCurveError
+	}
+	n := curve.Params().N
+	if paillierKey.N == nil || paillierKey.N.BitLen() <= 2*n.BitLen()+mtaMaskBits+1 {
+		return nil, PaillierKeyTooSmallError
+	}
+
+	sorted, err := keyShare.SortedIdentifiers(signers)
+	if err != nil {
+		return nil, err
+	}
+	lambda, err := dkg.LagrangeCoefficient(curve, sorted, keyShare.Identifier)
+	if err != nil {
+		return nil, NotInSignersError
+	}
+
+	// u_i = λ_i*x_i，标识最小的参与者额外加1，使得 Σu_i = 1 + d
+	u := new(big.Int).Mul(lambda, keyShare.SecretShare)
+	if sorted[0] == keyShare.Identifier {
+		u.Add(u, big.NewInt(1))
+	}
+	u.Mod(u, n)
+
+	return &ThresholdSigner{
+		keyShare:     keyShare,
+		paillierKey:  paillierKey,
+		signers:      sorted,
+		digest:       append([]byte{}, digest...),
+		u:            u,
+		commitments:  make(map[int][]byte),
+		peerPaillier: make(map[int]*paillier.PublicKey),
+		peerEncGamma: make(map[int]*big.Int),
+		betas:        make(map[int]*big.Int),
+		nus:          make(map[int]*big.Int),
+		round:        signRoundOne,
+	}, nil
+}
+
+// Round1 生成k_i、γ_i，返回需要广播的承诺与 Enc_i(γ_i)
+func (s *ThresholdSigner) Round1() (*SignRound1Message, error) {
+	if s.round != signRoundOne {
+		return nil, RoundStateError
+	}
+	curve := s.keyShare.Curve
+
+	k, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, err
+	}
+	blinding := make([]byte, 32)
+	if _, err := rand.Read(blinding); err != nil {
+		return nil, err
+	}
+	encGamma, err := s.paillierKey.Encrypt(gamma)
+	if err != nil {
+		return nil, err
+	}
+
+	s.k = k
+	s.gamma = gamma
+	s.nonce = ecc.ScalarBaseMult(curve, k)
+	s.blinding = blinding
+	commitment, err := nonceCommitment(s.keyShare.Identifier, s.nonce, blinding)
+	if err != nil {
+		return nil, err
+	}
+
+	s.round = signRoundTwo
+	return &SignRound1Message{
+		From:              s.keyShare.Identifier,
+		Commitment:        commitment,
+		PaillierPublicKey: &paillier.PublicKey{N: s.paillierKey.N, G: s.paillierKey.G},
+		EncryptedGamma:    encGamma.Bytes(),
+	}, nil
+}
+
+// Round2 处理其他签名者的第一轮消息，返回分别发送给其他签名者的MtA消息
+func (s *ThresholdSigner) Round2(messages []*SignRound1Message) ([]*SignRound2Message, error) {
+	if s.round != signRoundTwo {
+		return nil, RoundStateError
+	}
+	n := s.keyShare.Curve.Params().N
+
+	received := make(map[int]bool)
+	for _, msg := range messages {
+		if msg == nil || msg.PaillierPublicKey == nil || msg.PaillierPublicKey.N == nil || msg.PaillierPublicKey.G == nil {
+			return nil, InvalidInputParamsError
+		}
+		if msg.From == s.keyShare.Identifier {
+			continue
+		}
+		if err := s.checkSender(msg.From, received); err != nil {
+			return nil, err
+		}
+		pk := msg.PaillierPublicKey
+		if pk.N.BitLen() <= 2*n.BitLen()+mtaMaskBits+1 {
+			return nil, fmt.Errorf("%v: signer %d", PaillierKeyTooSmallError, msg.From)
+		}
+		encGamma := new(big.Int).SetBytes(msg.EncryptedGamma)
+		if !validCiphertext(pk, encGamma) {
+			return nil, fmt.Errorf("%v: signer %d", InvalidCiphertextError, msg.From)
+		}
+
+		s.commitments[msg.From] = append([]byte{}, msg.Commitment...)
+		s.peerPaillier[msg.From] = pk
+		s.peerEncGamma[msg.From] = encGamma
+	}
+	if len(received) != len(s.signers)-1 {
+		return nil, MissingMessageError
+	}
+
+	curve := s.keyShare.Curve
+	nonceBytes, err := s.nonce.MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+	proof, err := ecc.ProveDiscreteLog(curve, s.k, nil, s.nonceProofContext(s.keyShare.Identifier))
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*SignRound2Message, 0, len(s.signers)-1)
+	for _, j := range s.signers {
+		if j == s.keyShare.Identifier {
+			continue
+		}
+		encGammaU, beta, err := mta(s.peerPaillier[j], s.peerEncGamma[j], s.u, n)
+		if err != nil {
+			return nil, err
+		}
+		encGammaK, nu, err := mta(s.peerPaillier[j], s.peerEncGamma[j], s.k, n)
+		if err != nil {
+			return nil, err
+		}
+		s.betas[j] = beta
+		s.nus[j] = nu
+
+		result = append(result, &SignRound2Message{
+			From:            s.keyShare.Identifier,
+			To:              j,
+			Nonce:           nonceBytes,
+			Blinding:        s.blinding,
+			NonceProof:      proofBytes,
+			EncryptedGammaU: encGammaU.Bytes(),
+			EncryptedGammaK: encGammaK.Bytes(),
+		})
+	}
+
+	s.round = signRoundThree
+	return result, nil
+}
+
+// Round3 验证其他签名者公开的K_j，解密MtA结果得到δ_i、τ_i，返回需要广播的δ_i
+func (s *ThresholdSigner) Round3(messages []*SignRound2Message) (*SignRound3Message, error) {
+	if s.round != signRoundThree {
+		return nil, RoundStateError
+	}
+	curve := s.keyShare.Curve
+	n := curve.Params().N
+
+	// δ_i = γ_i*u_i + Σ(α_ij + β_ij)，τ_i = γ_i*k_i + Σ(μ_ij + ν_ij)
+	delta := new(big.Int).Mul(s.gamma, s.u)
+	tau := new(big.Int).Mul(s.gamma, s.k)
+	aggregateNonce := s.nonce
+
+	received := make(map[int]bool)
+	for _, msg := range messages {
+		if msg == nil || msg.To != s.keyShare.Identifier {
+			return nil, InvalidInputParamsError
+		}
+		if err := s.checkSender(msg.From, received); err != nil {
+			return nil, err
+		}
+
+		nonce, err := ecc.UnmarshalPoint(curve, msg.Nonce)
+		if err != nil {
+			return nil, fmt.Errorf("%v: signer %d", NonceCommitmentError, msg.From)
+		}
+		commitment, err := nonceCommitment(msg.From, nonce, msg.Blinding)
+		if err != nil || !bytes.Equal(commitment, s.commitments[msg.From]) {
+			return nil, fmt.Errorf("%v: signer %d", NonceCommitmentError, msg.From)
+		}
+		proof, err := ecc.UnmarshalSchnorrProof(curve, msg.NonceProof)
+		if err != nil || !ecc.VerifyDiscreteLog(nonce, nil, proof, s.nonceProofContext(msg.From)) {
+			return nil, fmt.Errorf("%v: signer %d", InvalidProofError, msg.From)
+		}
+
+		encGammaU := new(big.Int).SetBytes(msg.EncryptedGammaU)
+		encGammaK := new(big.Int).SetBytes(msg.EncryptedGammaK)
+		if !validCiphertext(&s.paillierKey.PublicKey, encGammaU) || !validCiphertext(&s.paillierKey.PublicKey, encGammaK) {
+			return nil, fmt.Errorf("%v: signer %d", InvalidCiphertextError, msg.From)
+		}
+		delta.Add(delta, s.paillierKey.Decrypt(encGammaU))
+		delta.Add(delta, s.betas[msg.From])
+		tau.Add(tau, s.paillierKey.Decrypt(encGammaK))
+		tau.Add(tau, s.nus[msg.From])
+
+		aggregateNonce, err = aggregateNonce.Add(nonce)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(received) != len(s.signers)-1 {
+		return nil, MissingMessageError
+	}
+	if aggregateNonce.IsInfinity() {
+		return nil, InvalidSignatureError
+	}
+
+	s.delta = delta.Mod(delta, n)
+	s.tau = tau.Mod(tau, n)
+	s.aggregateNonce = aggregateNonce
+	s.round = signRoundFour
+	return &SignRound3Message{
+		From:  s.keyShare.Identifier,
+		Delta: ecc.ScalarBytes(curve, s.delta),
+	}, nil
+}
+
+// Round4 汇总δ并计算r，返回需要广播的部分签名 s_i = δ^-1 * (τ_i + r*γ_i)
+func (s *ThresholdSigner) Round4(messages []*SignRound3Message) (*SignRound4Message, error) {
+	if s.round != signRoundFour {
+		return nil, RoundStateError
+	}
+	curve := s.keyShare.Curve
+	n := curve.Params().N
+
+	delta := new(big.Int).Set(s.delta)
+	received := make(map[int]bool)
+	for _, msg := range messages {
+		if msg == nil {
+			return nil, InvalidInputParamsError
+		}
+		if msg.From == s.keyShare.Identifier {
+			continue
+		}
+		if err := s.checkSender(msg.From, received); err != nil {
+			return nil, err
+		}
+		d := new(big.Int).SetBytes(msg.Delta)
+		if d.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("%v: signer %d", InvalidSignatureShareError, msg.From)
+		}
+		delta.Add(delta, d)
+	}
+	if len(received) != len(s.signers)-1 {
+		return nil, MissingMessageError
+	}
+	delta.Mod(delta, n)
+	deltaInv := new(big.Int).ModInverse(delta, n)
+	if deltaInv == nil {
+		return nil, InvalidSignatureError
+	}
+
+	// r = (R.x + e) mod n
+	r := new(big.Int).Add(s.aggregateNonce.X, new(big.Int).SetBytes(s.digest))
+	r.Mod(r, n)
+	if r.Sign() == 0 {
+		return nil, InvalidSignatureError
+	}
+
+	partial := new(big.Int).Mul(r, s.gamma)
+	partial.Add(partial, s.tau)
+	partial.Mul(partial, deltaInv)
+	partial.Mod(partial, n)
+
+	// 清除本次签名的随机数，防止被再次使用
+	s.k.SetInt64(0)
+	s.gamma.SetInt64(0)
+	s.tau.SetInt64(0)
+	for _, v := range s.betas {
+		v.SetInt64(0)
+	}
+	for _, v := range s.nus {
+		v.SetInt64(0)
+	}
+	s.r = r
+	s.round = signRoundFinalize
+	return &SignRound4Message{
+		From:     s.keyShare.Identifier,
+		PartialS: ecc.ScalarBytes(curve, partial),
+	}, nil
+}
+
+// Finalize 汇总全部签名者的部分签名（包括自己的），验证后返回ASN.1编码的SM2签名
+func (s *ThresholdSigner) Finalize(messages []*SignRound4Message) ([]byte, error) {
+	if s.round != signRoundFinalize {
+		return nil, RoundStateError
+	}
+	n := s.keyShare.Curve.Params().N
+
+	sum := new(big.Int)
+	received := make(map[int]bool)
+	for _, msg := range messages {
+		if msg == nil {
+			return nil, InvalidInputParamsError
+		}
+		if !s.isSigner(msg.From) {
+			return nil, UnknownSignerError
+		}
+		if received[msg.From] {
+			return nil, DuplicateMessageError
+		}
+		partial := new(big.Int).SetBytes(msg.PartialS)
+		if partial.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("%v: signer %d", InvalidSignatureShareError, msg.From)
+		}
+		sum.Add(sum, partial)
+		received[msg.From] = true
+	}
+	if len(received) != len(s.signers) {
+		return nil, MissingMessageError
+	}
+
+	// s = Σs_i - r
+	sum.Sub(sum, s.r)
+	sum.Mod(sum, n)
+
+	sig, err := finishSignature(s.keyShare.GroupPublicKey, s.digest, s.r, sum)
+	if err != nil {
+		return nil, err
+	}
+	s.round = signRoundDone
+	return sig, nil
+}
+
+func (s *ThresholdSigner) isSigner(identifier int) bool {
+	for _, id := range s.signers {
+		if id == identifier {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ThresholdSigner) checkSender(from int, received map[int]bool) error {
+	if from == s.keyShare.Identifier || !s.isSigner(from) {
+		return UnknownSignerError
+	}
+	if received[from] {
+		return DuplicateMessageError
+	}
+	received[from] = true
+	return nil
+}
+
+// nonceProofContext 知识证明绑定签名者标识与待签名的摘要
+func (s *ThresholdSigner) nonceProofContext(identifier int) []byte {
+	ctx := []byte(contextThresholdNonce + strconv.Itoa(identifier) + "/")
+	return append(ctx, s.digest...)
+}
+
+// mta 对 Enc(a) 计算 Enc(a*b + β')，返回密文以及自己的加法份额 -β' mod n
+func mta(pk *paillier.PublicKey, encA, b, n *big.Int) (*big.Int, *big.Int, error) {
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(2*n.BitLen()+mtaMaskBits))
+	betaPrime, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return nil, nil, err
+	}
+	// 使用新的随机数加密β'，使得结果密文的随机数与Enc(a)无关
+	encBeta, err := pk.Encrypt(betaPrime)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := pk.CyphersAdd(pk.CypherPlainMultiply(encA, b), encBeta)
+
+	share := new(big.Int).Neg(betaPrime)
+	share.Mod(share, n)
+	return c, share, nil
+}
+
+// validCiphertext 密文需要在(0, N^2)内且与N互素
+func validCiphertext(pk *paillier.PublicKey, c *big.Int) bool {
+	nSquare := new(big.Int).Mul(pk.N, pk.N)
+	if c.Sign() <= 0 || c.Cmp(nSquare) >= 0 {
+		return false
+	}
+	return new(big.Int).GCD(nil, nil, c, pk.N).Cmp(big.NewInt(1)) == 0
+}
+
+// nonceCommitment SM3(tag || identifier || K_i || blinding)
+func nonceCommitment(identifier int, nonce *ecc.Point, blinding []byte) ([]byte, error) {
+	nonceBytes, err := nonce.MarshalCompressed()
+	if err != nil {
+		return nil, err
+	}
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], uint32(identifier))
+
+	h := sm3.New()
+	h.Write([]byte(tagNonceCommitment))
+	h.Write(id[:])
+	h.Write(nonceBytes)
+	h.Write(blinding)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,298 @@
+package threshold_sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/homomorphism/paillier"
+	"github.com/legendzhouwd/cu_crypto/core/gm/dkg"
+	"github.com/legendzhouwd/cu_crypto/core/gm/ecies"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/sign"
+)
+
+var testUID = []byte("1234567812345678")
+
+// requireStandardSignature 签名需要同时通过sign.VerifyECDSA与sm2.Sm2Verify的验证
+func requireStandardSignature(t *testing.T, publicKey *ecdsa.PublicKey, message, digest, sig []byte) {
+	ok, err := sign.VerifyECDSA(publicKey, sig, digest)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	r, s, err := sm2.SignDataToSignDigit(sig)
+	require.NoError(t, err)
+	pub := &sm2.PublicKey{Curve: sm2.P256Sm2(), X: publicKey.X, Y: publicKey.Y}
+	require.True(t, sm2.Sm2Verify(pub, message, testUID, r, s))
+	require.False(t, sm2.Sm2Verify(pub, []byte("tampered"), testUID, r, s))
+}
+
+func TestTwoPartySign(t *testing.T) {
+	paillierKey, err := paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+	require.NoError(t, err)
+	keyGen, req, err := NewClientKeyGen(paillierKey)
+	require.NoError(t, err)
+	serverShare, resp, err := ServerKeyGen(req)
+	require.NoError(t, err)
+	clientShare, err := keyGen.Finish(resp)
+	require.NoError(t, err)
+	require.Equal(t, serverShare.PublicKey.X, clientShare.PublicKey.X)
+	require.Equal(t, serverShare.PublicKey.Y, clientShare.PublicKey.Y)
+
+	message := []byte("two-party SM2 signature")
+	digest, err := MessageDigest(clientShare.PublicKey, message, testUID)
+	require.NoError(t, err)
+
+	session, signReq, err := clientShare.StartSign(digest)
+	require.NoError(t, err)
+	signResp, err := serverShare.Sign(signReq)
+	require.NoError(t, err)
+	sig, err := session.Finish(signResp)
+	require.NoError(t, err)
+	requireStandardSignature(t, clientShare.PublicKey, message, digest, sig)
+
+	_, err = session.Finish(signResp)
+	require.Equal(t, SessionAlreadyUsedError, err)
+
+	// 服务端返回错误的部分签名时，客户端拒绝输出
+	session, signReq, err = clientShare.StartSign(digest)
+	require.NoError(t, err)
+	signResp, err = serverShare.Sign(signReq)
+	require.NoError(t, err)
+	signResp.EncryptedS = serverShare.PaillierPublicKey.CypherPlainAdd(new(big.Int).SetBytes(signResp.EncryptedS), big.NewInt(1)).Bytes()
+	_, err = session.Finish(signResp)
+	require.Equal(t, InvalidSignatureError, err)
+
+	// 服务端只能看到客户端数据的密文，不能接受无效的密文
+	_, signReq, err = clientShare.StartSign(digest)
+	require.NoError(t, err)
+	signReq.EncryptedD1K1 = paillierKey.N.Bytes()
+	_, err = serverShare.Sign(signReq)
+	require.Equal(t, InvalidCiphertextError, err)
+}
+
+func TestTwoPartyInvalidProof(t *testing.T) {
+	paillierKey, err := paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+	require.NoError(t, err)
+	keyGen, req, err := NewClientKeyGen(paillierKey)
+	require.NoError(t, err)
+
+	_, otherReq, err := NewClientKeyGen(paillierKey)
+	require.NoError(t, err)
+	_, _, err = ServerKeyGen(&KeyGenRequest{P1: req.P1, Proof: otherReq.Proof, PaillierPublicKey: req.PaillierPublicKey, EncryptedD1: req.EncryptedD1})
+	require.Equal(t, InvalidProofError, err)
+	_, _, err = ServerKeyGen(&KeyGenRequest{P1: req.P1, Proof: req.Proof, PaillierPublicKey: req.PaillierPublicKey})
+	require.Equal(t, InvalidCiphertextError, err)
+
+	// Paillier模数需要容纳签名计算中的明文
+	smallKey, err := paillier.GeneratePrivateKey(256)
+	require.NoError(t, err)
+	_, _, err = NewClientKeyGen(smallKey)
+	require.Equal(t, PaillierKeyTooSmallError, err)
+	_, _, err = ServerKeyGen(&KeyGenRequest{P1: req.P1, Proof: req.Proof, PaillierPublicKey: &smallKey.PublicKey, EncryptedD1: req.EncryptedD1})
+	require.Equal(t, PaillierKeyTooSmallError, err)
+
+	serverShare, resp, err := ServerKeyGen(req)
+	require.NoError(t, err)
+	_, otherResp, err := ServerKeyGen(otherReq)
+	require.NoError(t, err)
+	_, err = keyGen.Finish(&KeyGenResponse{D2Point: resp.D2Point, W: resp.W, Proof: otherResp.Proof})
+	require.Equal(t, InvalidProofError, err)
+
+	clientShare, err := keyGen.Finish(resp)
+	require.NoError(t, err)
+	_, signReq, err := clientShare.StartSign([]byte("digest"))
+	require.NoError(t, err)
+	// 知识证明绑定了摘要，不能用于其他摘要
+	signReq.Digest = []byte("other digest")
+	_, err = serverShare.Sign(signReq)
+	require.Equal(t, InvalidProofError, err)
+}
+
+func generateKeyShares(t *testing.T, threshold, total int) []*dkg.KeyShare {
+	participants := make([]*dkg.Participant, total)
+	round1 := make([]*dkg.Round1Package, total)
+	for i := range participants {
+		p, err := NewKeyGenParticipant(i+1, threshold, total, []byte("threshold sm2 test"))
+		require.NoError(t, err)
+		participants[i] = p
+		round1[i], err = p.Round1()
+		require.NoError(t, err)
+	}
+
+	inbox := make(map[int][]*dkg.Round2Package)
+	for _, p := range participants {
+		packages, err := p.Round2(round1)
+		require.NoError(t, err)
+		for _, pkg := range packages {
+			inbox[pkg.To] = append(inbox[pkg.To], pkg)
+		}
+	}
+
+	keyShares := make([]*dkg.KeyShare, total)
+	for i, p := range participants {
+		keyShare, err := p.Finalize(inbox[p.Identifier()])
+		require.NoError(t, err)
+		keyShares[i] = keyShare
+	}
+	return keyShares
+}
+
+// thresholdSign 由signers中的参与者完成一次门限签名，返回每个签名者的输出
+func thresholdSign(t *testing.T, keyShares []*dkg.KeyShare, paillierKeys map[int]*paillier.PrivateKey, ids []int, digest []byte) [][]byte {
+	signers := make([]*ThresholdSigner, len(ids))
+	round1 := make([]*SignRound1Message, len(ids))
+	for i, id := range ids {
+		signer, err := NewThresholdSigner(keyShares[id-1], paillierKeys[id], ids, digest)
+		require.NoError(t, err)
+		signers[i] = signer
+		round1[i], err = signer.Round1()
+		require.NoError(t, err)
+	}
+
+	inbox := make(map[int][]*SignRound2Message)
+	for _, signer := range signers {
+		messages, err := signer.Round2(round1)
+		require.NoError(t, err)
+		for _, msg := range messages {
+			inbox[msg.To] = append(inbox[msg.To], msg)
+		}
+	}
+
+	round3 := make([]*SignRound3Message, len(ids))
+	for i, signer := range signers {
+		var err error
+		round3[i], err = signer.Round3(inbox[ids[i]])
+		require.NoError(t, err)
+	}
+
+	round4 := make([]*SignRound4Message, len(ids))
+	for i, signer := range signers {
+		var err error
+		round4[i], err = signer.Round4(round3)
+		require.NoError(t, err)
+	}
+
+	sigs := make([][]byte, len(ids))
+	for i, signer := range signers {
+		var err error
+		sigs[i], err = signer.Finalize(round4)
+		require.NoError(t, err)
+
+		_, err = signer.Finalize(round4)
+		require.Equal(t, RoundStateError, err)
+	}
+	return sigs
+}
+
+func TestThresholdSign(t *testing.T) {
+	keyShares := generateKeyShares(t, 2, 3)
+	publicKey := keyShares[0].GroupPublicKey
+
+	paillierKeys := make(map[int]*paillier.PrivateKey)
+	for _, keyShare := range keyShares {
+		key, err := paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+		require.NoError(t, err)
+		paillierKeys[keyShare.Identifier] = key
+	}
+
+	message := []byte("threshold SM2 signature")
+	digest, err := MessageDigest(publicKey, message, testUID)
+	require.NoError(t, err)
+
+	for _, ids := range [][]int{{1, 2}, {3, 2}, {1, 2, 3}} {
+		sigs := thresholdSign(t, keyShares, paillierKeys, ids, digest)
+		for _, sig := range sigs {
+			require.Equal(t, sigs[0], sig)
+		}
+		requireStandardSignature(t, publicKey, message, digest, sigs[0])
+	}
+}
+
+func TestThresholdSignerErrors(t *testing.T) {
+	keyShares := generateKeyShares(t, 2, 3)
+	paillierKey, err := paillier.GeneratePrivateKey(paillier.DefaultPrimeLength)
+	require.NoError(t, err)
+	digest := []byte("digest")
+
+	_, err = NewThresholdSigner(keyShares[0], paillierKey, []int{1}, digest)
+	require.Equal(t, dkg.NotEnoughIdentifiersError, err)
+	_, err = NewThresholdSigner(keyShares[0], paillierKey, []int{2, 3}, digest)
+	require.Equal(t, NotInSignersError, err)
+
+	smallKey, err := paillier.GeneratePrivateKey(256)
+	require.NoError(t, err)
+	_, err = NewThresholdSigner(keyShares[0], smallKey, []int{1, 2}, digest)
+	require.Equal(t, PaillierKeyTooSmallError, err)
+
+	nistShare := &dkg.KeyShare{Identifier: 1, SecretShare: keyShares[0].SecretShare, PublicKeyPackage: &dkg.PublicKeyPackage{Curve: elliptic.P256()}}
+	_, err = NewThresholdSigner(nistShare, paillierKey, []int{1, 2}, digest)
+	require.Equal(t, UnsupportedCurveError, err)
+
+	signer, err := NewThresholdSigner(keyShares[0], paillierKey, []int{1, 2}, digest)
+	require.NoError(t, err)
+	_, err = signer.Round2(nil)
+	require.Equal(t, RoundStateError, err)
+	round1, err := signer.Round1()
+	require.NoError(t, err)
+	_, err = signer.Round2([]*SignRound1Message{round1})
+	require.Equal(t, MissingMessageError, err)
+}
+
+func TestThresholdDecrypt(t *testing.T) {
+	keyShares := generateKeyShares(t, 2, 3)
+	pkg := keyShares[0].PublicKeyPackage
+	publicKey := pkg.GroupPublicKey
+	plaintext := []byte("escrowed data")
+
+	ciphertext, err := sm2.Encrypt(&sm2.PublicKey{Curve: sm2.P256Sm2(), X: publicKey.X, Y: publicKey.Y}, plaintext)
+	require.NoError(t, err)
+	eciesCiphertext, err := ecies.Encrypt(publicKey, plaintext)
+	require.NoError(t, err)
+
+	for _, ct := range [][]byte{ciphertext, eciesCiphertext} {
+		shares := make([]*DecryptionShare, len(keyShares))
+		for i, keyShare := range keyShares {
+			shares[i], err = PartialDecrypt(keyShare, ct)
+			require.NoError(t, err)
+			require.NoError(t, VerifyDecryptionShare(pkg, ct, shares[i]))
+		}
+
+		for _, subset := range [][]*DecryptionShare{shares[:2], {shares[2], shares[0]}, shares} {
+			decrypted, err := CombineDecryptionShares(pkg, ct, subset)
+			require.NoError(t, err)
+			require.Equal(t, plaintext, decrypted)
+		}
+
+		_, err = CombineDecryptionShares(pkg, ct, shares[:1])
+		require.Equal(t, NotEnoughDecryptionShareError, err)
+		_, err = CombineDecryptionShares(pkg, ct, []*DecryptionShare{shares[0], shares[0]})
+		require.Equal(t, dkg.DuplicateIdentifierError, err)
+
+		// 部分解密与其他参与者的验证公钥不匹配
+		forged := &DecryptionShare{Identifier: 2, Share: shares[0].Share, Proof: shares[0].Proof}
+		require.Error(t, VerifyDecryptionShare(pkg, ct, forged))
+		_, err = CombineDecryptionShares(pkg, ct, []*DecryptionShare{shares[0], forged})
+		require.Error(t, err)
+	}
+
+	// C3校验失败
+	tampered := append([]byte{}, ciphertext...)
+	tampered[len(tampered)-1] ^= 0x01
+	shares := make([]*DecryptionShare, 2)
+	for i := range shares {
+		shares[i], err = PartialDecrypt(keyShares[i], tampered)
+		require.NoError(t, err)
+	}
+	_, err = CombineDecryptionShares(pkg, tampered, shares)
+	require.Error(t, err)
+
+	// C1不在曲线上
+	invalid := append([]byte{}, ciphertext...)
+	invalid[1] ^= 0x01
+	_, err = PartialDecrypt(keyShares[0], invalid)
+	require.Equal(t, InvalidCiphertextC1Error, err)
+}
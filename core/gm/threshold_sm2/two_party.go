@@ -0,0 +1,458 @@
+package threshold_sm2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/common/math/homomorphism/paillier"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm3"
+)
+
+// 两方协同SM2签名，私钥d以乘法份额的形式保存：(1 + d)^-1 = d1 * d2 mod n
+// 客户端（例如手机）持有d1，服务端持有d2，完整的私钥不会出现在任何一方。
+//
+// 客户端持有Paillier私钥，服务端只能看到客户端数据的Paillier密文，联合随机数与签名值在密文上计算。
+//
+// 密钥生成：
+// 1. 客户端随机选择d1，发送 P1 = d1^-1 * G、对d1^-1的知识证明、Paillier公钥以及 Enc(d1)
+// 2. 服务端随机选择d2，计算 W = d2^-1 * P1，公钥 P = W - G = (d1*d2)^-1 * G - G，
+// 并附带DLEQ证明说明 W 与 d2^-1 * G 使用了相同的d2^-1；服务端保存 Enc(d1)
+// 3. 客户端验证证明后得到同样的公钥P
+//
+// 签名（e为待签名的摘要）：
+// 1. 客户端随机选择k1，发送 Q1 = k1 * G、Enc(d1 * k1)、e，以及对k1的知识证明
+// 2. 服务端随机选择k2、k3，计算 (x1, y1) = k3 * Q1 + k2 * G，r = (x1 + e) mod n，
+// 利用Paillier同态计算 C = Enc(d1*k1)^(d2*k3) * Enc(d1)^(d2*(r + k2)) * Enc(ρ*n)，发送(r, C)，
+// 其中ρ为随机掩码，用于统计隐藏明文在整数上的取值
+// 3. 客户端解密得到 Dec(C) = d1*d2*(k1*k3 + k2 + r) mod n，计算 s = Dec(C) - r mod n
+// 此时 k = k1*k3 + k2，s = d1*d2*(k + r) - r = (1 + d)^-1 * (k + r) - r = (1 + d)^-1 * (k - r*d)，即标准的SM2签名
+// 4. 客户端使用公钥验证签名后再输出
+//
+// 本协议中的Paillier密文不附带零知识证明，只能抵抗半诚实的参与者：
+// 服务端返回错误的数据时，客户端只会得到无法通过验证的签名并报错。
+
+const (
+	contextKeyGenClient = "cu_crypto/threshold_sm2/2p/keygen/client"
+	contextKeyGenServer = "cu_crypto/threshold_sm2/2p/keygen/server"
+	contextSignClient   = "cu_crypto/threshold_sm2/2p/sign/client"
+)
+
+var (
+	InvalidInputParamsError = errors.New("Invalid input params")
+	InvalidProofError       = errors.New("The zero-knowledge proof is invalid")
+	InvalidDigestError      = errors.New("The digest to be signed should not be empty")
+	InvalidSignatureError   = errors.New("The generated signature can not be verified")
+	InvalidResponseError    = errors.New("The response of the counterparty is invalid")
+	SessionAlreadyUsedError = errors.New("The signing session has already been used")
+)
+
+// ClientKeyShare 客户端的私钥份额d1、Paillier私钥以及公钥
+type ClientKeyShare struct {
+	D1          *big.Int
+	PaillierKey *paillier.PrivateKey
+	PublicKey   *ecdsa.PublicKey
+}
+
+// ServerKeyShare 服务端的私钥份额d2、客户端的Paillier公钥与 Enc(d1)，以及公钥
+type ServerKeyShare struct {
+	D2                *big.Int
+	PaillierPublicKey *paillier.PublicKey
+	EncryptedD1       *big.Int
+	PublicKey         *ecdsa.PublicKey
+}
+
+// KeyGenRequest 客户端发送给服务端的密钥生成请求
+type KeyGenRequest struct {
+	// P1 = d1^-1 * G
+	P1                []byte
+	Proof             []byte
+	PaillierPublicKey *paillier.PublicKey
+	// Enc(d1)
+	EncryptedD1 []byte
+}
+
+// KeyGenResponse 服务端返回的密钥生成结果
+type KeyGenResponse struct {
+	// D2Point = d2^-1 * G，W = d2^-1 * P1
+	D2Point []byte
+	W       []byte
+	Proof   []byte
+}
+
+// ClientKeyGen 客户端在密钥生成过程中的状态
+type ClientKeyGen struct {
+	d1          *big.Int
+	p1          *ecc.Point
+	paillierKey *paillier.PrivateKey
+}
+
+// SignRequest 客户端发送给服务端的签名请求
+type SignRequest struct {
+	// Q1 = k1 * G
+	Q1    []byte
+	Proof []byte
+	// Enc(d1 * k1)
+	EncryptedD1K1 []byte
+	Digest        []byte
+}
+
+// SignResponse 服务端返回的r以及部分签名的Paillier密文
+type SignResponse struct {
+	R []byte
+	// Enc(d1*d2*(k1*k3 + k2 + r) + ρ*n)
+	EncryptedS []byte
+}
+
+// ClientSignSession 客户端一次签名的状态，随机数k1只能使用一次
+type ClientSignSession struct {
+	keyShare *ClientKeyShare
+	k1       *big.Int
+	digest   []byte
+	used     bool
+}
+
+// NewClientKeyGen 客户端使用自己的Paillier私钥开始生成密钥，返回需要发送给服务端的请求
+func NewClientKeyGen(paillierKey *paillier.PrivateKey) (*ClientKeyGen, *KeyGenRequest, error) {
+	if paillierKey == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	curve := sm2.P256Sm2()
+	if !twoPartyPaillierKeySize(curve, &paillierKey.PublicKey) {
+		return nil, nil, PaillierKeyTooSmallError
+	}
+	d1, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	d1Inv := new(big.Int).ModInverse(d1, curve.Params().N)
+	p1 := ecc.ScalarBaseMult(curve, d1Inv)
+
+	proof, err := ecc.ProveDiscreteLog(curve, d1Inv, nil, []byte(contextKeyGenClient))
+	if err != nil {
+		return nil, nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	p1Bytes, err := p1.MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encD1, err := paillierKey.Encrypt(d1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyGen := &ClientKeyGen{d1: d1, p1: p1, paillierKey: paillierKey}
+	req := &KeyGenRequest{
+		P1:                p1Bytes,
+		Proof:             proofBytes,
+		PaillierPublicKey: &paillier.PublicKey{N: paillierKey.N, G: paillierKey.G},
+		EncryptedD1:       encD1.Bytes(),
+	}
+	return keyGen, req, nil
+}
+
+// ServerKeyGen 服务端处理客户端的密钥生成请求，返回服务端的私钥份额与需要回复给客户端的数据
+func ServerKeyGen(req *KeyGenRequest) (*ServerKeyShare, *KeyGenResponse, error) {
+	if req == nil || req.PaillierPublicKey == nil || req.PaillierPublicKey.N == nil || req.PaillierPublicKey.G == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	curve := sm2.P256Sm2()
+	p1, err := verifyPoK(curve, req.P1, req.Proof, contextKeyGenClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !twoPartyPaillierKeySize(curve, req.PaillierPublicKey) {
+		return nil, nil, PaillierKeyTooSmallError
+	}
+	encD1 := new(big.Int).SetBytes(req.EncryptedD1)
+	if !validCiphertext(req.PaillierPublicKey, encD1) {
+		return nil, nil, InvalidCiphertextError
+	}
+
+	d2, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	d2Inv := new(big.Int).ModInverse(d2, curve.Params().N)
+
+	proof, err := ecc.ProveDLEQ(curve, d2Inv, ecc.BasePoint(curve), p1, []byte(contextKeyGenServer))
+	if err != nil {
+		return nil, nil, err
+	}
+	d2Point := ecc.ScalarBaseMult(curve, d2Inv)
+	w := p1.ScalarMult(d2Inv)
+
+	publicKey, err := publicKeyFromW(w)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	d2Bytes, err := d2Point.MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	wBytes, err := w.MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyShare := &ServerKeyShare{
+		D2:                d2,
+		PaillierPublicKey: req.PaillierPublicKey,
+		EncryptedD1:       encD1,
+		PublicKey:         publicKey,
+	}
+	resp := &KeyGenResponse{D2Point: d2Bytes, W: wBytes, Proof: proofBytes}
+	return keyShare, resp, nil
+}
+
+// Finish 客户端验证服务端的回复，得到客户端的私钥份额与公钥
+func (keyGen *ClientKeyGen) Finish(resp *KeyGenResponse) (*ClientKeyShare, error) {
+	if resp == nil {
+		return nil, InvalidInputParamsError
+	}
+	curve := sm2.P256Sm2()
+
+	d2Point, err := ecc.UnmarshalPoint(curve, resp.D2Point)
+	if err != nil {
+		return nil, InvalidResponseError
+	}
+	w, err := ecc.UnmarshalPoint(curve, resp.W)
+	if err != nil {
+		return nil, InvalidResponseError
+	}
+	proof, err := ecc.UnmarshalDLEQProof(curve, resp.Proof)
+	if err != nil {
+		return nil, InvalidProofError
+	}
+	statement := &ecc.DLEQStatement{G1: ecc.BasePoint(curve), H1: d2Point, G2: keyGen.p1, H2: w}
+	if !ecc.VerifyDLEQ(statement, proof, []byte(contextKeyGenServer)) {
+		return nil, InvalidProofError
+	}
+
+	publicKey, err := publicKeyFromW(w)
+	if err != nil {
+		return nil, err
+	}
+	return &ClientKeyShare{D1: keyGen.d1, PaillierKey: keyGen.paillierKey, PublicKey: publicKey}, nil
+}
+
+// StartSign 客户端开始对摘要e签名，返回需要发送给服务端的请求
+func (keyShare *ClientKeyShare) StartSign(digest []byte) (*ClientSignSession, *SignRequest, error) {
+	if keyShare.PaillierKey == nil {
+		return nil, nil, InvalidInputParamsError
+	}
+	if len(digest) == 0 {
+		return nil, nil, InvalidDigestError
+	}
+	curve := sm2.P256Sm2()
+
+	k1, err := ecc.RandomScalar(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	q1, err := ecc.ScalarBaseMult(curve, k1).MarshalCompressed()
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := ecc.ProveDiscreteLog(curve, k1, nil, signContext(digest))
+	if err != nil {
+		return nil, nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, nil, err
+	}
+	d1k1 := new(big.Int).Mul(keyShare.D1, k1)
+	d1k1.Mod(d1k1, curve.Params().N)
+	encD1K1, err := keyShare.PaillierKey.Encrypt(d1k1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := &ClientSignSession{
+		keyShare: keyShare,
+		k1:       k1,
+		digest:   append([]byte{}, digest...),
+	}
+	req := &SignRequest{
+		Q1:            q1,
+		Proof:         proofBytes,
+		EncryptedD1K1: encD1K1.Bytes(),
+		Digest:        append([]byte{}, digest...),
+	}
+	return session, req, nil
+}
+
+// Sign 服务端计算r以及部分签名的密文 C = Enc(d1*k1)^(d2*k3) * Enc(d1)^(d2*(r + k2)) * Enc(ρ*n)
+func (keyShare *ServerKeyShare) Sign(req *SignRequest) (*SignResponse, error) {
+	if req == nil || keyShare.PaillierPublicKey == nil || keyShare.EncryptedD1 == nil {
+		return nil, InvalidInputParamsError
+	}
+	if len(req.Digest) == 0 {
+		return nil, InvalidDigestError
+	}
+	curve := sm2.P256Sm2()
+	n := curve.Params().N
+
+	q1, err := verifyPoK(curve, req.Q1, req.Proof, string(signContext(req.Digest)))
+	if err != nil {
+		return nil, err
+	}
+	pk := keyShare.PaillierPublicKey
+	encD1K1 := new(big.Int).SetBytes(req.EncryptedD1K1)
+	if !validCiphertext(pk, encD1K1) {
+		return nil, InvalidCiphertextError
+	}
+	e := new(big.Int).SetBytes(req.Digest)
+
+	var r, k2, k3 *big.Int
+	for {
+		k2, err = ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+		k3, err = ecc.RandomScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+
+		// (x1, y1) = k3 * Q1 + k2 * G
+		p := ecc.MultiScalarMult(curve, []*big.Int{k3, k2}, []*ecc.Point{q1, ecc.BasePoint(curve)})
+
+		r = new(big.Int).Add(p.X, e)
+		r.Mod(r, n)
+		if r.Sign() != 0 {
+			break
+		}
+	}
+
+	// s2 = d2 * k3，s3 = d2 * (r + k2)
+	s2 := new(big.Int).Mul(keyShare.D2, k3)
+	s2.Mod(s2, n)
+	s3 := new(big.Int).Add(r, k2)
+	s3.Mul(s3, keyShare.D2)
+	s3.Mod(s3, n)
+
+	// ρ*n不改变模n的结果，使用新的随机数加密，使得结果密文的随机数与客户端的密文无关
+	bound := new(big.Int).Lsh(big.NewInt(1), uint(2*n.BitLen()+mtaMaskBits))
+	rho, err := rand.Int(rand.Reader, bound)
+	if err != nil {
+		return nil, err
+	}
+	encMask, err := pk.Encrypt(rho.Mul(rho, n))
+	if err != nil {
+		return nil, err
+	}
+	encS := pk.CyphersAdd(pk.CypherPlainMultiply(encD1K1, s2), pk.CypherPlainMultiply(keyShare.EncryptedD1, s3), encMask)
+	k2.SetInt64(0)
+	k3.SetInt64(0)
+
+	resp := &SignResponse{
+		R:          ecc.ScalarBytes(curve, r),
+		EncryptedS: encS.Bytes(),
+	}
+	return resp, nil
+}
+
+// Finish 客户端解密得到最终签名 s = Dec(C) - r，并返回ASN.1编码的SM2签名
+func (session *ClientSignSession) Finish(resp *SignResponse) ([]byte, error) {
+	if resp == nil {
+		return nil, InvalidInputParamsError
+	}
+	if session.used {
+		return nil, SessionAlreadyUsedError
+	}
+	curve := sm2.P256Sm2()
+	n := curve.Params().N
+
+	paillierKey := session.keyShare.PaillierKey
+	r := new(big.Int).SetBytes(resp.R)
+	encS := new(big.Int).SetBytes(resp.EncryptedS)
+	if r.Sign() == 0 || r.Cmp(n) >= 0 || !validCiphertext(&paillierKey.PublicKey, encS) {
+		return nil, InvalidResponseError
+	}
+
+	k1 := session.k1
+	session.used = true
+	session.k1 = nil
+	k1.SetInt64(0)
+
+	s := paillierKey.Decrypt(encS)
+	s.Sub(s, r)
+	s.Mod(s, n)
+
+	return finishSignature(session.keyShare.PublicKey, session.digest, r, s)
+}
+
+// MessageDigest 计算SM2标准的消息摘要 e = SM3(ZA || M)，使用该摘要生成的签名可以通过sm2.Sm2Verify验证
+func MessageDigest(publicKey *ecdsa.PublicKey, message, uid []byte) ([]byte, error) {
+	if publicKey == nil {
+		return nil, InvalidInputParamsError
+	}
+	pub := &sm2.PublicKey{Curve: sm2.P256Sm2(), X: publicKey.X, Y: publicKey.Y}
+	za, err := sm2.ZA(pub, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	h := sm3.New()
+	h.Write(za)
+	h.Write(message)
+	return h.Sum(nil), nil
+}
+
+// finishSignature 使用公钥验证签名，验证通过后返回ASN.1编码
+func finishSignature(publicKey *ecdsa.PublicKey, digest []byte, r, s *big.Int) ([]byte, error) {
+	pub := &sm2.PublicKey{Curve: sm2.P256Sm2(), X: publicKey.X, Y: publicKey.Y}
+	if s.Sign() == 0 || !sm2.Verify(pub, digest, r, s) {
+		return nil, InvalidSignatureError
+	}
+	return sm2.SignDigitToSignData(r, s)
+}
+
+// publicKeyFromW P = W - G
+func publicKeyFromW(w *ecc.Point) (*ecdsa.PublicKey, error) {
+	p := ecc.AddPoints(w, ecc.BasePoint(w.Curve).Neg())
+	if p.IsInfinity() {
+		return nil, InvalidResponseError
+	}
+	return &ecdsa.PublicKey{Curve: p.Curve, X: p.X, Y: p.Y}, nil
+}
+
+func verifyPoK(curve elliptic.Curve, pointBytes, proofBytes []byte, context string) (*ecc.Point, error) {
+	point, err := ecc.UnmarshalPoint(curve, pointBytes)
+	if err != nil {
+		return nil, InvalidProofError
+	}
+	proof, err := ecc.UnmarshalSchnorrProof(curve, proofBytes)
+	if err != nil {
+		return nil, InvalidProofError
+	}
+	if !ecc.VerifyDiscreteLog(point, nil, proof, []byte(context)) {
+		return nil, InvalidProofError
+	}
+	return point, nil
+}
+
+// twoPartyPaillierKeySize Paillier明文空间需要容纳 d1*d2*(k1*k3 + k2 + r) 在整数上的取值以及掩码ρ*n
+func twoPartyPaillierKeySize(curve elliptic.Curve, pk *paillier.PublicKey) bool {
+	return pk.N != nil && pk.N.BitLen() > 3*curve.Params().N.BitLen()+mtaMaskBits+1
+}
+
+func signContext(digest []byte) []byte {
+	return append([]byte(contextSignClient+"/"), digest...)
+}
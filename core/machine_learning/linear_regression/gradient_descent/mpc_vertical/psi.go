@@ -16,6 +16,7 @@ package mpc_vertical
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"math/big"
 
 	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
 	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
@@ -72,8 +73,12 @@ type Empty struct{}
 var empty Empty
 
 // 样本加密集合
+// EncIDs的key为加密后的点的SEC1编码，Format指明编码格式，零值为非压缩格式
 type EncSet struct {
 	EncIDs map[string]int
+	Format int
+	// 是否经过了DLEQ证明的验证，只在本地有效，不参与序列化
+	verified bool
 }
 
 // 参与方分别对自己的样本特征ID进行计算，通过如下方式获得每个样本特征ID的加密公钥集合：
@@ -82,104 +87,165 @@ type EncSet struct {
 //	  Bob: Pub'Bi=HP(ID-Bi)^Prv'B=HashToCurve(ID-Bi)^Prv'B
 
 func SM2EncryptSampleIDSet(sampleID []string, privateKey *sm2.PrivateKey) *EncSet {
-	curve := privateKey.Curve
-
-	encIDs := make(map[string]int)
-
-	for i := 0; i < len(sampleID); i++ {
-		eccPoint, _ := ecc.HashToCurve([]byte(sampleID[i]), curve)
-
-		newX, newY := curve.ScalarMult(eccPoint.X, eccPoint.Y, privateKey.D.Bytes())
-
-		id := string(elliptic.Marshal(curve, newX, newY))
-
-		encIDs[id] = i
-	}
-
-	encSet := &EncSet{
-		EncIDs: encIDs,
-	}
-
+	encSet, _ := encryptSampleIDSet(sampleID, privateKey.Curve, privateKey.D, ecc.PointFormatUncompressed)
 	return encSet
 }
 
 func EncryptSampleIDSet(sampleID []string, privateKey *ecdsa.PrivateKey) *EncSet {
-	curve := privateKey.Curve
+	encSet, _ := encryptSampleIDSet(sampleID, privateKey.Curve, privateKey.D, ecc.PointFormatUncompressed)
+	return encSet
+}
+
+// SM2EncryptSampleIDSetWithFormat 使用指定的点编码格式加密样本特征ID集合，压缩格式可以减少一半的传输量
+func SM2EncryptSampleIDSetWithFormat(sampleID []string, privateKey *sm2.PrivateKey, format int) (*EncSet, error) {
+	return encryptSampleIDSet(sampleID, privateKey.Curve, privateKey.D, format)
+}
 
+// EncryptSampleIDSetWithFormat 使用指定的点编码格式加密样本特征ID集合，压缩格式可以减少一半的传输量
+func EncryptSampleIDSetWithFormat(sampleID []string, privateKey *ecdsa.PrivateKey, format int) (*EncSet, error) {
+	return encryptSampleIDSet(sampleID, privateKey.Curve, privateKey.D, format)
+}
+
+func encryptSampleIDSet(sampleID []string, curve elliptic.Curve, d *big.Int, format int) (*EncSet, error) {
 	encIDs := make(map[string]int)
 
 	for i := 0; i < len(sampleID); i++ {
-		eccPoint, _ := ecc.HashToCurve([]byte(sampleID[i]), curve)
+		eccPoint, err := ecc.HashToCurve([]byte(sampleID[i]), curve)
+		if err != nil {
+			return nil, err
+		}
 
-		newX, newY := curve.ScalarMult(eccPoint.X, eccPoint.Y, privateKey.D.Bytes())
+		newX, newY := curve.ScalarMult(eccPoint.X, eccPoint.Y, d.Bytes())
 
-		id := string(elliptic.Marshal(curve, newX, newY))
+		id, err := ecc.MarshalPoint(curve, newX, newY, format)
+		if err != nil {
+			return nil, err
+		}
 
-		encIDs[id] = i
+		encIDs[string(id)] = i
 	}
 
 	encSet := &EncSet{
 		EncIDs: encIDs,
+		Format: format,
 	}
 
-	return encSet
+	return encSet, nil
 }
 
 // 参与方使用自己的公钥对其它方的样本特征ID加密集合进行二次加密，例如：
 //
 //	Alice: Pub'Bi-A=Pub'Bi^Prv'A=Hash(ID-Bi)*G^Prv'B^Prv'A
 //	  Bob: Pub'Ai-B=Pub'Ai^Prv'B=Hash(ID-Ai)*G^Prv'A^Prv'B
+//
+// 输出使用与输入相同的编码格式，输入中无法解析或不在曲线上的点会导致返回错误
 
-func SM2ReEncryptIDSet(encSet *EncSet, privateKey *sm2.PrivateKey) *EncSet {
-	curve := privateKey.PublicKey.Curve
-
-	//	var encIDs []*ID
-	encIDs := make(map[string]int)
-
-	for idstr, value := range encSet.EncIDs {
-		// Pub'Bi^Prv'A
-		x, y := elliptic.Unmarshal(curve, []byte(idstr))
-
-		newX, newY := curve.ScalarMult(x, y, privateKey.D.Bytes())
-
-		id := string(elliptic.Marshal(curve, newX, newY))
-
-		encIDs[id] = value
+func SM2ReEncryptIDSet(encSet *EncSet, privateKey *sm2.PrivateKey) (*EncSet, error) {
+	if encSet == nil {
+		return nil, ErrEmptyEncSet
 	}
+	return reEncryptIDSet(encSet, privateKey.PublicKey.Curve, privateKey.D, encSet.Format)
+}
 
-	newEncSet := &EncSet{
-		EncIDs: encIDs,
+func ReEncryptIDSet(encSet *EncSet, privateKey *ecdsa.PrivateKey) (*EncSet, error) {
+	if encSet == nil {
+		return nil, ErrEmptyEncSet
 	}
+	return reEncryptIDSet(encSet, privateKey.PublicKey.Curve, privateKey.D, encSet.Format)
+}
 
-	return newEncSet
+// SM2ReEncryptIDSetWithFormat 二次加密，输入集合的编码格式自动识别，输出使用指定的编码格式
+// 输入中不在曲线上的点会导致返回错误
+func SM2ReEncryptIDSetWithFormat(encSet *EncSet, privateKey *sm2.PrivateKey, format int) (*EncSet, error) {
+	return reEncryptIDSet(encSet, privateKey.PublicKey.Curve, privateKey.D, format)
 }
 
-func ReEncryptIDSet(encSet *EncSet, privateKey *ecdsa.PrivateKey) *EncSet {
-	curve := privateKey.PublicKey.Curve
+// ReEncryptIDSetWithFormat 二次加密，输入集合的编码格式自动识别，输出使用指定的编码格式
+// 输入中不在曲线上的点会导致返回错误
+func ReEncryptIDSetWithFormat(encSet *EncSet, privateKey *ecdsa.PrivateKey, format int) (*EncSet, error) {
+	return reEncryptIDSet(encSet, privateKey.PublicKey.Curve, privateKey.D, format)
+}
 
-	//	var encIDs []*ID
+func reEncryptIDSet(encSet *EncSet, curve elliptic.Curve, d *big.Int, format int) (*EncSet, error) {
 	encIDs := make(map[string]int)
 
 	for idstr, value := range encSet.EncIDs {
 		// Pub'Bi^Prv'A
-		x, y := elliptic.Unmarshal(curve, []byte(idstr))
+		point, err := ecc.UnmarshalPoint(curve, []byte(idstr))
+		if err != nil {
+			return nil, err
+		}
 
-		newX, newY := curve.ScalarMult(x, y, privateKey.D.Bytes())
+		newX, newY := curve.ScalarMult(point.X, point.Y, d.Bytes())
 
-		id := string(elliptic.Marshal(curve, newX, newY))
+		id, err := ecc.MarshalPoint(curve, newX, newY, format)
+		if err != nil {
+			return nil, err
+		}
 
-		encIDs[id] = value
+		encIDs[string(id)] = value
 	}
 
 	newEncSet := &EncSet{
 		EncIDs: encIDs,
+		Format: format,
 	}
 
-	return newEncSet
+	return newEncSet, nil
 }
 
 // 加密样本对齐
-func Intersect(sampleID []string, reEncSetLocal *EncSet, reEncSetOthers []*EncSet) []string {
+// 只接受经过DLEQ证明验证的集合（见VerifyReEncryptedIDSet）以及本地附带证明计算的集合，
+// 其余集合返回ErrUnverifiedEncSet
+func Intersect(sampleID []string, reEncSetLocal *EncSet, reEncSetOthers []*EncSet) ([]string, error) {
+	if reEncSetLocal != nil && !reEncSetLocal.verified {
+		return nil, ErrUnverifiedEncSet
+	}
+	for _, reEncSetOther := range reEncSetOthers {
+		if reEncSetOther != nil && !reEncSetOther.verified {
+			return nil, ErrUnverifiedEncSet
+		}
+	}
+
+	return intersect(sampleID, reEncSetLocal, reEncSetOthers)
+}
+
+// UnverifiedIntersect 不验证集合来源的加密样本对齐，对方可以通过丢弃或篡改元素推测我方的样本ID，
+// 只适用于参与方均为半诚实的场景
+func UnverifiedIntersect(sampleID []string, reEncSetLocal *EncSet, reEncSetOthers []*EncSet) ([]string, error) {
+	return intersect(sampleID, reEncSetLocal, reEncSetOthers)
+}
+
+// 各方集合的编码格式不一致时，统一转换为压缩格式后再对比，集合中无法解析的点会导致返回错误
+func intersect(sampleID []string, reEncSetLocal *EncSet, reEncSetOthers []*EncSet) ([]string, error) {
+	if reEncSetLocal == nil {
+		return nil, ErrEmptyEncSet
+	}
+	mixedFormat := false
+	for _, reEncSetOther := range reEncSetOthers {
+		if reEncSetOther == nil {
+			return nil, ErrEmptyEncSet
+		}
+		if reEncSetOther.Format != reEncSetLocal.Format {
+			mixedFormat = true
+		}
+	}
+	if mixedFormat {
+		var err error
+		reEncSetLocal, err = compressEncSet(reEncSetLocal)
+		if err != nil {
+			return nil, err
+		}
+		compressedOthers := make([]*EncSet, len(reEncSetOthers))
+		for i, reEncSetOther := range reEncSetOthers {
+			compressedOthers[i], err = compressEncSet(reEncSetOther)
+			if err != nil {
+				return nil, err
+			}
+		}
+		reEncSetOthers = compressedOthers
+	}
+
 	idSetLocal := reEncSetLocal.EncIDs
 	//	idSetOther := reEncSetOther.EncIDs
 
@@ -211,5 +277,28 @@ func Intersect(sampleID []string, reEncSetLocal *EncSet, reEncSetOthers []*EncSe
 	//
 	//	return encSet
 
-	return intersection
+	return intersection, nil
+}
+
+// compressEncSet 将集合中的点统一转换为压缩格式，存在无法解析的元素时返回错误
+func compressEncSet(encSet *EncSet) (*EncSet, error) {
+	if encSet.Format == ecc.PointFormatCompressed {
+		return encSet, nil
+	}
+
+	encIDs := make(map[string]int, len(encSet.EncIDs))
+	for idstr, value := range encSet.EncIDs {
+		id, err := ecc.CompressPoint([]byte(idstr))
+		if err != nil {
+			return nil, err
+		}
+		encIDs[string(id)] = value
+	}
+
+	compressed := &EncSet{
+		EncIDs: encIDs,
+		Format: ecc.PointFormatCompressed,
+	}
+
+	return compressed, nil
 }
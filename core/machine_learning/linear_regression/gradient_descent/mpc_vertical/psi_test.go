@@ -18,8 +18,10 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/json"
+	"sort"
 	"testing"
 
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
 	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
 )
 
@@ -92,15 +94,30 @@ func TestPSI(t *testing.T) {
 	encSetC := EncryptSampleIDSet(sampleIDsC, privateKeyC)
 
 	// 计算A和B的隐私交集
-	sm2reEncSetB := SM2ReEncryptIDSet(sm2encSetB, sm2PrivateKeyA)
-	sm2reEncSetA := SM2ReEncryptIDSet(sm2encSetA, sm2PrivateKeyB)
+	sm2reEncSetB, err := SM2ReEncryptIDSet(sm2encSetB, sm2PrivateKeyA)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSet failed: %v", err)
+	}
+	sm2reEncSetA, err := SM2ReEncryptIDSet(sm2encSetA, sm2PrivateKeyB)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSet failed: %v", err)
+	}
 
-	reEncSetB := ReEncryptIDSet(encSetB, privateKeyA)
-	reEncSetA := ReEncryptIDSet(encSetA, privateKeyB)
+	reEncSetB, err := ReEncryptIDSet(encSetB, privateKeyA)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
+	reEncSetA, err := ReEncryptIDSet(encSetA, privateKeyB)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
 
 	var sm2reEncSetOthers []*EncSet
 	sm2reEncSetOthers = append(sm2reEncSetOthers, sm2reEncSetB)
-	sm2intersection := Intersect(sampleIDsA, sm2reEncSetA, sm2reEncSetOthers)
+	sm2intersection, err := UnverifiedIntersect(sampleIDsA, sm2reEncSetA, sm2reEncSetOthers)
+	if err != nil {
+		t.Fatalf("UnverifiedIntersect failed: %v", err)
+	}
 	sm2jsonIntersection, err := json.Marshal(sm2intersection)
 	if err != nil {
 		t.Errorf("failed to marshal A and B intersection: %v", err)
@@ -110,7 +127,10 @@ func TestPSI(t *testing.T) {
 	var reEncSetOthers []*EncSet
 	reEncSetOthers = append(reEncSetOthers, reEncSetB)
 
-	intersection := Intersect(sampleIDsA, reEncSetA, reEncSetOthers)
+	intersection, err := UnverifiedIntersect(sampleIDsA, reEncSetA, reEncSetOthers)
+	if err != nil {
+		t.Fatalf("UnverifiedIntersect failed: %v", err)
+	}
 
 	jsonIntersection, err := json.Marshal(intersection)
 	if err != nil {
@@ -119,20 +139,41 @@ func TestPSI(t *testing.T) {
 	t.Logf("intersection of A and B is %s", jsonIntersection)
 
 	// 计算A、B、C的隐私交集
-	reEncSetBA := ReEncryptIDSet(encSetB, privateKeyA)
-	reEncSetBAC := ReEncryptIDSet(reEncSetBA, privateKeyC)
+	reEncSetBA, err := ReEncryptIDSet(encSetB, privateKeyA)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
+	reEncSetBAC, err := ReEncryptIDSet(reEncSetBA, privateKeyC)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
 
-	reEncSetAB := ReEncryptIDSet(encSetA, privateKeyB)
-	reEncSetABC := ReEncryptIDSet(reEncSetAB, privateKeyC)
+	reEncSetAB, err := ReEncryptIDSet(encSetA, privateKeyB)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
+	reEncSetABC, err := ReEncryptIDSet(reEncSetAB, privateKeyC)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
 
-	reEncSetCA := ReEncryptIDSet(encSetC, privateKeyA)
-	reEncSetCAB := ReEncryptIDSet(reEncSetCA, privateKeyB)
+	reEncSetCA, err := ReEncryptIDSet(encSetC, privateKeyA)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
+	reEncSetCAB, err := ReEncryptIDSet(reEncSetCA, privateKeyB)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
 
 	var reEncSetOthers2 []*EncSet
 	reEncSetOthers2 = append(reEncSetOthers2, reEncSetBAC)
 	reEncSetOthers2 = append(reEncSetOthers2, reEncSetCAB)
 
-	intersection = Intersect(sampleIDsA, reEncSetABC, reEncSetOthers2)
+	intersection, err = UnverifiedIntersect(sampleIDsA, reEncSetABC, reEncSetOthers2)
+	if err != nil {
+		t.Fatalf("UnverifiedIntersect failed: %v", err)
+	}
 
 	jsonIntersection, err = json.Marshal(intersection)
 	if err != nil {
@@ -140,3 +181,191 @@ func TestPSI(t *testing.T) {
 	}
 	t.Logf("intersection of A、B、C is %s", jsonIntersection)
 }
+
+func TestPSIWithCompressedFormat(t *testing.T) {
+	sampleIDsA := []string{"10000", "10001", "10002", "10003"}
+	sampleIDsB := []string{"10001", "10003", "10005"}
+
+	privateKeyA, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("privateKeyA generation failed: %v", err)
+	}
+	privateKeyB, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("privateKeyB generation failed: %v", err)
+	}
+
+	encSetA, err := EncryptSampleIDSetWithFormat(sampleIDsA, privateKeyA, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("EncryptSampleIDSetWithFormat failed: %v", err)
+	}
+	for id := range encSetA.EncIDs {
+		if len(id) != 33 {
+			t.Fatalf("compressed point length is %d, 33 expected", len(id))
+		}
+	}
+	encSetB := EncryptSampleIDSet(sampleIDsB, privateKeyB)
+
+	// B的集合使用非压缩格式，二次加密时自动识别并转换为压缩格式
+	reEncSetB, err := ReEncryptIDSetWithFormat(encSetB, privateKeyA, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSetWithFormat failed: %v", err)
+	}
+	// A的集合保持压缩格式，UnverifiedIntersect会统一编码格式
+	reEncSetA, err := ReEncryptIDSet(encSetA, privateKeyB)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSet failed: %v", err)
+	}
+	uncompressedReEncSetA, err := ReEncryptIDSetWithFormat(encSetA, privateKeyB, ecc.PointFormatUncompressed)
+	if err != nil {
+		t.Fatalf("ReEncryptIDSetWithFormat failed: %v", err)
+	}
+
+	for _, local := range []*EncSet{reEncSetA, uncompressedReEncSetA} {
+		intersection, err := UnverifiedIntersect(sampleIDsA, local, []*EncSet{reEncSetB})
+		if err != nil {
+			t.Fatalf("UnverifiedIntersect failed: %v", err)
+		}
+		sort.Strings(intersection)
+		if len(intersection) != 2 || intersection[0] != "10001" || intersection[1] != "10003" {
+			t.Errorf("intersection of A and B is %v", intersection)
+		}
+	}
+
+	// 不在曲线上的点会被拒绝
+	invalid := &EncSet{EncIDs: map[string]int{string(make([]byte, 33)): 0}, Format: ecc.PointFormatCompressed}
+	if _, err := ReEncryptIDSetWithFormat(invalid, privateKeyA, ecc.PointFormatCompressed); err == nil {
+		t.Errorf("invalid point should be rejected")
+	}
+	if _, err := ReEncryptIDSet(invalid, privateKeyA); err == nil {
+		t.Errorf("invalid point should be rejected by ReEncryptIDSet")
+	}
+
+	// 统一编码格式时无法解析的元素返回错误，而不是被丢弃
+	malformed := &EncSet{EncIDs: map[string]int{}}
+	for id, value := range uncompressedReEncSetA.EncIDs {
+		malformed.EncIDs[id] = value
+	}
+	malformed.EncIDs[string(make([]byte, 65))] = 0
+	if _, err := UnverifiedIntersect(sampleIDsA, malformed, []*EncSet{reEncSetB}); err == nil {
+		t.Errorf("malformed element should be rejected")
+	}
+	if _, err := UnverifiedIntersect(sampleIDsA, reEncSetA, []*EncSet{nil}); err != ErrEmptyEncSet {
+		t.Errorf("nil set should be rejected, got %v", err)
+	}
+}
+
+func TestVerifiablePSI(t *testing.T) {
+	sampleIDsA := []string{"10000", "10001", "10002", "10003"}
+	sampleIDsB := []string{"10001", "10003", "10005"}
+
+	privateKeyA, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatalf("privateKeyA generation failed: %v", err)
+	}
+	privateKeyB, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatalf("privateKeyB generation failed: %v", err)
+	}
+
+	encSetA := SM2EncryptSampleIDSet(sampleIDsA, privateKeyA)
+	encSetB := SM2EncryptSampleIDSet(sampleIDsB, privateKeyB)
+
+	// B二次加密A的集合并附带证明，A使用B公开的PSI公钥验证
+	provedSetA, err := SM2ReEncryptIDSetWithProof(encSetA, privateKeyB, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSetWithProof failed: %v", err)
+	}
+	reEncSetA, err := SM2VerifyReEncryptedIDSet(encSetA, provedSetA, &privateKeyB.PublicKey)
+	if err != nil {
+		t.Fatalf("SM2VerifyReEncryptedIDSet failed: %v", err)
+	}
+	// A本地计算的集合无需验证
+	provedSetB, err := SM2ReEncryptIDSetWithProof(encSetB, privateKeyA, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSetWithProof failed: %v", err)
+	}
+
+	intersection, err := Intersect(sampleIDsA, reEncSetA, []*EncSet{provedSetB.EncSet})
+	if err != nil {
+		t.Fatalf("Intersect failed: %v", err)
+	}
+	sort.Strings(intersection)
+	if len(intersection) != 2 || intersection[0] != "10001" || intersection[1] != "10003" {
+		t.Errorf("intersection of A and B is %v", intersection)
+	}
+
+	// 从网络收到的集合只包含导出字段，需要重新验证
+	received := &ProvedEncSet{
+		EncSet: &EncSet{EncIDs: provedSetA.EncSet.EncIDs, Format: provedSetA.EncSet.Format},
+		Proof:  provedSetA.Proof,
+	}
+	if _, err := Intersect(sampleIDsA, received.EncSet, []*EncSet{provedSetB.EncSet}); err != ErrUnverifiedEncSet {
+		t.Errorf("unverified set should be rejected, got %v", err)
+	}
+	// 不带证明的二次加密结果同样被拒绝
+	plainSetB, err := SM2ReEncryptIDSet(encSetB, privateKeyA)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSet failed: %v", err)
+	}
+	if _, err := Intersect(sampleIDsA, reEncSetA, []*EncSet{plainSetB}); err != ErrUnverifiedEncSet {
+		t.Errorf("set without proof should be rejected, got %v", err)
+	}
+	if _, err := SM2VerifyReEncryptedIDSet(encSetA, received, &privateKeyB.PublicKey); err != nil {
+		t.Errorf("SM2VerifyReEncryptedIDSet failed after serialization: %v", err)
+	}
+
+	// 使用错误的公钥
+	if _, err := SM2VerifyReEncryptedIDSet(encSetA, provedSetA, &privateKeyA.PublicKey); err != ErrInvalidReEncProof {
+		t.Errorf("proof with wrong public key should be rejected, got %v", err)
+	}
+
+	// 其中一个元素使用了不同的私钥
+	cheated, err := SM2ReEncryptIDSetWithProof(encSetA, privateKeyB, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSetWithProof failed: %v", err)
+	}
+	otherKey, err := sm2.GenerateKey()
+	if err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+	for id, index := range encSetA.EncIDs {
+		if index != 0 {
+			continue
+		}
+		single := &EncSet{EncIDs: map[string]int{id: index}}
+		forged, err := SM2ReEncryptIDSet(single, otherKey)
+		if err != nil {
+			t.Fatalf("SM2ReEncryptIDSet failed: %v", err)
+		}
+		for forgedID := range forged.EncIDs {
+			forgedCompressed, err := ecc.CompressPoint([]byte(forgedID))
+			if err != nil {
+				t.Fatalf("CompressPoint failed: %v", err)
+			}
+			for cheatedID, cheatedIndex := range cheated.EncSet.EncIDs {
+				if cheatedIndex == 0 {
+					delete(cheated.EncSet.EncIDs, cheatedID)
+				}
+			}
+			cheated.EncSet.EncIDs[string(forgedCompressed)] = 0
+		}
+	}
+	if _, err := SM2VerifyReEncryptedIDSet(encSetA, cheated, &privateKeyB.PublicKey); err != ErrInvalidReEncProof {
+		t.Errorf("forged element should be rejected, got %v", err)
+	}
+
+	// 丢弃元素
+	dropped, err := SM2ReEncryptIDSetWithProof(encSetA, privateKeyB, ecc.PointFormatCompressed)
+	if err != nil {
+		t.Fatalf("SM2ReEncryptIDSetWithProof failed: %v", err)
+	}
+	for id, index := range dropped.EncSet.EncIDs {
+		if index == 1 {
+			delete(dropped.EncSet.EncIDs, id)
+		}
+	}
+	if _, err := SM2VerifyReEncryptedIDSet(encSetA, dropped, &privateKeyB.PublicKey); err != ErrEncSetMismatch {
+		t.Errorf("dropped element should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2021 PaddlePaddle Authors. All Rights Reserved.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mpc_vertical
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
+	"github.com/legendzhouwd/cu_crypto/core/gm/gmsm/sm2"
+)
+
+// 可验证的ECDH-PSI
+//
+// 普通模式下，二次加密方可以对每个元素使用不同的私钥，或者丢弃、篡改元素，从而推测对方的样本ID。
+// 可验证模式下：
+// Step 1: 参与方公开自己的PSI公钥 Pub'A = Prv'A*G
+// Step 2: 参与方二次加密其它方的集合时，附带一个批量DLEQ证明：
+//			对所有的i，Out_i = Prv'A*In_i，并且Pub'A = Prv'A*G
+// Step 3: 集合的提供方按样本索引将自己发出的集合与收到的集合配对，验证证明；
+//			索引集合必须完全一致，否则说明有元素被丢弃或伪造
+// Step 4: 只有验证通过的集合（以及本地附带证明计算的集合）才能参与Intersect
+
+// DLEQ证明的上下文，用于区分不同协议中的证明
+var psiProofContext = []byte("cu_crypto/mpc_vertical/psi")
+
+var (
+	ErrUnverifiedEncSet    = errors.New("encrypted set has not been verified")
+	ErrEncSetMismatch      = errors.New("re-encrypted set does not match the original set")
+	ErrInvalidReEncProof   = errors.New("proof of re-encryption is invalid")
+	ErrDuplicateIndex      = errors.New("sample index appears more than once")
+	ErrEmptyEncSet         = errors.New("encrypted set is empty")
+	ErrPublicKeyNotOnCurve = errors.New("PSI public key is not on the curve")
+)
+
+// ProvedEncSet 二次加密的集合以及批量DLEQ证明
+type ProvedEncSet struct {
+	EncSet *EncSet
+	Proof  []byte
+}
+
+// SM2ReEncryptIDSetWithProof 二次加密并生成DLEQ证明，返回的集合在本地视为已验证
+func SM2ReEncryptIDSetWithProof(encSet *EncSet, privateKey *sm2.PrivateKey, format int) (*ProvedEncSet, error) {
+	return reEncryptIDSetWithProof(encSet, privateKey.PublicKey.Curve, privateKey.D, format)
+}
+
+// ReEncryptIDSetWithProof 二次加密并生成DLEQ证明，返回的集合在本地视为已验证
+func ReEncryptIDSetWithProof(encSet *EncSet, privateKey *ecdsa.PrivateKey, format int) (*ProvedEncSet, error) {
+	return reEncryptIDSetWithProof(encSet, privateKey.PublicKey.Curve, privateKey.D, format)
+}
+
+// SM2VerifyReEncryptedIDSet 验证对方对我方集合encSet的二次加密，验证通过后返回可用于Intersect的集合
+func SM2VerifyReEncryptedIDSet(encSet *EncSet, provedEncSet *ProvedEncSet, publicKey *sm2.PublicKey) (*EncSet, error) {
+	return verifyReEncryptedIDSet(encSet, provedEncSet, publicKey.Curve, publicKey.X, publicKey.Y)
+}
+
+// VerifyReEncryptedIDSet 验证对方对我方集合encSet的二次加密，验证通过后返回可用于Intersect的集合
+func VerifyReEncryptedIDSet(encSet *EncSet, provedEncSet *ProvedEncSet, publicKey *ecdsa.PublicKey) (*EncSet, error) {
+	return verifyReEncryptedIDSet(encSet, provedEncSet, publicKey.Curve, publicKey.X, publicKey.Y)
+}
+
+func reEncryptIDSetWithProof(encSet *EncSet, curve elliptic.Curve, d *big.Int, format int) (*ProvedEncSet, error) {
+	indexes, inputs, err := pointsByIndex(encSet, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	newEncSet, err := reEncryptIDSet(encSet, curve, d, format)
+	if err != nil {
+		return nil, err
+	}
+	// 重复的点会在map中合并，无法逐一证明
+	if len(newEncSet.EncIDs) != len(indexes) {
+		return nil, ErrDuplicateIndex
+	}
+
+	proof, err := ecc.ProveDLEQBatch(curve, d, nil, inputs, psiProofContext)
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := proof.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	newEncSet.verified = true
+	provedEncSet := &ProvedEncSet{
+		EncSet: newEncSet,
+		Proof:  proofBytes,
+	}
+
+	return provedEncSet, nil
+}
+
+func verifyReEncryptedIDSet(encSet *EncSet, provedEncSet *ProvedEncSet, curve elliptic.Curve, x, y *big.Int) (*EncSet, error) {
+	if provedEncSet == nil || provedEncSet.EncSet == nil {
+		return nil, ErrEmptyEncSet
+	}
+	if x == nil || y == nil {
+		return nil, ErrPublicKeyNotOnCurve
+	}
+	publicKey, err := ecc.NewPoint(curve, x, y)
+	if err != nil {
+		return nil, ErrPublicKeyNotOnCurve
+	}
+
+	inputIndexes, inputs, err := pointsByIndex(encSet, curve)
+	if err != nil {
+		return nil, err
+	}
+	outputIndexes, outputs, err := pointsByIndex(provedEncSet.EncSet, curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// 索引集合必须完全一致，防止元素被丢弃或替换
+	if len(inputIndexes) != len(outputIndexes) {
+		return nil, ErrEncSetMismatch
+	}
+	for i := range inputIndexes {
+		if inputIndexes[i] != outputIndexes[i] {
+			return nil, ErrEncSetMismatch
+		}
+	}
+
+	proof, err := ecc.UnmarshalDLEQProof(curve, provedEncSet.Proof)
+	if err != nil {
+		return nil, err
+	}
+	if !ecc.VerifyDLEQBatch(publicKey, nil, inputs, outputs, proof, psiProofContext) {
+		return nil, ErrInvalidReEncProof
+	}
+
+	encIDs := make(map[string]int, len(provedEncSet.EncSet.EncIDs))
+	for id, value := range provedEncSet.EncSet.EncIDs {
+		encIDs[id] = value
+	}
+	verifiedEncSet := &EncSet{
+		EncIDs:   encIDs,
+		Format:   provedEncSet.EncSet.Format,
+		verified: true,
+	}
+
+	return verifiedEncSet, nil
+}
+
+// pointsByIndex 解析集合中的点，并按样本索引升序排列
+func pointsByIndex(encSet *EncSet, curve elliptic.Curve) ([]int, []*ecc.Point, error) {
+	if encSet == nil || len(encSet.EncIDs) == 0 {
+		return nil, nil, ErrEmptyEncSet
+	}
+
+	byIndex := make(map[int]*ecc.Point, len(encSet.EncIDs))
+	for id, value := range encSet.EncIDs {
+		if _, exist := byIndex[value]; exist {
+			return nil, nil, ErrDuplicateIndex
+		}
+		point, err := ecc.UnmarshalPoint(curve, []byte(id))
+		if err != nil {
+			return nil, nil, err
+		}
+		byIndex[value] = point
+	}
+
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	points := make([]*ecc.Point, len(indexes))
+	for i, index := range indexes {
+		points[i] = byIndex[index]
+	}
+
+	return indexes, points, nil
+}
@@ -19,6 +19,7 @@ import (
 	"errors"
 	"math/big"
 
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
 	"github.com/legendzhouwd/cu_crypto/core/ecies"
 	"github.com/legendzhouwd/cu_crypto/core/hash"
 )
@@ -131,6 +132,48 @@ func ReceiverChoose(receiverPrivateKey *ecdsa.PrivateKey, senderPublicKey *ecdsa
 	return newPubKey, nil
 }
 
+// MarshalPublicKey 按SEC1格式编码双方交换的公钥，压缩格式为33字节
+func MarshalPublicKey(publicKey *ecdsa.PublicKey, format int) ([]byte, error) {
+	return ecc.MarshalPoint(publicKey.Curve, publicKey.X, publicKey.Y, format)
+}
+
+// UnmarshalPublicKey 解析SEC1格式的公钥，自动识别压缩与非压缩格式
+func UnmarshalPublicKey(curve elliptic.Curve, data []byte) (*ecdsa.PublicKey, error) {
+	point, err := ecc.UnmarshalPoint(curve, data)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := new(ecdsa.PublicKey)
+	publicKey.Curve = curve
+	publicKey.X = point.X
+	publicKey.Y = point.Y
+
+	return publicKey, nil
+}
+
+// SenderEncryptMsgWithFormat 与SenderEncryptMsg相同，但密文中的临时公钥使用指定的编码格式
+// 使用压缩格式时每份密文可以减少32字节，ReceiverRetrieveMsg会自动识别
+func SenderEncryptMsgWithFormat(senderPrivateKey *ecdsa.PrivateKey, receiverPublicKey *ecdsa.PublicKey, msgs []string, format int) ([]string, error) {
+	cts, err := SenderEncryptMsg(senderPrivateKey, receiverPublicKey, msgs)
+	if err != nil {
+		return nil, err
+	}
+	if format == ecc.PointFormatUncompressed {
+		return cts, nil
+	}
+
+	for i, ct := range cts {
+		compressed, err := compressCipherText(senderPrivateKey.Curve, []byte(ct))
+		if err != nil {
+			return nil, err
+		}
+		cts[i] = string(compressed)
+	}
+
+	return cts, nil
+}
+
 // SenderEncryptMsg 发送方Alice根据接收方Bob发来的公钥，做进一步计算
 func SenderEncryptMsg(senderPrivateKey *ecdsa.PrivateKey, receiverPublicKey *ecdsa.PublicKey, msgs []string) ([]string, error) {
 	curve := senderPrivateKey.Curve
@@ -235,21 +278,51 @@ func ReceiverRetrieveMsg(receiverPrivateKey *ecdsa.PrivateKey, senderPublicKey *
 	privateKey.X, privateKey.Y = curve.ScalarBaseMult(hashP)
 	privateKey.D = new(big.Int).SetBytes(hashP)
 
-	// 如果之前选择的是M(0)
-	if chosenIndex == IndexOne {
-		msg, err := ecies.Decrypt(privateKey, []byte(cts[0]))
-		if err != nil {
-			return "", err
-		}
-
-		return string(msg), nil
+	// 密文中的临时公钥可能是压缩格式，统一还原为非压缩格式
+	ct, err := decompressCipherText(curve, []byte(cts[chosenIndex]))
+	if err != nil {
+		return "", err
 	}
 
-	// 如果之前选择的是M(1)
-	msg, err := ecies.Decrypt(privateKey, []byte(cts[1]))
+	// 如果之前选择的是M(0)，解密s0；否则解密s1
+	msg, err := ecies.Decrypt(privateKey, ct)
 	if err != nil {
 		return "", err
 	}
 
 	return string(msg), nil
 }
+
+// compressCipherText 将ECIES密文开头的临时公钥转换为压缩格式
+func compressCipherText(curve elliptic.Curve, ct []byte) ([]byte, error) {
+	pointLen := 1 + 2*((curve.Params().BitSize+7)/8)
+	if len(ct) < pointLen {
+		return nil, ecc.ErrInvalidPointEncoding
+	}
+
+	point, err := ecc.CompressPoint(ct[:pointLen])
+	if err != nil {
+		return nil, err
+	}
+
+	return append(point, ct[pointLen:]...), nil
+}
+
+// decompressCipherText 将ECIES密文开头的压缩格式临时公钥还原为非压缩格式
+func decompressCipherText(curve elliptic.Curve, ct []byte) ([]byte, error) {
+	pointLen := 1 + (curve.Params().BitSize+7)/8
+	if len(ct) < pointLen || (ct[0] != 0x02 && ct[0] != 0x03) {
+		return ct, nil
+	}
+
+	point, err := ecc.UnmarshalPoint(curve, ct[:pointLen])
+	if err != nil {
+		return nil, err
+	}
+	uncompressed, err := point.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(uncompressed, ct[pointLen:]...), nil
+}
@@ -18,6 +18,8 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"testing"
+
+	"github.com/legendzhouwd/cu_crypto/common/math/ecc"
 )
 
 func TestOT(t *testing.T) {
@@ -48,3 +50,41 @@ func TestOT(t *testing.T) {
 	}
 	t.Logf("msgChosen is: %s", msgChosen)
 }
+
+func TestOTWithCompressedFormat(t *testing.T) {
+	msgs := []string{"msg 0 for ot protocol", "msg 1 for ot protocol"}
+
+	senderPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	receiverPrivateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// 发送方公钥以压缩格式传输
+	senderPublicKeyBytes, err := MarshalPublicKey(&senderPrivateKey.PublicKey, ecc.PointFormatCompressed)
+	if err != nil || len(senderPublicKeyBytes) != 33 {
+		t.Fatalf("MarshalPublicKey err is %v", err)
+	}
+	senderPublicKey, err := UnmarshalPublicKey(elliptic.P256(), senderPublicKeyBytes)
+	if err != nil {
+		t.Fatalf("UnmarshalPublicKey err is %v", err)
+	}
+
+	for _, chosenIndex := range []int{IndexOne, IndexTwo} {
+		chosenPublicKey, err := ReceiverChoose(receiverPrivateKey, senderPublicKey, chosenIndex)
+		if err != nil {
+			t.Fatalf("ReceiverChoose err is %v", err)
+		}
+		cts, err := SenderEncryptMsgWithFormat(senderPrivateKey, chosenPublicKey, msgs, ecc.PointFormatCompressed)
+		if err != nil {
+			t.Fatalf("SenderEncryptMsgWithFormat err is %v", err)
+		}
+		if cts[0][0] != 0x02 && cts[0][0] != 0x03 {
+			t.Fatalf("ephemeral public key in cipher text is not compressed")
+		}
+		msgChosen, err := ReceiverRetrieveMsg(receiverPrivateKey, senderPublicKey, cts, chosenIndex)
+		if err != nil {
+			t.Fatalf("ReceiverRetrieveMsg err is %v", err)
+		}
+		if msgChosen != msgs[chosenIndex] {
+			t.Errorf("msgChosen is %s, %s expected", msgChosen, msgs[chosenIndex])
+		}
+	}
+}